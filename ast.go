@@ -2,18 +2,66 @@ package goclangast
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"hash/maphash"
+	"io"
+	"strconv"
+	"sync"
 
 	"github.com/philpearl/intern"
 	"github.com/valyala/fastjson"
 )
 
+// ParseContext holds the string intern pool used while parsing. A single ParseContext can be shared by multiple
+// goroutines, so strings are deduplicated across all translation units parsed with it. The zero value is ready to use.
 type ParseContext struct {
+	// Strict makes parsing fail on node kinds which are unknown to this package, instead of representing them as
+	// UnknownNode.
+	Strict bool
+
+	initOnce sync.Once
+	seed     maphash.Seed
+	shards   []internShard
+}
+
+// internShards is the number of independently locked intern pools, so goroutines parsing in parallel rarely wait on
+// each other.
+const internShards = 64
+
+type internShard struct {
+	mu        sync.Mutex
 	strIntern *intern.Intern
 }
 
+func NewParseContext() *ParseContext {
+	p := &ParseContext{}
+	p.init()
+	return p
+}
+
+func (p *ParseContext) init() {
+	p.initOnce.Do(func() {
+		p.seed = maphash.MakeSeed()
+		p.shards = make([]internShard, internShards)
+		for i := range p.shards {
+			p.shards[i].strIntern = intern.New(256)
+		}
+	})
+}
+
 func (p *ParseContext) InternBytes(b []byte) string {
-	return p.strIntern.Deduplicate(string(b))
+	// Besides being common, the empty string can't be the first string of an intern pool.
+	if len(b) == 0 {
+		return ""
+	}
+
+	p.init()
+
+	shard := &p.shards[maphash.Bytes(p.seed, b)%internShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	return shard.strIntern.Deduplicate(string(b))
 }
 
 type Node interface {
@@ -31,41 +79,180 @@ type Node interface {
 }
 
 func ParseTU(b *bytes.Buffer) (*TranslationUnitDecl, error) {
-	var p fastjson.Parser
-	ctx := ParseContext{
-		strIntern: intern.New(8 * 1024),
+	return NewParseContext().ParseTU(b)
+}
+
+func (p *ParseContext) ParseTU(b *bytes.Buffer) (*TranslationUnitDecl, error) {
+	var parser fastjson.Parser
+	v, err := parser.ParseBytes(b.Bytes())
+	if err != nil {
+		return nil, err
 	}
-	v, err := p.ParseBytes(b.Bytes())
+
+	node, err := parseNode(v, p)
 	if err != nil {
 		return nil, err
 	}
 
-	node, err := parseNode(v, &ctx)
+	return finishTU(node)
+}
+
+func ParseTUReader(r io.Reader) (*TranslationUnitDecl, error) {
+	return NewParseContext().ParseTUReader(r)
+}
+
+// ParseTUReader parses the JSON AST dump read from r. Unlike ParseTU the dump is not read into memory as a whole, each
+// top-level declaration is parsed as soon as it has been read, so peak memory use is bound by the largest declaration
+// instead of the size of the dump.
+func (p *ParseContext) ParseTUReader(r io.Reader) (*TranslationUnitDecl, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	var (
+		parser fastjson.Parser
+		header = []byte{'{'}
+		inner  []Node
+	)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected object key, got %v", tok)
+		}
+
+		if key != "inner" {
+			var raw json.RawMessage
+			if err = dec.Decode(&raw); err != nil {
+				return nil, err
+			}
+
+			if len(header) > 1 {
+				header = append(header, ',')
+			}
+			header = strconv.AppendQuote(header, key)
+			header = append(header, ':')
+			header = append(header, raw...)
+			continue
+		}
+
+		if err = expectDelim(dec, '['); err != nil {
+			return nil, err
+		}
+
+		for dec.More() {
+			var raw json.RawMessage
+			if err = dec.Decode(&raw); err != nil {
+				return nil, err
+			}
+
+			v, err := parser.ParseBytes(raw)
+			if err != nil {
+				return nil, err
+			}
+
+			child, err := parseNode(v, p)
+			if err != nil {
+				return nil, err
+			}
+
+			if child != nil {
+				inner = append(inner, child)
+			}
+		}
+
+		if err = expectDelim(dec, ']'); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+
+	header = append(header, '}')
+	v, err := parser.ParseBytes(header)
 	if err != nil {
 		return nil, err
 	}
 
-	linkNodes(node)
+	node, err := parseNode(v, p)
+	if err != nil {
+		return nil, err
+	}
 
-	fillOmittedFields(node)
+	if node == nil {
+		return nil, fmt.Errorf("missing kind of top-level node")
+	}
+	node.GetBaseNode().Inner = inner
 
-	return node.(*TranslationUnitDecl), nil
+	return finishTU(node)
 }
 
-var kindMap map[string]func() Node
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
 
-func getKindMap() map[string]func() Node {
-	if kindMap != nil {
-		return kindMap
+	if tok != delim {
+		return fmt.Errorf("expected '%v', got %v", delim, tok)
 	}
 
+	return nil
+}
+
+func finishTU(node Node) (*TranslationUnitDecl, error) {
+	tu, ok := node.(*TranslationUnitDecl)
+	if !ok {
+		return nil, fmt.Errorf("expected TranslationUnitDecl, got %T", node)
+	}
+
+	linkNodes(tu)
+
+	fillOmittedFields(tu)
+
+	tu.buildIndex()
+
+	PreOrderVisit(tu, func(n Node, depth int) error {
+		if _, ok := n.(*UnknownNode); ok {
+			if tu.UnknownKinds == nil {
+				tu.UnknownKinds = make(map[string]int)
+			}
+			tu.UnknownKinds[n.GetBaseNode().Kind]++
+		}
+		return nil
+	})
+
+	return tu, nil
+}
+
+var (
+	kindMap     map[string]func() Node
+	kindMapOnce sync.Once
+)
+
+func getKindMap() map[string]func() Node {
+	kindMapOnce.Do(buildKindMap)
+	return kindMap
+}
+
+func buildKindMap() {
 	kindMap = make(map[string]func() Node)
 	maps := []map[string]func() Node{
 		AttrMap,
 		CommentMap,
+		CXXMap,
 		DeclMap,
 		ExprMap,
 		LiteralMap,
+		ObjCMap,
+		OMPMap,
 		OperatorMap,
 		StmtMap,
 		TypeMap,
@@ -75,8 +262,20 @@ func getKindMap() map[string]func() Node {
 			kindMap[k] = v
 		}
 	}
+}
+
+// lookupKind returns the constructor for nodes of the given kind.
+func lookupKind(kind string) (func() Node, bool) {
+	if nodeFn, found := getKindMap()[kind]; found {
+		return nodeFn, true
+	}
 
-	return kindMap
+	// OpenMP clauses aren't enumerated, every OpenMP version adds new ones.
+	if isOMPClauseKind(kind) {
+		return func() Node { return &OMPClause{} }, true
+	}
+
+	return nil, false
 }
 
 func parseNode(v *fastjson.Value, ctx *ParseContext) (Node, error) {
@@ -86,9 +285,13 @@ func parseNode(v *fastjson.Value, ctx *ParseContext) (Node, error) {
 		return nil, nil
 	}
 
-	nodeFn, found := getKindMap()[string(kind)]
+	nodeFn, found := lookupKind(string(kind))
 	if !found {
-		return nil, fmt.Errorf("unknown node kind: %s", string(kind))
+		if ctx.Strict {
+			return nil, fmt.Errorf("unknown node kind: %s", string(kind))
+		}
+
+		nodeFn = func() Node { return &UnknownNode{} }
 	}
 
 	node := nodeFn()
@@ -97,6 +300,35 @@ func parseNode(v *fastjson.Value, ctx *ParseContext) (Node, error) {
 	return node, err
 }
 
+// UnknownNode represents a node of a kind which is unknown to this package, the kind is kept in BaseNode.Kind.
+type UnknownNode struct {
+	BaseNode
+	// Attributes holds the raw JSON of all fields other than the ones decoded into BaseNode. They are encoded inline by
+	// MarshalNode.
+	Attributes map[string]json.RawMessage `json:"-"`
+}
+
+func (n *UnknownNode) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	obj, err := v.Object()
+	if err != nil {
+		return err
+	}
+
+	obj.Visit(func(key []byte, v *fastjson.Value) {
+		switch string(key) {
+		case "id", "kind", "loc", "range", "inner":
+			return
+		}
+
+		if n.Attributes == nil {
+			n.Attributes = make(map[string]json.RawMessage)
+		}
+		n.Attributes[string(key)] = v.MarshalTo(nil)
+	})
+
+	return n.BaseNode.Unmarshal(v, ctx)
+}
+
 type RawNode struct {
 	Kind string `json:"kind"`
 }
@@ -404,6 +636,8 @@ func nodeLoc(n Node) *Loc {
 	return nodeLoc(p)
 }
 
+// PreOrderVisit calls fn for node and its descendants, parents before children. A non-nil error returned by fn skips
+// the children of the node, use Walk to stop a walk early or propagate errors.
 func PreOrderVisit(node Node, fn func(n Node, depth int) error) {
 	preOrderVisit(node, 0, fn)
 }
@@ -422,6 +656,7 @@ func preOrderVisit(node Node, depth int, fn func(n Node, depth int) error) {
 	}
 }
 
+// PostOrderVisit calls fn for node and its descendants, children before parents. Errors returned by fn are ignored.
 func PostOrderVisit(node Node, fn func(n Node, depth int) error) {
 	postOrderVisit(node, 0, fn)
 }
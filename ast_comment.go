@@ -43,6 +43,11 @@ type BlockCommandComment struct {
 	Name string `json:"name"`
 }
 
+func (c *BlockCommandComment) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	c.Name = string(v.GetStringBytes("name"))
+	return c.BaseNode.Unmarshal(v, ctx)
+}
+
 type VerbatimBlockComment struct {
 	BaseNode
 	Name      string `json:"name"`
@@ -0,0 +1,504 @@
+package goclangast
+
+import (
+	"strings"
+
+	"github.com/valyala/fastjson"
+)
+
+var CXXMap = map[string]func() Node{
+	"NamespaceDecl":        func() Node { return &NamespaceDecl{} },
+	"CXXRecordDecl":        func() Node { return &CXXRecordDecl{} },
+	"CXXMethodDecl":        func() Node { return &CXXMethodDecl{} },
+	"CXXConstructorDecl":   func() Node { return &CXXConstructorDecl{} },
+	"CXXDestructorDecl":    func() Node { return &CXXDestructorDecl{} },
+	"CXXConversionDecl":    func() Node { return &CXXConversionDecl{} },
+	"ClassTemplateDecl":    func() Node { return &ClassTemplateDecl{} },
+	"FunctionTemplateDecl": func() Node { return &FunctionTemplateDecl{} },
+	"TemplateTypeParmDecl": func() Node { return &TemplateTypeParmDecl{} },
+	"AccessSpecDecl":       func() Node { return &AccessSpecDecl{} },
+	"UsingDecl":            func() Node { return &UsingDecl{} },
+	"CXXMemberCallExpr":    func() Node { return &CXXMemberCallExpr{} },
+	"CXXOperatorCallExpr":  func() Node { return &CXXOperatorCallExpr{} },
+	"CXXThisExpr":          func() Node { return &CXXThisExpr{} },
+	"CXXNewExpr":           func() Node { return &CXXNewExpr{} },
+	"CXXDeleteExpr":        func() Node { return &CXXDeleteExpr{} },
+}
+
+// AsRecordDecl returns the RecordDecl of a *RecordDecl or *CXXRecordDecl, or nil for other nodes.
+func AsRecordDecl(n Node) *RecordDecl {
+	if d, ok := n.(interface{ recordDecl() *RecordDecl }); ok {
+		return d.recordDecl()
+	}
+	return nil
+}
+
+func (d *RecordDecl) recordDecl() *RecordDecl {
+	return d
+}
+
+// AsFunctionDecl returns the FunctionDecl of a *FunctionDecl or one of the C++ method declarations, or nil for other
+// nodes.
+func AsFunctionDecl(n Node) *FunctionDecl {
+	if d, ok := n.(interface{ functionDecl() *FunctionDecl }); ok {
+		return d.functionDecl()
+	}
+	return nil
+}
+
+func (d *FunctionDecl) functionDecl() *FunctionDecl {
+	return d
+}
+
+// asMethodDecl returns the CXXMethodDecl of a method, constructor, destructor or conversion function, or nil for
+// other nodes.
+func asMethodDecl(n Node) *CXXMethodDecl {
+	if d, ok := n.(interface{ methodDecl() *CXXMethodDecl }); ok {
+		return d.methodDecl()
+	}
+	return nil
+}
+
+func (d *CXXMethodDecl) methodDecl() *CXXMethodDecl {
+	return d
+}
+
+type NamespaceDecl struct {
+	BaseNode
+	Name     string `json:"name"`
+	IsInline bool   `json:"isInline"`
+	// OriginalNamespace is set when the namespace reopens a namespace declared earlier.
+	OriginalNamespace ReferencedDecl `json:"originalNamespace"`
+}
+
+func (d *NamespaceDecl) DeclName() string {
+	return d.Name
+}
+
+func (d *NamespaceDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	d.Name = string(v.GetStringBytes("name"))
+	d.IsInline = v.GetBool("isInline")
+	err := d.OriginalNamespace.Unmarshal(v.Get("originalNamespace"), ctx)
+	if err != nil {
+		return err
+	}
+	return d.BaseNode.Unmarshal(v, ctx)
+}
+
+// Original returns the first declaration of the namespace, which is d itself unless d reopens the namespace.
+func (d *NamespaceDecl) Original() Node {
+	if d.OriginalNamespace.ID == "" {
+		return d
+	}
+	return lookupFrom(&d.BaseNode, d.OriginalNamespace.ID)
+}
+
+type CXXRecordDecl struct {
+	RecordDecl
+	IsImplicit bool               `json:"isImplicit"`
+	Access     string             `json:"access"`
+	Bases      []CXXBaseSpecifier `json:"bases"`
+	// DefinitionData is only set for complete definitions.
+	DefinitionData *CXXDefinitionData `json:"definitionData"`
+}
+
+func (d *CXXRecordDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	var err error
+	d.IsImplicit = v.GetBool("isImplicit")
+	d.Access = string(v.GetStringBytes("access"))
+
+	for _, bv := range v.GetArray("bases") {
+		var base CXXBaseSpecifier
+		base.Access = string(bv.GetStringBytes("access"))
+		base.WrittenAccess = string(bv.GetStringBytes("writtenAccess"))
+		base.IsVirtual = bv.GetBool("isVirtual")
+		base.IsPackExpansion = bv.GetBool("isPackExpansion")
+		base.Type, err = typeFromVal(bv.Get("type"), ctx)
+		if err != nil {
+			return err
+		}
+		d.Bases = append(d.Bases, base)
+	}
+
+	if dv := v.Get("definitionData"); dv != nil {
+		d.DefinitionData = definitionDataFromVal(dv)
+	}
+
+	return d.RecordDecl.Unmarshal(v, ctx)
+}
+
+// Methods returns the methods, constructors, destructors and conversion functions declared in the record, including
+// the implicit ones clang declared.
+func (d *CXXRecordDecl) Methods() []Node {
+	var methods []Node
+	for _, c := range d.Inner {
+		if asMethodDecl(c) != nil {
+			methods = append(methods, c)
+		}
+	}
+	return methods
+}
+
+type CXXBaseSpecifier struct {
+	Access          string `json:"access"`
+	Type            Type   `json:"type"`
+	WrittenAccess   string `json:"writtenAccess"`
+	IsVirtual       bool   `json:"isVirtual"`
+	IsPackExpansion bool   `json:"isPackExpansion"`
+}
+
+// CXXDefinitionData holds the properties clang computes for the definition of a class.
+type CXXDefinitionData struct {
+	IsGenericLambda                    bool `json:"isGenericLambda"`
+	IsLambda                           bool `json:"isLambda"`
+	IsEmpty                            bool `json:"isEmpty"`
+	IsAggregate                        bool `json:"isAggregate"`
+	IsStandardLayout                   bool `json:"isStandardLayout"`
+	IsTriviallyCopyable                bool `json:"isTriviallyCopyable"`
+	IsPOD                              bool `json:"isPOD"`
+	IsTrivial                          bool `json:"isTrivial"`
+	IsPolymorphic                      bool `json:"isPolymorphic"`
+	IsAbstract                         bool `json:"isAbstract"`
+	IsLiteral                          bool `json:"isLiteral"`
+	CanPassInRegisters                 bool `json:"canPassInRegisters"`
+	HasUserDeclaredConstructor         bool `json:"hasUserDeclaredConstructor"`
+	HasConstexprNonCopyMoveConstructor bool `json:"hasConstexprNonCopyMoveConstructor"`
+	HasMutableFields                   bool `json:"hasMutableFields"`
+	HasVariantMembers                  bool `json:"hasVariantMembers"`
+	CanConstDefaultInit                bool `json:"canConstDefaultInit"`
+
+	DefaultCtor CXXSpecialMember `json:"defaultCtor"`
+	CopyCtor    CXXSpecialMember `json:"copyCtor"`
+	MoveCtor    CXXSpecialMember `json:"moveCtor"`
+	CopyAssign  CXXSpecialMember `json:"copyAssign"`
+	MoveAssign  CXXSpecialMember `json:"moveAssign"`
+	Dtor        CXXSpecialMember `json:"dtor"`
+}
+
+// CXXSpecialMember describes a special member function of a class, not all fields apply to all special members.
+type CXXSpecialMember struct {
+	Exists                  bool `json:"exists"`
+	Simple                  bool `json:"simple"`
+	Trivial                 bool `json:"trivial"`
+	NonTrivial              bool `json:"nonTrivial"`
+	UserProvided            bool `json:"userProvided"`
+	UserDeclared            bool `json:"userDeclared"`
+	IsConstexpr             bool `json:"isConstexpr"`
+	HasConstParam           bool `json:"hasConstParam"`
+	ImplicitHasConstParam   bool `json:"implicitHasConstParam"`
+	NeedsImplicit           bool `json:"needsImplicit"`
+	NeedsOverloadResolution bool `json:"needsOverloadResolution"`
+	DefaultedIsConstexpr    bool `json:"defaultedIsConstexpr"`
+	DefaultedIsDeleted      bool `json:"defaultedIsDeleted"`
+	Irrelevant              bool `json:"irrelevant"`
+}
+
+func definitionDataFromVal(v *fastjson.Value) *CXXDefinitionData {
+	return &CXXDefinitionData{
+		IsGenericLambda:                    v.GetBool("isGenericLambda"),
+		IsLambda:                           v.GetBool("isLambda"),
+		IsEmpty:                            v.GetBool("isEmpty"),
+		IsAggregate:                        v.GetBool("isAggregate"),
+		IsStandardLayout:                   v.GetBool("isStandardLayout"),
+		IsTriviallyCopyable:                v.GetBool("isTriviallyCopyable"),
+		IsPOD:                              v.GetBool("isPOD"),
+		IsTrivial:                          v.GetBool("isTrivial"),
+		IsPolymorphic:                      v.GetBool("isPolymorphic"),
+		IsAbstract:                         v.GetBool("isAbstract"),
+		IsLiteral:                          v.GetBool("isLiteral"),
+		CanPassInRegisters:                 v.GetBool("canPassInRegisters"),
+		HasUserDeclaredConstructor:         v.GetBool("hasUserDeclaredConstructor"),
+		HasConstexprNonCopyMoveConstructor: v.GetBool("hasConstexprNonCopyMoveConstructor"),
+		HasMutableFields:                   v.GetBool("hasMutableFields"),
+		HasVariantMembers:                  v.GetBool("hasVariantMembers"),
+		CanConstDefaultInit:                v.GetBool("canConstDefaultInit"),
+
+		DefaultCtor: specialMemberFromVal(v.Get("defaultCtor")),
+		CopyCtor:    specialMemberFromVal(v.Get("copyCtor")),
+		MoveCtor:    specialMemberFromVal(v.Get("moveCtor")),
+		CopyAssign:  specialMemberFromVal(v.Get("copyAssign")),
+		MoveAssign:  specialMemberFromVal(v.Get("moveAssign")),
+		Dtor:        specialMemberFromVal(v.Get("dtor")),
+	}
+}
+
+func specialMemberFromVal(v *fastjson.Value) CXXSpecialMember {
+	return CXXSpecialMember{
+		Exists:                  v.GetBool("exists"),
+		Simple:                  v.GetBool("simple"),
+		Trivial:                 v.GetBool("trivial"),
+		NonTrivial:              v.GetBool("nonTrivial"),
+		UserProvided:            v.GetBool("userProvided"),
+		UserDeclared:            v.GetBool("userDeclared"),
+		IsConstexpr:             v.GetBool("isConstexpr"),
+		HasConstParam:           v.GetBool("hasConstParam"),
+		ImplicitHasConstParam:   v.GetBool("implicitHasConstParam"),
+		NeedsImplicit:           v.GetBool("needsImplicit"),
+		NeedsOverloadResolution: v.GetBool("needsOverloadResolution"),
+		DefaultedIsConstexpr:    v.GetBool("defaultedIsConstexpr"),
+		DefaultedIsDeleted:      v.GetBool("defaultedIsDeleted"),
+		Irrelevant:              v.GetBool("irrelevant"),
+	}
+}
+
+type CXXMethodDecl struct {
+	FunctionDecl
+	IsImplicit bool   `json:"isImplicit"`
+	Access     string `json:"access"`
+	Virtual    bool   `json:"virtual"`
+	Pure       bool   `json:"pure"`
+	Constexpr  bool   `json:"constexpr"`
+	// ExplicitlyDefaulted is "default" or "deleted" for methods declared "= default" or "= delete".
+	ExplicitlyDefaulted string `json:"explicitlyDefaulted"`
+	ExplicitlyDeleted   bool   `json:"explicitlyDeleted"`
+}
+
+func (d *CXXMethodDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	d.IsImplicit = v.GetBool("isImplicit")
+	d.Access = string(v.GetStringBytes("access"))
+	d.Virtual = v.GetBool("virtual")
+	d.Pure = v.GetBool("pure")
+	d.Constexpr = v.GetBool("constexpr")
+	d.ExplicitlyDefaulted = string(v.GetStringBytes("explicitlyDefaulted"))
+	d.ExplicitlyDeleted = v.GetBool("explicitlyDeleted")
+	return d.FunctionDecl.Unmarshal(v, ctx)
+}
+
+// Record returns the class the method is a member of, or nil if the method is defined outside of it.
+func (d *CXXMethodDecl) Record() *CXXRecordDecl {
+	r, _ := d.Parent().(*CXXRecordDecl)
+	return r
+}
+
+type CXXConstructorDecl struct {
+	CXXMethodDecl
+}
+
+type CXXDestructorDecl struct {
+	CXXMethodDecl
+}
+
+type CXXConversionDecl struct {
+	CXXMethodDecl
+}
+
+type ClassTemplateDecl struct {
+	BaseNode
+	Name string `json:"name"`
+}
+
+func (d *ClassTemplateDecl) DeclName() string {
+	return d.Name
+}
+
+func (d *ClassTemplateDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	d.Name = string(v.GetStringBytes("name"))
+	return d.BaseNode.Unmarshal(v, ctx)
+}
+
+// TemplatedDecl returns the class the template declares.
+func (d *ClassTemplateDecl) TemplatedDecl() *CXXRecordDecl {
+	for _, c := range d.Inner {
+		if r, ok := c.(*CXXRecordDecl); ok {
+			return r
+		}
+	}
+	return nil
+}
+
+type FunctionTemplateDecl struct {
+	BaseNode
+	Name string `json:"name"`
+}
+
+func (d *FunctionTemplateDecl) DeclName() string {
+	return d.Name
+}
+
+func (d *FunctionTemplateDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	d.Name = string(v.GetStringBytes("name"))
+	return d.BaseNode.Unmarshal(v, ctx)
+}
+
+// TemplatedDecl returns the function or method the template declares, the instantiations of the template follow it
+// in Inner.
+func (d *FunctionTemplateDecl) TemplatedDecl() Node {
+	for _, c := range d.Inner {
+		if AsFunctionDecl(c) != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+type TemplateTypeParmDecl struct {
+	BaseNode
+	Name string `json:"name"`
+	// TagUsed is "class" or "typename".
+	TagUsed         string            `json:"tagUsed"`
+	Depth           int               `json:"depth"`
+	Index           int               `json:"index"`
+	IsParameterPack bool              `json:"isParameterPack"`
+	DefaultArg      *TemplateArgument `json:"defaultArg"`
+}
+
+func (d *TemplateTypeParmDecl) DeclName() string {
+	return d.Name
+}
+
+func (d *TemplateTypeParmDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	d.Name = string(v.GetStringBytes("name"))
+	d.TagUsed = string(v.GetStringBytes("tagUsed"))
+	d.Depth = v.GetInt("depth")
+	d.Index = v.GetInt("index")
+	d.IsParameterPack = v.GetBool("isParameterPack")
+
+	if av := v.Get("defaultArg"); av != nil {
+		d.DefaultArg = &TemplateArgument{}
+		err := d.DefaultArg.Unmarshal(av, ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.BaseNode.Unmarshal(v, ctx)
+}
+
+// TemplateArgument is a type template argument, such as the default argument of a TemplateTypeParmDecl.
+type TemplateArgument struct {
+	Kind string `json:"kind"`
+	Type Type   `json:"type"`
+}
+
+func (a *TemplateArgument) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	var err error
+	a.Kind = ctx.InternBytes(v.GetStringBytes("kind"))
+	a.Type, err = typeFromVal(v.Get("type"), ctx)
+	return err
+}
+
+type AccessSpecDecl struct {
+	BaseNode
+	Access string `json:"access"`
+}
+
+func (d *AccessSpecDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	d.Access = string(v.GetStringBytes("access"))
+	return d.BaseNode.Unmarshal(v, ctx)
+}
+
+type UsingDecl struct {
+	BaseNode
+	// Name is the qualified name of the declaration, such as "std::swap".
+	Name string `json:"name"`
+}
+
+func (d *UsingDecl) DeclName() string {
+	return d.Name
+}
+
+func (d *UsingDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	d.Name = string(v.GetStringBytes("name"))
+	return d.BaseNode.Unmarshal(v, ctx)
+}
+
+type CXXMemberCallExpr struct {
+	CallExpr
+}
+
+// ImplicitObjectArgument returns the object the method is called on, a pointer if the call uses "->".
+func (d *CXXMemberCallExpr) ImplicitObjectArgument() Node {
+	m, ok := ignoreParenCasts(d.Callee()).(*MemberExpr)
+	if !ok {
+		return nil
+	}
+	return m.Base()
+}
+
+// MethodDecl returns the method called, or nil if the call is through a pointer to member.
+func (d *CXXMemberCallExpr) MethodDecl() *CXXMethodDecl {
+	m, ok := ignoreParenCasts(d.Callee()).(*MemberExpr)
+	if !ok {
+		return nil
+	}
+	return asMethodDecl(m.MemberDecl())
+}
+
+type CXXOperatorCallExpr struct {
+	CallExpr
+}
+
+// Operator returns the spelling of the overloaded operator called, such as "+" or "[]".
+func (d *CXXOperatorCallExpr) Operator() string {
+	ref, ok := ignoreParenCasts(d.Callee()).(*DeclRefExpr)
+	if !ok {
+		return ""
+	}
+	return strings.TrimPrefix(ref.ReferencedDecl.Name, "operator")
+}
+
+type CXXThisExpr struct {
+	Expr
+	Implicit bool `json:"implicit"`
+}
+
+func (d *CXXThisExpr) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	d.Implicit = v.GetBool("implicit")
+	return d.Expr.Unmarshal(v, ctx)
+}
+
+type CXXNewExpr struct {
+	Expr
+	IsGlobal    bool `json:"isGlobal"`
+	IsArray     bool `json:"isArray"`
+	IsPlacement bool `json:"isPlacement"`
+	// InitStyle is "cinit", "call" or "list", it is empty if the object isn't initialized.
+	InitStyle       string         `json:"initStyle"`
+	OperatorNewDecl ReferencedDecl `json:"operatorNewDecl"`
+}
+
+func (d *CXXNewExpr) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	d.IsGlobal = v.GetBool("isGlobal")
+	d.IsArray = v.GetBool("isArray")
+	d.IsPlacement = v.GetBool("isPlacement")
+	d.InitStyle = string(v.GetStringBytes("initStyle"))
+	err := d.OperatorNewDecl.Unmarshal(v.Get("operatorNewDecl"), ctx)
+	if err != nil {
+		return err
+	}
+	return d.Expr.Unmarshal(v, ctx)
+}
+
+// OperatorNew returns the declaration of the allocation function called.
+func (d *CXXNewExpr) OperatorNew() Node {
+	return lookupFrom(&d.BaseNode, d.OperatorNewDecl.ID)
+}
+
+type CXXDeleteExpr struct {
+	Expr
+	IsGlobal           bool           `json:"isGlobal"`
+	IsArray            bool           `json:"isArray"`
+	IsArrayAsWritten   bool           `json:"isArrayAsWritten"`
+	OperatorDeleteDecl ReferencedDecl `json:"operatorDeleteDecl"`
+}
+
+func (d *CXXDeleteExpr) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	d.IsGlobal = v.GetBool("isGlobal")
+	d.IsArray = v.GetBool("isArray")
+	d.IsArrayAsWritten = v.GetBool("isArrayAsWritten")
+	err := d.OperatorDeleteDecl.Unmarshal(v.Get("operatorDeleteDecl"), ctx)
+	if err != nil {
+		return err
+	}
+	return d.Expr.Unmarshal(v, ctx)
+}
+
+// Argument returns the pointer deleted.
+func (d *CXXDeleteExpr) Argument() Node {
+	return childAt(d.Inner, 0)
+}
+
+// OperatorDelete returns the declaration of the deallocation function called.
+func (d *CXXDeleteExpr) OperatorDelete() Node {
+	return lookupFrom(&d.BaseNode, d.OperatorDeleteDecl.ID)
+}
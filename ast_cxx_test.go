@@ -0,0 +1,200 @@
+package goclangast
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testdata/cxx/classes.json is the output of clang++ -std=c++17 -Xclang -ast-dump=json -fsyntax-only for classes.cpp,
+// trimmed of most implicit declarations.
+
+// cxxRecord returns the definition of the named class in tu.
+func cxxRecord(t *testing.T, tu *TranslationUnitDecl, name string) *CXXRecordDecl {
+	t.Helper()
+
+	for _, n := range nodesOfKind(tu, "CXXRecordDecl") {
+		if d := n.(*CXXRecordDecl); d.Name == name && !d.IsImplicit {
+			return d
+		}
+	}
+	t.Fatalf("class %s not found", name)
+	return nil
+}
+
+func TestCXXRecordDecl(t *testing.T) {
+	tu := parseTestdata(t, "testdata/cxx/classes.json")
+
+	base := cxxRecord(t, tu, "Base")
+	square := cxxRecord(t, tu, "Square")
+
+	if len(base.Bases) != 0 {
+		t.Errorf("Base has bases %+v, want none", base.Bases)
+	}
+	wantBases := []CXXBaseSpecifier{
+		{Access: "public", Type: Type{QualType: "Base"}, WrittenAccess: "public", IsVirtual: true},
+	}
+	if !reflect.DeepEqual(square.Bases, wantBases) {
+		t.Errorf("Square has bases %+v, want %+v", square.Bases, wantBases)
+	}
+
+	if dd := base.DefinitionData; dd == nil || !dd.IsAbstract || !dd.IsPolymorphic || dd.IsStandardLayout ||
+		!dd.CopyCtor.NonTrivial || !dd.Dtor.Trivial || !dd.DefaultCtor.IsConstexpr {
+		t.Errorf("Base has definition data %+v, want an abstract polymorphic class with a trivial destructor", dd)
+	}
+	if dd := square.DefinitionData; dd == nil || dd.IsAbstract || !dd.IsPolymorphic || dd.IsLiteral ||
+		dd.DefaultCtor.IsConstexpr || !dd.MoveAssign.Exists {
+		t.Errorf("Square has definition data %+v, want a concrete polymorphic class", dd)
+	}
+
+	// The implicit declaration of the class inside itself isn't a definition.
+	self, ok := square.Inner[0].(*CXXRecordDecl)
+	if !ok || !self.IsImplicit || self.DefinitionData != nil {
+		t.Errorf("first child of Square is %#v, want the implicit declaration of Square", square.Inner[0])
+	}
+
+	type method struct {
+		kind string
+		name string
+	}
+	methods := func(d *CXXRecordDecl) []method {
+		var ms []method
+		for _, m := range d.Methods() {
+			ms = append(ms, method{m.GetBaseNode().Kind, AsFunctionDecl(m).Name})
+		}
+		return ms
+	}
+
+	wantMethods := []method{
+		{"CXXMethodDecl", "area"},
+		{"CXXMethodDecl", "operator="},
+		{"CXXMethodDecl", "operator="},
+		{"CXXDestructorDecl", "~Base"},
+	}
+	if got := methods(base); !reflect.DeepEqual(got, wantMethods) {
+		t.Errorf("Base has methods %v, want %v", got, wantMethods)
+	}
+
+	// The member template is not a method itself.
+	wantMethods = []method{
+		{"CXXMethodDecl", "area"},
+		{"CXXMethodDecl", "unit"},
+		{"CXXMethodDecl", "operator+"},
+		{"CXXMethodDecl", "operator="},
+		{"CXXMethodDecl", "operator="},
+		{"CXXDestructorDecl", "~Square"},
+	}
+	if got := methods(square); !reflect.DeepEqual(got, wantMethods) {
+		t.Errorf("Square has methods %v, want %v", got, wantMethods)
+	}
+
+	area := asMethodDecl(base.Methods()[0])
+	if !area.Virtual || !area.Pure || area.Type.QualType != "int () const" || area.Record() != base {
+		t.Errorf("Base::area is %+v, want a pure virtual const method of Base", area)
+	}
+
+	unit := asMethodDecl(square.Methods()[1])
+	if unit.StorageClass != "static" || unit.Record() != square {
+		t.Errorf("Square::unit is %+v, want a static method of Square", unit)
+	}
+
+	dtor, ok := square.Methods()[5].(*CXXDestructorDecl)
+	if !ok || !dtor.IsImplicit || dtor.ExplicitlyDefaulted != "default" || dtor.Record() != square {
+		t.Errorf("Square::~Square is %+v, want the implicit defaulted destructor", square.Methods()[5])
+	}
+}
+
+func TestCXXTemplates(t *testing.T) {
+	tu := parseTestdata(t, "testdata/cxx/classes.json")
+
+	templates := nodesOfKind(tu, "ClassTemplateDecl")
+	if len(templates) != 1 {
+		t.Fatalf("got %d class templates, want 1", len(templates))
+	}
+	box := templates[0].(*ClassTemplateDecl)
+	if r := box.TemplatedDecl(); r == nil || r.Name != "Box" || r.DefinitionData == nil || !r.DefinitionData.IsPOD {
+		t.Errorf("Box templates %#v, want the POD struct Box", r)
+	}
+
+	param, ok := box.Inner[0].(*TemplateTypeParmDecl)
+	if !ok {
+		t.Fatalf("first child of Box is %T, want *TemplateTypeParmDecl", box.Inner[0])
+	}
+	wantArg := &TemplateArgument{Kind: "TemplateArgument", Type: Type{QualType: "int"}}
+	if param.Name != "T" || param.TagUsed != "typename" || param.Depth != 0 || param.Index != 0 ||
+		!reflect.DeepEqual(param.DefaultArg, wantArg) {
+		t.Errorf("template parameter of Box is %+v, want typename T = int", param)
+	}
+
+	templates = nodesOfKind(tu, "FunctionTemplateDecl")
+	if len(templates) != 1 {
+		t.Fatalf("got %d function templates, want 1", len(templates))
+	}
+	as := templates[0].(*FunctionTemplateDecl)
+	m, ok := as.TemplatedDecl().(*CXXMethodDecl)
+	if !ok || m.Name != "as" || m.Type.QualType != "U () const" {
+		t.Errorf("as templates %#v, want the method as", as.TemplatedDecl())
+	}
+}
+
+func TestCXXExprs(t *testing.T) {
+	tu := parseTestdata(t, "testdata/cxx/classes.json")
+
+	single := func(kind string) Node {
+		t.Helper()
+		nodes := nodesOfKind(tu, kind)
+		if len(nodes) != 1 {
+			t.Fatalf("got %d %s nodes, want 1", len(nodes), kind)
+		}
+		return nodes[0]
+	}
+
+	newExpr := single("CXXNewExpr").(*CXXNewExpr)
+	if !newExpr.IsArray || newExpr.IsGlobal || newExpr.IsPlacement {
+		t.Errorf("new expression is %+v, want a non-global array new", newExpr)
+	}
+	if f, ok := newExpr.OperatorNew().(*FunctionDecl); !ok || f.Name != "operator new[]" {
+		t.Errorf("new expression calls %#v, want operator new[]", newExpr.OperatorNew())
+	}
+
+	deleteExpr := single("CXXDeleteExpr").(*CXXDeleteExpr)
+	if !deleteExpr.IsGlobal || !deleteExpr.IsArray || !deleteExpr.IsArrayAsWritten {
+		t.Errorf("delete expression is %+v, want a global array delete", deleteExpr)
+	}
+	if f, ok := deleteExpr.OperatorDelete().(*FunctionDecl); !ok || f.Name != "operator delete[]" {
+		t.Errorf("delete expression calls %#v, want operator delete[]", deleteExpr.OperatorDelete())
+	}
+	if ref, ok := ignoreParenCasts(deleteExpr.Argument()).(*DeclRefExpr); !ok || ref.ReferencedDecl.Name != "p" {
+		t.Errorf("delete expression deletes %#v, want p", deleteExpr.Argument())
+	}
+
+	op := single("CXXOperatorCallExpr").(*CXXOperatorCallExpr)
+	if op.Operator() != "+" {
+		t.Errorf("operator call calls operator %q, want +", op.Operator())
+	}
+	if d := op.CalleeDecl(); d == nil || d.Name != "operator+" || d.Kind != "CXXMethodDecl" {
+		t.Errorf("operator call calls %#v, want the method Square::operator+", d)
+	}
+
+	call := single("CXXMemberCallExpr").(*CXXMemberCallExpr)
+	if m := call.MethodDecl(); m == nil || m.Name != "area" || m.Record() == nil || m.Record().Name != "Square" {
+		t.Errorf("member call calls %#v, want Square::area", m)
+	}
+	if d := call.CalleeDecl(); d == nil || d.Name != "area" {
+		t.Errorf("callee of the member call is %#v, want Square::area", d)
+	}
+	if ref, ok := ignoreParenCasts(call.ImplicitObjectArgument()).(*DeclRefExpr); !ok || ref.ReferencedDecl.Name != "s" {
+		t.Errorf("member call is on %#v, want s", call.ImplicitObjectArgument())
+	}
+
+	// Calls to static methods refer to the CXXMethodDecl.
+	static := single("CallExpr").(*CallExpr)
+	if d := static.CalleeDecl(); d == nil || d.Name != "unit" || d.Kind != "CXXMethodDecl" {
+		t.Errorf("static call calls %#v, want Square::unit", d)
+	}
+
+	// Record types refer to the RecordDecl embedded in the CXXRecordDecl.
+	typ := single("RecordType").(*RecordType)
+	if d := typ.RecordDecl(); d == nil || d != &cxxRecord(t, tu, "Square").RecordDecl {
+		t.Errorf("record type refers to %#v, want Square", d)
+	}
+}
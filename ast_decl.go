@@ -16,6 +16,8 @@ var DeclMap = map[string]func() Node{
 	"StaticAssertDecl":    func() Node { return &StaticAssertDecl{} },
 	"LabelDecl":           func() Node { return &LabelDecl{} },
 	"IndirectFieldDecl":   func() Node { return &IndirectFieldDecl{} },
+	"CapturedDecl":        func() Node { return &CapturedDecl{} },
+	"ImplicitParamDecl":   func() Node { return &ImplicitParamDecl{} },
 }
 
 type Decl struct {
@@ -31,8 +33,90 @@ func (d *Decl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return nil
 }
 
+// NamedDecl is implemented by declarations with a name, including the C++ and Objective-C declarations. Nodes which
+// embed one of them, such as CXXMethodDecl embedding FunctionDecl, implement it too.
+type NamedDecl interface {
+	Node
+	// DeclName returns the name of the declaration, which is empty for anonymous declarations.
+	DeclName() string
+}
+
 type TranslationUnitDecl struct {
 	BaseNode
+	// UnknownKinds counts the UnknownNode's in the translation unit per kind.
+	UnknownKinds map[string]int `json:"-"`
+
+	index  map[string]Node
+	labels map[string]*LabelStmt
+}
+
+func (d *TranslationUnitDecl) buildIndex() {
+	d.index = make(map[string]Node)
+	d.labels = make(map[string]*LabelStmt)
+	PreOrderVisit(d, func(n Node, depth int) error {
+		if id := n.GetBaseNode().ID; id != "" {
+			d.index[id] = n
+		}
+
+		if l, ok := n.(*LabelStmt); ok && l.DeclId != "" {
+			d.labels[l.DeclId] = l
+		}
+
+		return nil
+	})
+}
+
+// Lookup returns the node with the given ID, or nil if the translation unit doesn't contain it.
+func (d *TranslationUnitDecl) Lookup(id string) Node {
+	return d.index[id]
+}
+
+// TypeAliasDecl returns the declaration referenced by t.TypeAliasDeclId.
+func (d *TranslationUnitDecl) TypeAliasDecl(t Type) Node {
+	if t.TypeAliasDeclId == "" {
+		return nil
+	}
+
+	return d.Lookup(t.TypeAliasDeclId)
+}
+
+// rootTU returns the translation unit bn is part of, or nil if it isn't linked into one.
+func rootTU(bn *BaseNode) *TranslationUnitDecl {
+	var n Node = bn.parent
+	if n == nil {
+		return nil
+	}
+
+	for n.Parent() != nil {
+		n = n.Parent()
+	}
+
+	tu, _ := n.(*TranslationUnitDecl)
+	return tu
+}
+
+// lookupFrom looks up the node with the given ID in the translation unit bn is part of.
+func lookupFrom(bn *BaseNode, id string) Node {
+	if id == "" {
+		return nil
+	}
+
+	tu := rootTU(bn)
+	if tu == nil {
+		return nil
+	}
+
+	return tu.Lookup(id)
+}
+
+// labelFrom returns the LabelStmt declaring the label with the given ID in the translation unit bn is part of.
+func labelFrom(bn *BaseNode, declID string) *LabelStmt {
+	tu := rootTU(bn)
+	if tu == nil {
+		return nil
+	}
+
+	return tu.labels[declID]
 }
 
 type TypedefDecl struct {
@@ -42,6 +126,10 @@ type TypedefDecl struct {
 	Type       Type   `json:"type"`
 }
 
+func (d *TypedefDecl) DeclName() string {
+	return d.Name
+}
+
 func (d *TypedefDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	var err error
 	d.IsImplicit = v.GetBool("isImplicit")
@@ -53,8 +141,47 @@ func (d *TypedefDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return d.BaseNode.Unmarshal(v, ctx)
 }
 
+// TagDecl returns the *RecordDecl, *CXXRecordDecl or *EnumDecl the typedef names directly, as in
+// "typedef struct {...} foo_t;", or nil if the typedef names another kind of type.
+func (d *TypedefDecl) TagDecl() Node {
+	var (
+		n     Node = d
+		found Node
+	)
+	for found == nil && len(n.Children()) > 0 {
+		n = n.Children()[0]
+		switch t := n.(type) {
+		case *RecordType:
+			found = lookupFrom(&d.BaseNode, t.Decl.ID)
+		case *EnumType:
+			found = lookupFrom(&d.BaseNode, t.Decl.ID)
+		case *ElaboratedType, *QualType:
+		default:
+			return nil
+		}
+	}
+
+	return found
+}
+
 type EnumDecl struct {
 	BaseNode
+	Name                string `json:"name"`
+	FixedUnderlyingType Type   `json:"fixedUnderlyingType"`
+}
+
+func (d *EnumDecl) DeclName() string {
+	return d.Name
+}
+
+func (d *EnumDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	var err error
+	d.Name = string(v.GetStringBytes("name"))
+	d.FixedUnderlyingType, err = typeFromVal(v.Get("fixedUnderlyingType"), ctx)
+	if err != nil {
+		return err
+	}
+	return d.BaseNode.Unmarshal(v, ctx)
 }
 
 type EnumConstantDecl struct {
@@ -63,6 +190,10 @@ type EnumConstantDecl struct {
 	Type Type   `json:"type"`
 }
 
+func (d *EnumConstantDecl) DeclName() string {
+	return d.Name
+}
+
 func (d *EnumConstantDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	var err error
 	d.Name = string(v.GetStringBytes("name"))
@@ -78,6 +209,12 @@ type RecordDecl struct {
 	Name               string `json:"name"`
 	TagUsed            string `json:"tagUsed"`
 	CompleteDefinition bool   `json:"completeDefinition"`
+	// Layout is only set when the AST was created with Options.RecordLayouts.
+	Layout *RecordLayout `json:"-"`
+}
+
+func (d *RecordDecl) DeclName() string {
+	return d.Name
 }
 
 func (d *RecordDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
@@ -92,6 +229,12 @@ type FieldDecl struct {
 	Name       string `json:"name"`
 	Type       Type   `json:"type"`
 	IsBitfield bool   `json:"isBitfield"`
+	// Layout is only set when the AST was created with Options.RecordLayouts.
+	Layout *FieldLayout `json:"-"`
+}
+
+func (d *FieldDecl) DeclName() string {
+	return d.Name
 }
 
 func (d *FieldDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
@@ -115,6 +258,7 @@ type ReferencedDecl struct {
 func (d *ReferencedDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	var err error
 	d.ID = string(v.GetStringBytes("id"))
+	d.Kind = ctx.InternBytes(v.GetStringBytes("kind"))
 	d.Name = string(v.GetStringBytes("name"))
 	d.Type, err = typeFromVal(v.Get("type"), ctx)
 	return err
@@ -126,6 +270,10 @@ type IndirectFieldDecl struct {
 	IsImplicit bool   `json:"isImplicit"`
 }
 
+func (d *IndirectFieldDecl) DeclName() string {
+	return d.Name
+}
+
 func (d *IndirectFieldDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	d.Name = string(v.GetStringBytes("name"))
 	d.IsImplicit = v.GetBool("isImplicit")
@@ -142,6 +290,10 @@ type FunctionDecl struct {
 	Inline       bool   `json:"inline"`
 }
 
+func (d *FunctionDecl) DeclName() string {
+	return d.Name
+}
+
 func (d *FunctionDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	var err error
 	d.Name = string(v.GetStringBytes("name"))
@@ -166,6 +318,10 @@ type VarDecl struct {
 	Init         string `json:"init"`
 }
 
+func (d *VarDecl) DeclName() string {
+	return d.Name
+}
+
 func (d *VarDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	var err error
 	d.Name = string(v.GetStringBytes("name"))
@@ -188,6 +344,10 @@ type ParmVarDecl struct {
 	MangledName string `json:"mangledName"`
 }
 
+func (d *ParmVarDecl) DeclName() string {
+	return d.Name
+}
+
 func (d *ParmVarDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	var err error
 	d.Name = string(v.GetStringBytes("name"))
@@ -214,8 +374,34 @@ type LabelDecl struct {
 	Name   string `json:"name"`
 }
 
+func (d *LabelDecl) DeclName() string {
+	return d.Name
+}
+
 func (d *LabelDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	d.Name = string(v.GetStringBytes("name"))
 	d.IsUsed = v.GetBool("isUsed")
 	return d.BaseNode.Unmarshal(v, ctx)
 }
+
+// CapturedDecl is the outlined function of a CapturedStmt, the captured statement is followed by the implicit
+// parameters of the function.
+type CapturedDecl struct {
+	BaseNode
+}
+
+func (d *CapturedDecl) Body() Node {
+	return childAt(d.Inner, 0)
+}
+
+// ImplicitParamDecl is a parameter declared by the compiler, such as the parameters of the outlined function of a
+// CapturedDecl.
+type ImplicitParamDecl struct {
+	VarDecl
+	IsImplicit bool `json:"isImplicit"`
+}
+
+func (d *ImplicitParamDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	d.IsImplicit = v.GetBool("isImplicit")
+	return d.VarDecl.Unmarshal(v, ctx)
+}
@@ -0,0 +1,115 @@
+package goclangast
+
+import (
+	"testing"
+)
+
+func TestTranslationUnitDeclLookup(t *testing.T) {
+	tu := parseTestdata(t, "testdata/stmts/stmts.json")
+
+	for _, n := range flatten(tu) {
+		id := n.GetBaseNode().ID
+		if id == "" {
+			continue
+		}
+		if got := tu.Lookup(id); got != n {
+			t.Errorf("looking up %s gives %v, want the %s", id, got, n.GetBaseNode().Kind)
+		}
+	}
+	if n := tu.Lookup("0x1"); n != nil {
+		t.Errorf("looking up an unknown ID gives %v, want nil", n)
+	}
+	if n := tu.Lookup(""); n != nil {
+		t.Errorf("looking up an empty ID gives %v, want nil", n)
+	}
+}
+
+func TestDeclRefExprDecl(t *testing.T) {
+	tu := parseTestdata(t, "testdata/stmts/stmts.json")
+
+	want := map[string]string{
+		"add":     "FunctionDecl",
+		"fn":      "ParmVarDecl",
+		"p":       "ParmVarDecl",
+		"a":       "VarDecl",
+		"total":   "VarDecl",
+		"counter": "VarDecl",
+	}
+	for _, n := range nodesOfKind(stmtsFunc(t, "apply"), "DeclRefExpr") {
+		ref := n.(*DeclRefExpr)
+		d, ok := ref.Decl().(NamedDecl)
+		if !ok || d.DeclName() != ref.ReferencedDecl.Name || d.GetBaseNode().Kind != want[ref.ReferencedDecl.Name] {
+			t.Errorf("reference to %s resolves to %#v, want a %s", ref.ReferencedDecl.Name, ref.Decl(),
+				want[ref.ReferencedDecl.Name])
+		}
+	}
+
+	// References to file scope declarations resolve to the node in the tree.
+	counter := nodesOfKind(tu, "VarDecl")[0]
+	for _, n := range nodesOfKind(tu, "DeclRefExpr") {
+		if ref := n.(*DeclRefExpr); ref.ReferencedDecl.Name == "counter" && ref.Decl() != counter {
+			t.Errorf("reference to counter resolves to %p, want %p", ref.Decl(), counter)
+		}
+	}
+}
+
+func TestMemberExprMemberDecl(t *testing.T) {
+	tu := parseTestdata(t, "testdata/stmts/stmts.json")
+	point := tu.Inner[1].(*RecordDecl)
+
+	members := nodesOfKind(tu, "MemberExpr")
+	if len(members) != 2 {
+		t.Fatalf("got %d member expressions, want 2", len(members))
+	}
+	for i, m := range members {
+		if d := m.(*MemberExpr).MemberDecl(); d != point.Inner[i] {
+			t.Errorf("member expression %d refers to %#v, want %#v", i, d, point.Inner[i])
+		}
+	}
+}
+
+func TestTypedefDeclTagDecl(t *testing.T) {
+	tu := parseTestdata(t, "testdata/stmts/stmts.json")
+	point := tu.Inner[1].(*RecordDecl)
+	pointT := tu.Inner[2].(*TypedefDecl)
+
+	if d := pointT.TagDecl(); d != point {
+		t.Errorf("point_t names %#v, want struct point", d)
+	}
+
+	// Typedefs of other types don't name a tag declaration.
+	for _, n := range nodesOfKind(tu, "TypedefDecl") {
+		if d := n.(*TypedefDecl); d != pointT && d.TagDecl() != nil {
+			t.Errorf("typedef %s names %#v, want nil", d.Name, d.TagDecl())
+		}
+	}
+}
+
+func TestLookupDetached(t *testing.T) {
+	tu := parseTestdata(t, "testdata/stmts/stmts.json")
+	add := stmtsFunc(t, "add")
+
+	// Nodes which aren't part of a translation unit can't resolve references, even to IDs of nodes that exist.
+	ref := &DeclRefExpr{}
+	ref.ReferencedDecl = ReferencedDecl{ID: add.ID, Kind: "FunctionDecl", Name: "add"}
+	if d := ref.Decl(); d != nil {
+		t.Errorf("reference without a parent resolves to %#v, want nil", d)
+	}
+
+	s := &CompoundStmt{}
+	s.Inner = []Node{ref}
+	linkNodes(s)
+	if d := ref.Decl(); d != nil {
+		t.Errorf("reference in a tree without a translation unit resolves to %#v, want nil", d)
+	}
+
+	// A subtree of another translation unit resolves references in that translation unit.
+	other := parseTestdata(t, "testdata/stmts/stmts.json")
+	for _, n := range nodesOfKind(other, "DeclRefExpr") {
+		ref := n.(*DeclRefExpr)
+		if d := ref.Decl(); d == nil || d == tu.Lookup(ref.ReferencedDecl.ID) {
+			t.Errorf("reference to %s resolves to %p, want the declaration in its own translation unit",
+				ref.ReferencedDecl.Name, d)
+		}
+	}
+}
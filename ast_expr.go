@@ -42,6 +42,39 @@ func (e *Expr) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return e.BaseNode.Unmarshal(v, ctx)
 }
 
+func (e *Expr) valueType() Type {
+	return e.Type
+}
+
+// ignoreParenCasts strips the ParenExpr's and ImplicitCastExpr's around n.
+func ignoreParenCasts(n Node) Node {
+	for {
+		switch e := n.(type) {
+		case *ParenExpr:
+			n = e.SubExpr()
+		case *ImplicitCastExpr:
+			n = e.SubExpr()
+		default:
+			return n
+		}
+	}
+}
+
+// isPointerLike reports whether n is an expression or operator of pointer or array type.
+func isPointerLike(n Node) bool {
+	e, ok := n.(interface{ valueType() Type })
+	if !ok {
+		return false
+	}
+
+	t, err := e.valueType().DesugaredCType()
+	if err != nil {
+		return false
+	}
+
+	return t.Kind == CTypePointer || t.Kind == CTypeArray
+}
+
 type ConstantExpr struct {
 	Expr
 	Value string `json:"value"`
@@ -64,10 +97,15 @@ func (d *DeclRefExpr) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 		return err
 	}
 
-	d.NonOdrUseReason = string(v.GetStringBytes("value"))
+	d.NonOdrUseReason = string(v.GetStringBytes("nonOdrUseReason"))
 	return d.Expr.Unmarshal(v, ctx)
 }
 
+// Decl returns the declaration referenced by the expression.
+func (d *DeclRefExpr) Decl() Node {
+	return lookupFrom(&d.BaseNode, d.ReferencedDecl.ID)
+}
+
 type ImplicitCastExpr struct {
 	Expr
 	CastKind string `json:"castKind"`
@@ -78,10 +116,18 @@ func (d *ImplicitCastExpr) Unmarshal(v *fastjson.Value, ctx *ParseContext) error
 	return d.Expr.Unmarshal(v, ctx)
 }
 
+func (d *ImplicitCastExpr) SubExpr() Node {
+	return childAt(d.Inner, 0)
+}
+
 type ParenExpr struct {
 	Expr
 }
 
+func (d *ParenExpr) SubExpr() Node {
+	return childAt(d.Inner, 0)
+}
+
 type CStyleCastExpr struct {
 	Expr
 	CastKind string `json:"castKind"`
@@ -92,10 +138,51 @@ func (d *CStyleCastExpr) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return d.Expr.Unmarshal(v, ctx)
 }
 
+func (d *CStyleCastExpr) SubExpr() Node {
+	return childAt(d.Inner, 0)
+}
+
 type CallExpr struct {
 	Expr
 }
 
+// AsCallExpr returns the CallExpr of a call, including C++ method and operator calls and user-defined literals, or nil
+// for other nodes.
+func AsCallExpr(n Node) *CallExpr {
+	if e, ok := n.(interface{ callExpr() *CallExpr }); ok {
+		return e.callExpr()
+	}
+	return nil
+}
+
+func (d *CallExpr) callExpr() *CallExpr {
+	return d
+}
+
+// Callee returns the expression called, usually an ImplicitCastExpr decaying a function to a pointer.
+func (d *CallExpr) Callee() Node {
+	return childAt(d.Inner, 0)
+}
+
+func (d *CallExpr) Args() []Node {
+	if len(d.Inner) == 0 {
+		return nil
+	}
+	return d.Inner[1:]
+}
+
+// CalleeDecl returns the function called, or nil for indirect calls, such as calls through function pointers. For
+// calls to C++ methods this is the FunctionDecl embedded in the CXXMethodDecl.
+func (d *CallExpr) CalleeDecl() *FunctionDecl {
+	switch callee := ignoreParenCasts(d.Callee()).(type) {
+	case *DeclRefExpr:
+		return AsFunctionDecl(callee.Decl())
+	case *MemberExpr:
+		return AsFunctionDecl(callee.MemberDecl())
+	}
+	return nil
+}
+
 type MemberExpr struct {
 	Expr
 	IsArrow              bool   `json:"isArrow"`
@@ -108,10 +195,47 @@ func (d *MemberExpr) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return d.Expr.Unmarshal(v, ctx)
 }
 
+// MemberDecl returns the declaration of the referenced member, usually a *FieldDecl.
+func (d *MemberExpr) MemberDecl() Node {
+	return lookupFrom(&d.BaseNode, d.ReferencedMemberDecl)
+}
+
+// Base returns the expression whose member is accessed, a pointer if IsArrow is set.
+func (d *MemberExpr) Base() Node {
+	return childAt(d.Inner, 0)
+}
+
 type ArraySubscriptExpr struct {
 	Expr
 }
 
+// LHS returns the expression left of the brackets.
+func (d *ArraySubscriptExpr) LHS() Node {
+	return childAt(d.Inner, 0)
+}
+
+// RHS returns the expression between the brackets.
+func (d *ArraySubscriptExpr) RHS() Node {
+	return childAt(d.Inner, 1)
+}
+
+// Base returns the pointer or array subscripted. This is usually the LHS, but C allows swapping the operands, as in
+// "1[arr]".
+func (d *ArraySubscriptExpr) Base() Node {
+	if isPointerLike(d.RHS()) {
+		return d.RHS()
+	}
+	return d.LHS()
+}
+
+// Index returns the integer index.
+func (d *ArraySubscriptExpr) Index() Node {
+	if isPointerLike(d.RHS()) {
+		return d.LHS()
+	}
+	return d.RHS()
+}
+
 type UnaryExprOrTypeTraitExpr struct {
 	Expr
 	Name    string `json:"name"`
@@ -176,6 +300,17 @@ func (d *AddrLabelExpr) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return d.Expr.Unmarshal(v, ctx)
 }
 
+// Label returns the declaration of the label whose address is taken.
+func (d *AddrLabelExpr) Label() *LabelDecl {
+	l, _ := lookupFrom(&d.BaseNode, d.LabelDeclId).(*LabelDecl)
+	return l
+}
+
+// LabelStmt returns the statement declaring the label whose address is taken.
+func (d *AddrLabelExpr) LabelStmt() *LabelStmt {
+	return labelFrom(&d.BaseNode, d.LabelDeclId)
+}
+
 type GenericSelectionExpr struct {
 	Expr
 }
@@ -0,0 +1,99 @@
+package goclangast
+
+import (
+	"testing"
+)
+
+// refName returns the name of the declaration n refers to, ignoring parentheses and implicit casts, or "" if n isn't
+// a DeclRefExpr.
+func refName(n Node) string {
+	ref, ok := ignoreParenCasts(n).(*DeclRefExpr)
+	if !ok {
+		return ""
+	}
+	return ref.ReferencedDecl.Name
+}
+
+func TestCallExprCalleeDecl(t *testing.T) {
+	calls := stmtsOf(t, "apply", "CallExpr")
+	if len(calls) != 2 {
+		t.Fatalf("apply has %d calls, want 2", len(calls))
+	}
+
+	direct := calls[0].(*CallExpr)
+	if d := direct.CalleeDecl(); d == nil || d.Name != "add" || d.Kind != "FunctionDecl" {
+		t.Errorf("direct call calls %#v, want add", d)
+	}
+	if n := len(direct.Args()); n != 2 {
+		t.Errorf("direct call has %d arguments, want 2", n)
+	}
+
+	// Calls through function pointers have no callee declaration, the callee is the pointer.
+	indirect := calls[1].(*CallExpr)
+	if d := indirect.CalleeDecl(); d != nil {
+		t.Errorf("call through a function pointer calls %#v, want nil", d)
+	}
+	if name := refName(indirect.Callee()); name != "fn" {
+		t.Errorf("callee of the indirect call refers to %q, want fn", name)
+	}
+}
+
+func TestArraySubscriptExpr(t *testing.T) {
+	subscripts := stmtsOf(t, "apply", "ArraySubscriptExpr")
+	if len(subscripts) != 2 {
+		t.Fatalf("apply has %d subscripts, want 2", len(subscripts))
+	}
+
+	// a[1] and 1[a] have the same base and index, but swapped operands.
+	for i, n := range subscripts {
+		s := n.(*ArraySubscriptExpr)
+		if name := refName(s.Base()); name != "a" {
+			t.Errorf("subscript %d has base %#v, want a", i, s.Base())
+		}
+		if v, ok := EvalInt(s.Index()); !ok || v.Int64() != 1 {
+			t.Errorf("subscript %d has index %v, %v, want 1", i, v, ok)
+		}
+	}
+
+	swapped := subscripts[1].(*ArraySubscriptExpr)
+	if _, ok := swapped.LHS().(*IntegerLiteral); !ok || refName(swapped.RHS()) != "a" {
+		t.Errorf("1[a] has operands %#v and %#v, want 1 and a", swapped.LHS(), swapped.RHS())
+	}
+}
+
+func TestMemberExpr(t *testing.T) {
+	members := stmtsOf(t, "apply", "MemberExpr")
+	if len(members) != 2 {
+		t.Fatalf("apply has %d member expressions, want 2", len(members))
+	}
+	for i, want := range []string{"x", "y"} {
+		m := members[i].(*MemberExpr)
+		if !m.IsArrow {
+			t.Errorf("member expression %d isn't an arrow", i)
+		}
+		if f, ok := m.MemberDecl().(*FieldDecl); !ok || f.Name != want {
+			t.Errorf("member expression %d refers to %#v, want field %s", i, m.MemberDecl(), want)
+		}
+		if name := refName(m.Base()); name != "p" {
+			t.Errorf("member expression %d has base %#v, want p", i, m.Base())
+		}
+	}
+
+	// In C++ the member of a record value, as in sum.side, refers to the field of the CXXRecordDecl.
+	tu := parseTestdata(t, "testdata/cxx/classes.json")
+	var side *MemberExpr
+	for _, n := range nodesOfKind(tu, "MemberExpr") {
+		if m := n.(*MemberExpr); !m.IsArrow {
+			side = m
+		}
+	}
+	if side == nil {
+		t.Fatal("no member expression without arrow found")
+	}
+	if f, ok := side.MemberDecl().(*FieldDecl); !ok || f.Name != "side" {
+		t.Errorf("member expression refers to %#v, want field side", side.MemberDecl())
+	}
+	if name := refName(side.Base()); name != "sum" {
+		t.Errorf("member expression has base %#v, want sum", side.Base())
+	}
+}
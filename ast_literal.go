@@ -1,11 +1,25 @@
 package goclangast
 
-import "github.com/valyala/fastjson"
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/valyala/fastjson"
+)
 
 var LiteralMap = map[string]func() Node{
-	"IntegerLiteral":   func() Node { return &IntegerLiteral{} },
-	"StringLiteral":    func() Node { return &StringLiteral{} },
-	"CharacterLiteral": func() Node { return &CharacterLiteral{} },
+	"IntegerLiteral":     func() Node { return &IntegerLiteral{} },
+	"StringLiteral":      func() Node { return &StringLiteral{} },
+	"CharacterLiteral":   func() Node { return &CharacterLiteral{} },
+	"FloatingLiteral":    func() Node { return &FloatingLiteral{} },
+	"ImaginaryLiteral":   func() Node { return &ImaginaryLiteral{} },
+	"FixedPointLiteral":  func() Node { return &FixedPointLiteral{} },
+	"UserDefinedLiteral": func() Node { return &UserDefinedLiteral{} },
 }
 
 type IntegerLiteral struct {
@@ -27,6 +41,18 @@ func (l *IntegerLiteral) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return l.BaseNode.Unmarshal(v, ctx)
 }
 
+// Int returns the value of the literal, wrapped to the width and signedness of its type. The AST doesn't record the
+// target, so the widths of the integer types are those of LP64 targets such as x86-64 Linux: long is 64 bits, which
+// is wrong for ILP32 targets and for LLP64 targets such as Windows. The value is returned as written if the width of
+// the type is unknown.
+func (l *IntegerLiteral) Int() (*big.Int, bool) {
+	v, ok := new(big.Int).SetString(l.Value, 0)
+	if !ok {
+		return nil, false
+	}
+	return wrapInt(v, l.Type), true
+}
+
 type StringLiteral struct {
 	BaseNode
 	Type          Type   `json:"type"`
@@ -46,6 +72,189 @@ func (l *StringLiteral) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return l.BaseNode.Unmarshal(v, ctx)
 }
 
+// String literal encodings, the prefix of the literal as written in the source.
+const (
+	StringOrdinary = ""
+	StringWide     = "L"
+	StringUTF8     = "u8"
+	StringUTF16    = "u"
+	StringUTF32    = "U"
+)
+
+// Encoding returns the prefix of the literal, one of the String* constants.
+func (l *StringLiteral) Encoding() string {
+	prefix, _, _ := strings.Cut(l.Value, `"`)
+	return prefix
+}
+
+// CodeUnitSize returns the size in bytes of the code units of the literal. Wide strings are assumed to have a 32 bit
+// wchar_t unless the type of the literal says otherwise.
+func (l *StringLiteral) CodeUnitSize() int {
+	switch l.Encoding() {
+	case StringUTF16:
+		return 2
+	case StringUTF32:
+		return 4
+	case StringWide:
+		if ct, err := l.Type.DesugaredCType(); err == nil && ct.Kind == CTypeArray && ct.Elem != nil {
+			if w, ok := intTypeWidths[ct.Elem.Name]; ok && ct.Elem.Kind == CTypeBuiltin {
+				return w.bits / 8
+			}
+		}
+		return 4
+	}
+	return 1
+}
+
+// CodeUnits decodes the literal into its code units, excluding the null terminator. The code units of ordinary and
+// UTF-8 strings are bytes.
+func (l *StringLiteral) CodeUnits() ([]uint32, error) {
+	prefix, body, ok := strings.Cut(l.Value, `"`)
+	if !ok || !strings.HasSuffix(body, `"`) {
+		return nil, fmt.Errorf("malformed string literal %s", l.Value)
+	}
+	body = body[:len(body)-1]
+
+	switch prefix {
+	case StringOrdinary, StringWide, StringUTF8, StringUTF16, StringUTF32:
+	default:
+		return nil, fmt.Errorf("malformed string literal %s: unknown prefix", l.Value)
+	}
+
+	var (
+		size  = l.CodeUnitSize()
+		units []uint32
+	)
+	appendRune := func(r rune) {
+		switch size {
+		case 1:
+			for _, b := range []byte(string(r)) {
+				units = append(units, uint32(b))
+			}
+		case 2:
+			if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+				units = append(units, uint32(r1), uint32(r2))
+			} else {
+				units = append(units, uint32(r))
+			}
+		default:
+			units = append(units, uint32(r))
+		}
+	}
+
+	for i := 0; i < len(body); {
+		c := body[i]
+		if c == '"' {
+			// Clang splits the literal with "" after \x escapes followed by a hex digit.
+			i++
+			continue
+		}
+
+		if c != '\\' {
+			if size == 1 {
+				units = append(units, uint32(c))
+				i++
+				continue
+			}
+			r, n := utf8.DecodeRuneInString(body[i:])
+			appendRune(r)
+			i += n
+			continue
+		}
+
+		i++
+		if i == len(body) {
+			return nil, fmt.Errorf("malformed string literal %s: trailing backslash", l.Value)
+		}
+		c = body[i]
+		i++
+
+		switch c {
+		case 'a':
+			units = append(units, '\a')
+		case 'b':
+			units = append(units, '\b')
+		case 'f':
+			units = append(units, '\f')
+		case 'n':
+			units = append(units, '\n')
+		case 'r':
+			units = append(units, '\r')
+		case 't':
+			units = append(units, '\t')
+		case 'v':
+			units = append(units, '\v')
+		case 'e':
+			units = append(units, 0x1b)
+		case '\\', '"', '\'', '?':
+			units = append(units, uint32(c))
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			v := uint32(c - '0')
+			for n := 1; n < 3 && i < len(body) && body[i] >= '0' && body[i] <= '7'; n++ {
+				v = v<<3 | uint32(body[i]-'0')
+				i++
+			}
+			units = append(units, v)
+		case 'x':
+			j := i
+			for j < len(body) && isHexDigit(body[j]) {
+				j++
+			}
+			v, err := strconv.ParseUint(body[i:j], 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("malformed string literal %s: %w", l.Value, err)
+			}
+			units = append(units, uint32(v))
+			i = j
+		case 'u', 'U':
+			n := 4
+			if c == 'U' {
+				n = 8
+			}
+			if i+n > len(body) {
+				return nil, fmt.Errorf("malformed string literal %s: short universal character name", l.Value)
+			}
+			v, err := strconv.ParseUint(body[i:i+n], 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("malformed string literal %s: %w", l.Value, err)
+			}
+			appendRune(rune(v))
+			i += n
+		default:
+			return nil, fmt.Errorf("malformed string literal %s: unknown escape \\%c", l.Value, c)
+		}
+	}
+
+	return units, nil
+}
+
+// Bytes returns the contents of the literal as stored in memory, excluding the null terminator. Code units wider than
+// a byte are encoded in the given byte order.
+func (l *StringLiteral) Bytes(order binary.ByteOrder) ([]byte, error) {
+	units, err := l.CodeUnits()
+	if err != nil {
+		return nil, err
+	}
+
+	size := l.CodeUnitSize()
+	b := make([]byte, len(units)*size)
+	for i, u := range units {
+		switch size {
+		case 1:
+			b[i] = byte(u)
+		case 2:
+			order.PutUint16(b[i*2:], uint16(u))
+		default:
+			order.PutUint32(b[i*4:], u)
+		}
+	}
+	return b, nil
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
 type CharacterLiteral struct {
 	BaseNode
 	Type          Type   `json:"type"`
@@ -64,3 +273,214 @@ func (l *CharacterLiteral) Unmarshal(v *fastjson.Value, ctx *ParseContext) error
 	l.Value = v.GetInt("value")
 	return l.BaseNode.Unmarshal(v, ctx)
 }
+
+// Int returns the value of the literal, wrapped to the width and signedness of its type. Clang sign-extends plain char
+// literals if char is signed, so '\xff' is -1 both in C, where character literals have type int, and in C++, where they
+// have type char.
+//
+// The AST doesn't record the target, so char is taken to be signed and wchar_t to be a signed 32 bit type. On targets
+// where char is unsigned, such as arm64 Linux, or where wchar_t is 16 bits, such as Windows, the value of plain and
+// wide character literals with the high bit set is wrong.
+func (l *CharacterLiteral) Int() *big.Int {
+	return wrapInt(big.NewInt(int64(l.Value)), l.Type)
+}
+
+type FloatingLiteral struct {
+	BaseNode
+	Type          Type   `json:"type"`
+	ValueCategory string `json:"valueCategory"`
+	// Value is the value in decimal, such as "1.5", "1.0E+10" or "Inf".
+	Value string `json:"value"`
+}
+
+func (l *FloatingLiteral) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	var err error
+	l.Type, err = typeFromVal(v.Get("type"), ctx)
+	if err != nil {
+		return err
+	}
+
+	l.ValueCategory = string(v.GetStringBytes("valueCategory"))
+	l.Value = string(v.GetStringBytes("value"))
+	return l.BaseNode.Unmarshal(v, ctx)
+}
+
+// Float returns the value of the literal with the precision of its type, long double is assumed to be the x87 80 bit
+// format. It returns false for NaN, which big.Float can't represent.
+func (l *FloatingLiteral) Float() (*big.Float, bool) {
+	prec := uint(53)
+	if ct, err := l.Type.DesugaredCType(); err == nil && ct.Kind == CTypeBuiltin {
+		if p, ok := floatTypePrecisions[ct.Name]; ok {
+			prec = p
+		}
+	}
+
+	f, ok := new(big.Float).SetPrec(prec).SetString(l.Value)
+	return f, ok
+}
+
+// Float64 returns the value of the literal rounded to a float64, it returns false if the value is out of range.
+func (l *FloatingLiteral) Float64() (float64, bool) {
+	f, err := strconv.ParseFloat(l.Value, 64)
+	return f, err == nil
+}
+
+// floatTypePrecisions are the mantissa bits of the floating point types, including the implicit bit.
+var floatTypePrecisions = map[string]uint{
+	"__bf16":      8,
+	"_Float16":    11,
+	"__fp16":      11,
+	"float":       24,
+	"double":      53,
+	"long double": 64,
+	"__ibm128":    106,
+	"__float128":  113,
+	"_Float128":   113,
+}
+
+// ImaginaryLiteral is a GNU imaginary constant such as "2.0i", the value is the FloatingLiteral or IntegerLiteral
+// child.
+type ImaginaryLiteral struct {
+	BaseNode
+	Type          Type   `json:"type"`
+	ValueCategory string `json:"valueCategory"`
+}
+
+func (l *ImaginaryLiteral) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	var err error
+	l.Type, err = typeFromVal(v.Get("type"), ctx)
+	if err != nil {
+		return err
+	}
+
+	l.ValueCategory = string(v.GetStringBytes("valueCategory"))
+	return l.BaseNode.Unmarshal(v, ctx)
+}
+
+func (l *ImaginaryLiteral) SubExpr() Node {
+	return childAt(l.Inner, 0)
+}
+
+// Imag returns the imaginary part of the literal.
+func (l *ImaginaryLiteral) Imag() (*big.Float, bool) {
+	switch sub := l.SubExpr().(type) {
+	case *FloatingLiteral:
+		return sub.Float()
+	case *IntegerLiteral:
+		v, ok := sub.Int()
+		if !ok {
+			return nil, false
+		}
+		return new(big.Float).SetInt(v), true
+	}
+	return nil, false
+}
+
+// FixedPointLiteral is an Embedded C fixed point constant such as "0.5r" or "1.5k".
+type FixedPointLiteral struct {
+	BaseNode
+	Type          Type   `json:"type"`
+	ValueCategory string `json:"valueCategory"`
+	// Value is the exact value in decimal.
+	Value string `json:"value"`
+}
+
+func (l *FixedPointLiteral) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	var err error
+	l.Type, err = typeFromVal(v.Get("type"), ctx)
+	if err != nil {
+		return err
+	}
+
+	l.ValueCategory = string(v.GetStringBytes("valueCategory"))
+	l.Value = string(v.GetStringBytes("value"))
+	return l.BaseNode.Unmarshal(v, ctx)
+}
+
+// Float returns the value of the literal, the precision is sufficient to represent any fixed point type exactly.
+func (l *FixedPointLiteral) Float() (*big.Float, bool) {
+	return new(big.Float).SetPrec(64).SetString(l.Value)
+}
+
+// UserDefinedLiteral is a C++ literal with a ud-suffix, such as "10_km", which calls the literal operator. The children
+// are the callee and the arguments, like those of a CallExpr.
+type UserDefinedLiteral struct {
+	CallExpr
+}
+
+// Suffix returns the ud-suffix of the literal, such as "_km".
+func (l *UserDefinedLiteral) Suffix() string {
+	ref, ok := ignoreParenCasts(l.Callee()).(*DeclRefExpr)
+	if !ok {
+		return ""
+	}
+	_, suffix, _ := strings.Cut(ref.ReferencedDecl.Name, `""`)
+	return strings.TrimSpace(suffix)
+}
+
+// Literal returns the literal passed to the literal operator, with the ud-suffix removed. It is a StringLiteral holding
+// the spelling of the number for raw literal operators, and nil for literal operator templates, which receive the
+// literal as template arguments.
+func (l *UserDefinedLiteral) Literal() Node {
+	return childAt(l.Args(), 0)
+}
+
+type intWidth struct {
+	bits   int
+	signed bool
+}
+
+// intTypeWidths are the integer types as spelled by clang, assuming the LP64 data model with a signed char and 32 bit
+// wchar_t. The exported accessors which depend on them document the limitation.
+var intTypeWidths = map[string]intWidth{
+	"_Bool":              {1, false},
+	"bool":               {1, false},
+	"char":               {8, true},
+	"signed char":        {8, true},
+	"unsigned char":      {8, false},
+	"char8_t":            {8, false},
+	"short":              {16, true},
+	"unsigned short":     {16, false},
+	"char16_t":           {16, false},
+	"int":                {32, true},
+	"unsigned int":       {32, false},
+	"wchar_t":            {32, true},
+	"char32_t":           {32, false},
+	"long":               {64, true},
+	"unsigned long":      {64, false},
+	"long long":          {64, true},
+	"unsigned long long": {64, false},
+	"__int128":           {128, true},
+	"unsigned __int128":  {128, false},
+}
+
+// intTypeWidth returns the width and signedness of an integer type, including _BitInt(N).
+func intTypeWidth(t Type) (intWidth, bool) {
+	ct, err := t.DesugaredCType()
+	if err != nil || ct.Kind != CTypeBuiltin {
+		return intWidth{}, false
+	}
+
+	if ct.Bits > 0 {
+		return intWidth{ct.Bits, !strings.HasPrefix(ct.Name, "unsigned ")}, true
+	}
+
+	w, ok := intTypeWidths[ct.Name]
+	return w, ok
+}
+
+// wrapInt truncates v to the width of t and interprets it as signed or unsigned, v is returned unchanged if t isn't a
+// known integer type.
+func wrapInt(v *big.Int, t Type) *big.Int {
+	w, ok := intTypeWidth(t)
+	if !ok {
+		return v
+	}
+
+	mod := new(big.Int).Lsh(big.NewInt(1), uint(w.bits))
+	v = new(big.Int).Mod(v, mod)
+	if w.signed && v.Cmp(new(big.Int).Rsh(mod, 1)) >= 0 {
+		v.Sub(v, mod)
+	}
+	return v
+}
@@ -0,0 +1,353 @@
+package goclangast
+
+import "github.com/valyala/fastjson"
+
+var ObjCMap = map[string]func() Node{
+	"ObjCInterfaceDecl":      func() Node { return &ObjCInterfaceDecl{} },
+	"ObjCImplementationDecl": func() Node { return &ObjCImplementationDecl{} },
+	"ObjCProtocolDecl":       func() Node { return &ObjCProtocolDecl{} },
+	"ObjCCategoryDecl":       func() Node { return &ObjCCategoryDecl{} },
+	"ObjCMethodDecl":         func() Node { return &ObjCMethodDecl{} },
+	"ObjCPropertyDecl":       func() Node { return &ObjCPropertyDecl{} },
+	"ObjCIvarDecl":           func() Node { return &ObjCIvarDecl{} },
+	"ObjCMessageExpr":        func() Node { return &ObjCMessageExpr{} },
+}
+
+// referencedDeclsFromVal decodes an array of declaration references, such as the protocols an interface conforms to.
+func referencedDeclsFromVal(vals []*fastjson.Value, ctx *ParseContext) ([]ReferencedDecl, error) {
+	var decls []ReferencedDecl
+	for _, v := range vals {
+		var d ReferencedDecl
+		err := d.Unmarshal(v, ctx)
+		if err != nil {
+			return nil, err
+		}
+		decls = append(decls, d)
+	}
+	return decls, nil
+}
+
+type ObjCInterfaceDecl struct {
+	BaseNode
+	Name           string           `json:"name"`
+	Super          ReferencedDecl   `json:"super"`
+	Implementation ReferencedDecl   `json:"implementation"`
+	Protocols      []ReferencedDecl `json:"protocols"`
+}
+
+func (d *ObjCInterfaceDecl) DeclName() string {
+	return d.Name
+}
+
+func (d *ObjCInterfaceDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	var err error
+	d.Name = string(v.GetStringBytes("name"))
+	err = d.Super.Unmarshal(v.Get("super"), ctx)
+	if err != nil {
+		return err
+	}
+	err = d.Implementation.Unmarshal(v.Get("implementation"), ctx)
+	if err != nil {
+		return err
+	}
+	d.Protocols, err = referencedDeclsFromVal(v.GetArray("protocols"), ctx)
+	if err != nil {
+		return err
+	}
+	return d.BaseNode.Unmarshal(v, ctx)
+}
+
+// SuperDecl returns the interface of the superclass, or nil for root classes.
+func (d *ObjCInterfaceDecl) SuperDecl() *ObjCInterfaceDecl {
+	s, _ := lookupFrom(&d.BaseNode, d.Super.ID).(*ObjCInterfaceDecl)
+	return s
+}
+
+// ImplementationDecl returns the implementation of the interface, or nil if it isn't part of the translation unit.
+func (d *ObjCInterfaceDecl) ImplementationDecl() *ObjCImplementationDecl {
+	i, _ := lookupFrom(&d.BaseNode, d.Implementation.ID).(*ObjCImplementationDecl)
+	return i
+}
+
+type ObjCImplementationDecl struct {
+	BaseNode
+	Name      string         `json:"name"`
+	Super     ReferencedDecl `json:"super"`
+	Interface ReferencedDecl `json:"interface"`
+}
+
+func (d *ObjCImplementationDecl) DeclName() string {
+	return d.Name
+}
+
+func (d *ObjCImplementationDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	d.Name = string(v.GetStringBytes("name"))
+	err := d.Super.Unmarshal(v.Get("super"), ctx)
+	if err != nil {
+		return err
+	}
+	err = d.Interface.Unmarshal(v.Get("interface"), ctx)
+	if err != nil {
+		return err
+	}
+	return d.BaseNode.Unmarshal(v, ctx)
+}
+
+// InterfaceDecl returns the interface implemented.
+func (d *ObjCImplementationDecl) InterfaceDecl() *ObjCInterfaceDecl {
+	i, _ := lookupFrom(&d.BaseNode, d.Interface.ID).(*ObjCInterfaceDecl)
+	return i
+}
+
+type ObjCProtocolDecl struct {
+	BaseNode
+	Name      string           `json:"name"`
+	Protocols []ReferencedDecl `json:"protocols"`
+}
+
+func (d *ObjCProtocolDecl) DeclName() string {
+	return d.Name
+}
+
+func (d *ObjCProtocolDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	var err error
+	d.Name = string(v.GetStringBytes("name"))
+	d.Protocols, err = referencedDeclsFromVal(v.GetArray("protocols"), ctx)
+	if err != nil {
+		return err
+	}
+	return d.BaseNode.Unmarshal(v, ctx)
+}
+
+type ObjCCategoryDecl struct {
+	BaseNode
+	Name           string           `json:"name"`
+	Interface      ReferencedDecl   `json:"interface"`
+	Implementation ReferencedDecl   `json:"implementation"`
+	Protocols      []ReferencedDecl `json:"protocols"`
+}
+
+func (d *ObjCCategoryDecl) DeclName() string {
+	return d.Name
+}
+
+func (d *ObjCCategoryDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	var err error
+	d.Name = string(v.GetStringBytes("name"))
+	err = d.Interface.Unmarshal(v.Get("interface"), ctx)
+	if err != nil {
+		return err
+	}
+	err = d.Implementation.Unmarshal(v.Get("implementation"), ctx)
+	if err != nil {
+		return err
+	}
+	d.Protocols, err = referencedDeclsFromVal(v.GetArray("protocols"), ctx)
+	if err != nil {
+		return err
+	}
+	return d.BaseNode.Unmarshal(v, ctx)
+}
+
+// InterfaceDecl returns the interface the category extends.
+func (d *ObjCCategoryDecl) InterfaceDecl() *ObjCInterfaceDecl {
+	i, _ := lookupFrom(&d.BaseNode, d.Interface.ID).(*ObjCInterfaceDecl)
+	return i
+}
+
+type ObjCMethodDecl struct {
+	BaseNode
+	// Name is the selector of the method, such as "initWithName:age:".
+	Name       string `json:"name"`
+	ReturnType Type   `json:"returnType"`
+	// Instance is set for instance methods, declared with "-", and unset for class methods, declared with "+".
+	Instance bool `json:"instance"`
+	Variadic bool `json:"variadic"`
+}
+
+func (d *ObjCMethodDecl) DeclName() string {
+	return d.Name
+}
+
+func (d *ObjCMethodDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	var err error
+	d.Name = string(v.GetStringBytes("name"))
+	d.Instance = v.GetBool("instance")
+	d.Variadic = v.GetBool("variadic")
+	d.ReturnType, err = typeFromVal(v.Get("returnType"), ctx)
+	if err != nil {
+		return err
+	}
+	return d.BaseNode.Unmarshal(v, ctx)
+}
+
+// IsClassMethod reports whether the method is a class method, declared with "+".
+func (d *ObjCMethodDecl) IsClassMethod() bool {
+	return !d.Instance
+}
+
+// Params returns the parameters of the method.
+func (d *ObjCMethodDecl) Params() []*ParmVarDecl {
+	var params []*ParmVarDecl
+	for _, c := range d.Inner {
+		if p, ok := c.(*ParmVarDecl); ok {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// Body returns the body of the method, or nil if this is a declaration.
+func (d *ObjCMethodDecl) Body() *CompoundStmt {
+	for _, c := range d.Inner {
+		if body, ok := c.(*CompoundStmt); ok {
+			return body
+		}
+	}
+	return nil
+}
+
+type ObjCPropertyDecl struct {
+	BaseNode
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+	// Control is "required" or "optional" for properties declared in a protocol.
+	Control string         `json:"control"`
+	Getter  ReferencedDecl `json:"getter"`
+	Setter  ReferencedDecl `json:"setter"`
+
+	Readonly         bool `json:"readonly"`
+	Assign           bool `json:"assign"`
+	Readwrite        bool `json:"readwrite"`
+	Retain           bool `json:"retain"`
+	Copy             bool `json:"copy"`
+	Nonatomic        bool `json:"nonatomic"`
+	Atomic           bool `json:"atomic"`
+	Weak             bool `json:"weak"`
+	Strong           bool `json:"strong"`
+	UnsafeUnretained bool `json:"unsafe_unretained"`
+	Class            bool `json:"class"`
+	Direct           bool `json:"direct"`
+	Nullability      bool `json:"nullability"`
+	NullResettable   bool `json:"null_resettable"`
+}
+
+func (d *ObjCPropertyDecl) DeclName() string {
+	return d.Name
+}
+
+func (d *ObjCPropertyDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	var err error
+	d.Name = string(v.GetStringBytes("name"))
+	d.Type, err = typeFromVal(v.Get("type"), ctx)
+	if err != nil {
+		return err
+	}
+	d.Control = string(v.GetStringBytes("control"))
+	err = d.Getter.Unmarshal(v.Get("getter"), ctx)
+	if err != nil {
+		return err
+	}
+	err = d.Setter.Unmarshal(v.Get("setter"), ctx)
+	if err != nil {
+		return err
+	}
+
+	d.Readonly = v.GetBool("readonly")
+	d.Assign = v.GetBool("assign")
+	d.Readwrite = v.GetBool("readwrite")
+	d.Retain = v.GetBool("retain")
+	d.Copy = v.GetBool("copy")
+	d.Nonatomic = v.GetBool("nonatomic")
+	d.Atomic = v.GetBool("atomic")
+	d.Weak = v.GetBool("weak")
+	d.Strong = v.GetBool("strong")
+	d.UnsafeUnretained = v.GetBool("unsafe_unretained")
+	d.Class = v.GetBool("class")
+	d.Direct = v.GetBool("direct")
+	d.Nullability = v.GetBool("nullability")
+	d.NullResettable = v.GetBool("null_resettable")
+
+	return d.BaseNode.Unmarshal(v, ctx)
+}
+
+type ObjCIvarDecl struct {
+	FieldDecl
+	// Access is "none", "private", "protected", "public" or "package".
+	Access        string `json:"access"`
+	IsSynthesized bool   `json:"isSynthesized"`
+}
+
+func (d *ObjCIvarDecl) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	d.Access = string(v.GetStringBytes("access"))
+	d.IsSynthesized = v.GetBool("isSynthesized")
+	return d.FieldDecl.Unmarshal(v, ctx)
+}
+
+// Receiver kinds of an ObjCMessageExpr.
+const (
+	ObjCReceiverInstance      = "instance"
+	ObjCReceiverClass         = "class"
+	ObjCReceiverSuperInstance = "super (instance)"
+	ObjCReceiverSuperClass    = "super (class)"
+)
+
+type ObjCMessageExpr struct {
+	Expr
+	// Selector is the selector of the message, such as "initWithName:age:".
+	Selector     string `json:"selector"`
+	ReceiverKind string `json:"receiverKind"`
+	// ClassType is the receiver of class messages.
+	ClassType Type `json:"classType"`
+	// SuperType is the type of super for messages sent to super.
+	SuperType      Type `json:"superType"`
+	CallReturnType Type `json:"callReturnType"`
+}
+
+func (d *ObjCMessageExpr) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	var err error
+	d.Selector = string(v.GetStringBytes("selector"))
+	d.ReceiverKind = ctx.InternBytes(v.GetStringBytes("receiverKind"))
+	d.ClassType, err = typeFromVal(v.Get("classType"), ctx)
+	if err != nil {
+		return err
+	}
+	d.SuperType, err = typeFromVal(v.Get("superType"), ctx)
+	if err != nil {
+		return err
+	}
+	d.CallReturnType, err = typeFromVal(v.Get("callReturnType"), ctx)
+	if err != nil {
+		return err
+	}
+	return d.Expr.Unmarshal(v, ctx)
+}
+
+// IsInstanceMessage reports whether the message is sent to an object, including super in an instance method.
+func (d *ObjCMessageExpr) IsInstanceMessage() bool {
+	return d.ReceiverKind == ObjCReceiverInstance || d.ReceiverKind == ObjCReceiverSuperInstance
+}
+
+// IsClassMessage reports whether the message is sent to a class, including super in a class method.
+func (d *ObjCMessageExpr) IsClassMessage() bool {
+	return d.ReceiverKind == ObjCReceiverClass || d.ReceiverKind == ObjCReceiverSuperClass
+}
+
+// Receiver returns the expression the message is sent to, or nil if the receiver is a class or super.
+func (d *ObjCMessageExpr) Receiver() Node {
+	if d.ReceiverKind != ObjCReceiverInstance {
+		return nil
+	}
+	return childAt(d.Inner, 0)
+}
+
+// Args returns the arguments of the message.
+func (d *ObjCMessageExpr) Args() []Node {
+	if d.ReceiverKind != ObjCReceiverInstance {
+		return d.Inner
+	}
+	if len(d.Inner) == 0 {
+		return nil
+	}
+	return d.Inner[1:]
+}
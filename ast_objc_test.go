@@ -0,0 +1,193 @@
+package goclangast
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testdata/objc/messages.json is in the format of clang -x objective-c -Xclang -ast-dump=json -fsyntax-only for
+// messages.m, trimmed of most implicit declarations.
+
+// objcDecl returns the first node of the kind named name in tu.
+func objcDecl(t *testing.T, tu *TranslationUnitDecl, kind, name string) NamedDecl {
+	t.Helper()
+
+	for _, n := range nodesOfKind(tu, kind) {
+		if d := n.(NamedDecl); d.DeclName() == name {
+			return d
+		}
+	}
+	t.Fatalf("%s %s not found", kind, name)
+	return nil
+}
+
+func TestObjCDecls(t *testing.T) {
+	tu := parseTestdata(t, "testdata/objc/messages.json")
+
+	named := objcDecl(t, tu, "ObjCProtocolDecl", "Named").(*ObjCProtocolDecl)
+	base := objcDecl(t, tu, "ObjCInterfaceDecl", "Base").(*ObjCInterfaceDecl)
+	person := objcDecl(t, tu, "ObjCInterfaceDecl", "Person").(*ObjCInterfaceDecl)
+	impl := objcDecl(t, tu, "ObjCImplementationDecl", "Person").(*ObjCImplementationDecl)
+	category := objcDecl(t, tu, "ObjCCategoryDecl", "Greeting").(*ObjCCategoryDecl)
+
+	if base.SuperDecl() != nil || base.ImplementationDecl() != nil {
+		t.Errorf("Base has super %v and implementation %v, want a root class without implementation",
+			base.SuperDecl(), base.ImplementationDecl())
+	}
+	if person.SuperDecl() != base || person.ImplementationDecl() != impl || impl.InterfaceDecl() != person {
+		t.Errorf("Person has super %v and implementation %v, want Base and the implementation of Person",
+			person.SuperDecl(), person.ImplementationDecl())
+	}
+	if len(person.Protocols) != 1 || person.Protocols[0].ID != named.ID || person.Protocols[0].Name != "Named" {
+		t.Errorf("Person conforms to %+v, want Named", person.Protocols)
+	}
+	if category.InterfaceDecl() != person || category.Implementation.ID != "0x0" {
+		t.Errorf("category Greeting extends %v with implementation %+v, want Person without implementation",
+			category.InterfaceDecl(), category.Implementation)
+	}
+
+	ivar := objcDecl(t, tu, "ObjCIvarDecl", "_secret").(*ObjCIvarDecl)
+	if ivar.Access != "private" || ivar.IsSynthesized || ivar.Type.QualType != "int" {
+		t.Errorf("ivar _secret is %+v, want a private int", ivar)
+	}
+
+	// The attributes clang implies, such as assign for scalars, are dumped along with the written ones.
+	for _, tt := range []struct {
+		name string
+		want ObjCPropertyDecl
+	}{
+		{"name", ObjCPropertyDecl{Control: "required", Readonly: true, Copy: true, Nonatomic: true}},
+		{"nickname", ObjCPropertyDecl{Control: "optional", Readwrite: true, Strong: true, Nonatomic: true,
+			Setter: ReferencedDecl{Kind: "ObjCMethodDecl", Name: "changeNickname:"}}},
+		{"age", ObjCPropertyDecl{Readonly: true, Assign: true, UnsafeUnretained: true,
+			Getter: ReferencedDecl{Kind: "ObjCMethodDecl", Name: "years"}}},
+	} {
+		got := *objcDecl(t, tu, "ObjCPropertyDecl", tt.name).(*ObjCPropertyDecl)
+		got.BaseNode, got.Name, got.Type = BaseNode{}, "", Type{}
+		got.Getter.ID, got.Setter.ID = "", ""
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("property %s is %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// The getter named by the property is the implicit method declared for it.
+	age := objcDecl(t, tu, "ObjCPropertyDecl", "age").(*ObjCPropertyDecl)
+	if m, ok := lookupFrom(&age.BaseNode, age.Getter.ID).(*ObjCMethodDecl); !ok || m.Parent() != person {
+		t.Errorf("getter of age is %v, want the implicit method years of Person", m)
+	}
+}
+
+func TestObjCMethodDecl(t *testing.T) {
+	tu := parseTestdata(t, "testdata/objc/messages.json")
+
+	type method struct {
+		parent   string
+		selector string
+		instance bool
+		params   string
+		body     bool
+	}
+	var got []method
+	for _, n := range nodesOfKind(tu, "ObjCMethodDecl") {
+		d := n.(*ObjCMethodDecl)
+		if d.Instance == d.IsClassMethod() {
+			t.Errorf("%s has Instance %v and IsClassMethod %v", d.Name, d.Instance, d.IsClassMethod())
+		}
+
+		var params []string
+		for _, p := range d.Params() {
+			params = append(params, p.Type.QualType+" "+p.Name)
+		}
+		got = append(got, method{
+			parent:   d.Parent().(NamedDecl).DeclName(),
+			selector: d.Name,
+			instance: d.Instance,
+			params:   strings.Join(params, ", "),
+			body:     d.Body() != nil,
+		})
+	}
+
+	want := []method{
+		{"Named", "name", true, "", false},
+		{"Named", "nickname", true, "", false},
+		{"Named", "changeNickname:", true, "id nickname", false},
+		{"Base", "alloc", false, "", false},
+		{"Base", "init", true, "", false},
+		{"Person", "personWithAge:", false, "int age", false},
+		{"Person", "initWithName:age:", true, "id name, int age", false},
+		{"Person", "years", true, "", false},
+		{"Greeting", "greet", true, "", false},
+		// The implicit self and _cmd parameters of definitions aren't ParmVarDecls.
+		{"Person", "personWithAge:", false, "int age", true},
+		{"Person", "initWithName:age:", true, "id name, int age", true},
+		{"Person", "years", true, "", true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got methods\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestObjCMessageExpr(t *testing.T) {
+	tu := parseTestdata(t, "testdata/objc/messages.json")
+
+	// describe spells out a receiver or argument, looking through implicit casts.
+	describe := func(n Node) string {
+		for {
+			c, ok := n.(*ImplicitCastExpr)
+			if !ok {
+				break
+			}
+			n = c.Inner[0]
+		}
+		switch n := n.(type) {
+		case *DeclRefExpr:
+			return n.ReferencedDecl.Name
+		case *IntegerLiteral:
+			return n.Value
+		case nil:
+			return "<nil>"
+		}
+		return n.GetBaseNode().Kind
+	}
+
+	type message struct {
+		selector string
+		kind     string
+		instance bool
+		class    bool
+		receiver string
+		args     []string
+		// typ is the class type of class messages and the super type of messages to super.
+		typ        string
+		returnType string
+	}
+	var got []message
+	for _, n := range nodesOfKind(tu, "ObjCMessageExpr") {
+		e := n.(*ObjCMessageExpr)
+		m := message{
+			selector:   e.Selector,
+			kind:       e.ReceiverKind,
+			instance:   e.IsInstanceMessage(),
+			class:      e.IsClassMessage(),
+			receiver:   describe(e.Receiver()),
+			typ:        e.ClassType.QualType + e.SuperType.QualType,
+			returnType: e.CallReturnType.QualType,
+		}
+		for _, a := range e.Args() {
+			m.args = append(m.args, describe(a))
+		}
+		got = append(got, m)
+	}
+
+	want := []message{
+		{"alloc", ObjCReceiverSuperClass, false, true, "<nil>", nil, "Base", "id"},
+		{"initWithName:age:", ObjCReceiverInstance, true, false, "p", []string{"0", "age"}, "", ""},
+		{"init", ObjCReceiverSuperInstance, true, false, "<nil>", nil, "Base *", "id"},
+		{"personWithAge:", ObjCReceiverClass, false, true, "<nil>", []string{"3"}, "Person", ""},
+		{"years", ObjCReceiverInstance, true, false, "p", nil, "", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got messages\n%+v\nwant\n%+v", got, want)
+	}
+}
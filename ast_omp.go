@@ -0,0 +1,538 @@
+package goclangast
+
+import (
+	"strings"
+
+	"github.com/valyala/fastjson"
+)
+
+var OMPMap = map[string]func() Node{
+	"OMPAtomicDirective":                               func() Node { return &OMPAtomicDirective{} },
+	"OMPBarrierDirective":                              func() Node { return &OMPBarrierDirective{} },
+	"OMPCancelDirective":                               func() Node { return &OMPCancelDirective{} },
+	"OMPCancellationPointDirective":                    func() Node { return &OMPCancellationPointDirective{} },
+	"OMPCriticalDirective":                             func() Node { return &OMPCriticalDirective{} },
+	"OMPDepobjDirective":                               func() Node { return &OMPDepobjDirective{} },
+	"OMPDispatchDirective":                             func() Node { return &OMPDispatchDirective{} },
+	"OMPDistributeDirective":                           func() Node { return &OMPDistributeDirective{} },
+	"OMPDistributeParallelForDirective":                func() Node { return &OMPDistributeParallelForDirective{} },
+	"OMPDistributeParallelForSimdDirective":            func() Node { return &OMPDistributeParallelForSimdDirective{} },
+	"OMPDistributeSimdDirective":                       func() Node { return &OMPDistributeSimdDirective{} },
+	"OMPFlushDirective":                                func() Node { return &OMPFlushDirective{} },
+	"OMPForDirective":                                  func() Node { return &OMPForDirective{} },
+	"OMPForSimdDirective":                              func() Node { return &OMPForSimdDirective{} },
+	"OMPGenericLoopDirective":                          func() Node { return &OMPGenericLoopDirective{} },
+	"OMPInteropDirective":                              func() Node { return &OMPInteropDirective{} },
+	"OMPMaskedDirective":                               func() Node { return &OMPMaskedDirective{} },
+	"OMPMasterDirective":                               func() Node { return &OMPMasterDirective{} },
+	"OMPMasterTaskLoopDirective":                       func() Node { return &OMPMasterTaskLoopDirective{} },
+	"OMPMasterTaskLoopSimdDirective":                   func() Node { return &OMPMasterTaskLoopSimdDirective{} },
+	"OMPMetaDirective":                                 func() Node { return &OMPMetaDirective{} },
+	"OMPOrderedDirective":                              func() Node { return &OMPOrderedDirective{} },
+	"OMPParallelDirective":                             func() Node { return &OMPParallelDirective{} },
+	"OMPParallelForDirective":                          func() Node { return &OMPParallelForDirective{} },
+	"OMPParallelForSimdDirective":                      func() Node { return &OMPParallelForSimdDirective{} },
+	"OMPParallelMasterDirective":                       func() Node { return &OMPParallelMasterDirective{} },
+	"OMPParallelMasterTaskLoopDirective":               func() Node { return &OMPParallelMasterTaskLoopDirective{} },
+	"OMPParallelMasterTaskLoopSimdDirective":           func() Node { return &OMPParallelMasterTaskLoopSimdDirective{} },
+	"OMPParallelSectionsDirective":                     func() Node { return &OMPParallelSectionsDirective{} },
+	"OMPScanDirective":                                 func() Node { return &OMPScanDirective{} },
+	"OMPSectionDirective":                              func() Node { return &OMPSectionDirective{} },
+	"OMPSectionsDirective":                             func() Node { return &OMPSectionsDirective{} },
+	"OMPSimdDirective":                                 func() Node { return &OMPSimdDirective{} },
+	"OMPSingleDirective":                               func() Node { return &OMPSingleDirective{} },
+	"OMPTargetDataDirective":                           func() Node { return &OMPTargetDataDirective{} },
+	"OMPTargetDirective":                               func() Node { return &OMPTargetDirective{} },
+	"OMPTargetEnterDataDirective":                      func() Node { return &OMPTargetEnterDataDirective{} },
+	"OMPTargetExitDataDirective":                       func() Node { return &OMPTargetExitDataDirective{} },
+	"OMPTargetParallelDirective":                       func() Node { return &OMPTargetParallelDirective{} },
+	"OMPTargetParallelForDirective":                    func() Node { return &OMPTargetParallelForDirective{} },
+	"OMPTargetParallelForSimdDirective":                func() Node { return &OMPTargetParallelForSimdDirective{} },
+	"OMPTargetSimdDirective":                           func() Node { return &OMPTargetSimdDirective{} },
+	"OMPTargetTeamsDirective":                          func() Node { return &OMPTargetTeamsDirective{} },
+	"OMPTargetTeamsDistributeDirective":                func() Node { return &OMPTargetTeamsDistributeDirective{} },
+	"OMPTargetTeamsDistributeParallelForDirective":     func() Node { return &OMPTargetTeamsDistributeParallelForDirective{} },
+	"OMPTargetTeamsDistributeParallelForSimdDirective": func() Node { return &OMPTargetTeamsDistributeParallelForSimdDirective{} },
+	"OMPTargetTeamsDistributeSimdDirective":            func() Node { return &OMPTargetTeamsDistributeSimdDirective{} },
+	"OMPTargetUpdateDirective":                         func() Node { return &OMPTargetUpdateDirective{} },
+	"OMPTaskDirective":                                 func() Node { return &OMPTaskDirective{} },
+	"OMPTaskLoopDirective":                             func() Node { return &OMPTaskLoopDirective{} },
+	"OMPTaskLoopSimdDirective":                         func() Node { return &OMPTaskLoopSimdDirective{} },
+	"OMPTaskgroupDirective":                            func() Node { return &OMPTaskgroupDirective{} },
+	"OMPTaskwaitDirective":                             func() Node { return &OMPTaskwaitDirective{} },
+	"OMPTaskyieldDirective":                            func() Node { return &OMPTaskyieldDirective{} },
+	"OMPTeamsDirective":                                func() Node { return &OMPTeamsDirective{} },
+	"OMPTeamsDistributeDirective":                      func() Node { return &OMPTeamsDistributeDirective{} },
+	"OMPTeamsDistributeParallelForDirective":           func() Node { return &OMPTeamsDistributeParallelForDirective{} },
+	"OMPTeamsDistributeParallelForSimdDirective":       func() Node { return &OMPTeamsDistributeParallelForSimdDirective{} },
+	"OMPTeamsDistributeSimdDirective":                  func() Node { return &OMPTeamsDistributeSimdDirective{} },
+	"OMPTileDirective":                                 func() Node { return &OMPTileDirective{} },
+	"OMPUnrollDirective":                               func() Node { return &OMPUnrollDirective{} },
+}
+
+// Kinds of OpenMP clauses, OMPClause is the kind of clauses which have not been identified.
+const (
+	OMPClauseKind         = "OMPClause"
+	OMPClausePrivate      = "OMPPrivateClause"
+	OMPClauseFirstprivate = "OMPFirstprivateClause"
+	OMPClauseLastprivate  = "OMPLastprivateClause"
+	OMPClauseShared       = "OMPSharedClause"
+	OMPClauseReduction    = "OMPReductionClause"
+	OMPClauseSchedule     = "OMPScheduleClause"
+	OMPClauseCollapse     = "OMPCollapseClause"
+)
+
+func isOMPClauseKind(kind string) bool {
+	return strings.HasPrefix(kind, "OMP") && strings.HasSuffix(kind, "Clause")
+}
+
+// OMPClause is a clause of an OpenMP directive, its children are the variables or expressions of the clause. Clang
+// doesn't include the kind of clauses in the JSON dump, so Kind is OMPClauseKind unless the AST was created with
+// Options.OpenMPClauses, in which case it is the kind clang uses, such as OMPPrivateClause. If the text dump doesn't
+// match the JSON dump, the kinds are left unknown and a warning is added to the diagnostics.
+type OMPClause struct {
+	BaseNode
+	// IsImplicit is set for clauses clang added, such as the implicit shared clauses of a task. Like Kind it requires
+	// Options.OpenMPClauses.
+	IsImplicit bool `json:"isImplicit"`
+}
+
+func (c *OMPClause) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	c.IsImplicit = v.GetBool("isImplicit")
+	err := c.BaseNode.Unmarshal(v, ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.Kind == "" {
+		c.Kind = OMPClauseKind
+	}
+	return nil
+}
+
+// asOMPDirective returns the OMPExecutableDirective of an OpenMP directive, or nil for other nodes.
+func asOMPDirective(n Node) *OMPExecutableDirective {
+	if d, ok := n.(interface {
+		ompDirective() *OMPExecutableDirective
+	}); ok {
+		return d.ompDirective()
+	}
+	return nil
+}
+
+// OMPExecutableDirective holds what all OpenMP directives have in common. The clauses of a directive are followed by
+// the statement the directive applies to, if any, wrapped in a CapturedStmt.
+type OMPExecutableDirective struct {
+	BaseNode
+}
+
+func (d *OMPExecutableDirective) ompDirective() *OMPExecutableDirective {
+	return d
+}
+
+func (d *OMPExecutableDirective) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	err := d.BaseNode.Unmarshal(v, ctx)
+	if err != nil {
+		return err
+	}
+
+	// Clang dumps clauses as objects without a kind, which BaseNode.Unmarshal skips, they precede the other children.
+	var clauses []Node
+	for _, cv := range v.GetArray("inner") {
+		if len(cv.GetStringBytes("kind")) != 0 {
+			continue
+		}
+
+		c := &OMPClause{}
+		err = c.Unmarshal(cv, ctx)
+		if err != nil {
+			return err
+		}
+		clauses = append(clauses, c)
+	}
+
+	if len(clauses) > 0 {
+		d.Inner = append(clauses, d.Inner...)
+	}
+	return nil
+}
+
+// Clauses returns the clauses of the directive, including the implicit ones clang added.
+func (d *OMPExecutableDirective) Clauses() []*OMPClause {
+	var clauses []*OMPClause
+	for _, c := range d.Inner {
+		if clause, ok := c.(*OMPClause); ok {
+			clauses = append(clauses, clause)
+		}
+	}
+	return clauses
+}
+
+// ClauseKindsKnown reports whether the kinds of the clauses of the directive are known, which requires
+// Options.OpenMPClauses. Without them the accessors for specific clauses, such as Private, report false.
+func (d *OMPExecutableDirective) ClauseKindsKnown() bool {
+	for _, c := range d.Clauses() {
+		if c.Kind == OMPClauseKind {
+			return false
+		}
+	}
+	return true
+}
+
+// ClausesOf returns the clauses of the given kind, such as OMPClausePrivate. ok is false if the kinds of the clauses
+// are unknown, see ClauseKindsKnown.
+func (d *OMPExecutableDirective) ClausesOf(kind string) (clauses []*OMPClause, ok bool) {
+	if !d.ClauseKindsKnown() {
+		return nil, false
+	}
+
+	for _, c := range d.Clauses() {
+		if c.Kind == kind {
+			clauses = append(clauses, c)
+		}
+	}
+	return clauses, true
+}
+
+// clauseVars returns the children of all clauses of the given kind.
+func (d *OMPExecutableDirective) clauseVars(kind string) ([]Node, bool) {
+	clauses, ok := d.ClausesOf(kind)
+	var vars []Node
+	for _, c := range clauses {
+		vars = append(vars, c.Inner...)
+	}
+	return vars, ok
+}
+
+// Private returns the references to the variables listed in private clauses, ok is false if the clause kinds are
+// unknown.
+func (d *OMPExecutableDirective) Private() (vars []Node, ok bool) {
+	return d.clauseVars(OMPClausePrivate)
+}
+
+// Firstprivate returns the references to the variables listed in firstprivate clauses, ok is false if the clause
+// kinds are unknown.
+func (d *OMPExecutableDirective) Firstprivate() (vars []Node, ok bool) {
+	return d.clauseVars(OMPClauseFirstprivate)
+}
+
+// Lastprivate returns the references to the variables listed in lastprivate clauses, ok is false if the clause kinds
+// are unknown.
+func (d *OMPExecutableDirective) Lastprivate() (vars []Node, ok bool) {
+	return d.clauseVars(OMPClauseLastprivate)
+}
+
+// Shared returns the references to the variables listed in shared clauses, ok is false if the clause kinds are
+// unknown.
+func (d *OMPExecutableDirective) Shared() (vars []Node, ok bool) {
+	return d.clauseVars(OMPClauseShared)
+}
+
+// Reduction returns the references to the variables listed in reduction clauses, ok is false if the clause kinds are
+// unknown.
+func (d *OMPExecutableDirective) Reduction() (vars []Node, ok bool) {
+	return d.clauseVars(OMPClauseReduction)
+}
+
+// Schedule returns the schedule clause, or nil if there is none. Its child is the chunk size, if specified. ok is
+// false if the clause kinds are unknown.
+func (d *OMPExecutableDirective) Schedule() (clause *OMPClause, ok bool) {
+	clauses, ok := d.ClausesOf(OMPClauseSchedule)
+	if len(clauses) == 0 {
+		return nil, ok
+	}
+	return clauses[0], true
+}
+
+// Collapse returns the number of loops collapsed by the collapse clause, which is 1 without a collapse clause. ok is
+// false if the clause kinds are unknown.
+func (d *OMPExecutableDirective) Collapse() (n int, ok bool) {
+	clauses, ok := d.ClausesOf(OMPClauseCollapse)
+	if !ok {
+		return 0, false
+	}
+	for _, c := range clauses {
+		if n, ok := EvalInt(childAt(c.Inner, 0)); ok && n.IsInt64() {
+			return int(n.Int64()), true
+		}
+	}
+	return 1, true
+}
+
+// AssociatedStmt returns the CapturedStmt the directive applies to, or nil for stand-alone directives such as
+// barrier.
+func (d *OMPExecutableDirective) AssociatedStmt() Node {
+	for _, c := range d.Inner {
+		if _, ok := c.(*OMPClause); !ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// StructuredBlock returns the statement the directive applies to, without the CapturedStmt's clang wraps it in.
+func (d *OMPExecutableDirective) StructuredBlock() Node {
+	n := d.AssociatedStmt()
+	for {
+		s, ok := n.(*CapturedStmt)
+		if !ok {
+			return n
+		}
+		n = s.Body()
+	}
+}
+
+// OMPLoopDirective holds what all OpenMP loop directives, such as "omp for" and "omp simd", have in common.
+type OMPLoopDirective struct {
+	OMPExecutableDirective
+}
+
+// Loop returns the outermost loop the directive applies to.
+func (d *OMPLoopDirective) Loop() *ForStmt {
+	f, _ := d.StructuredBlock().(*ForStmt)
+	return f
+}
+
+type OMPAtomicDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPBarrierDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPCancelDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPCancellationPointDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPCriticalDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPDepobjDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPDispatchDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPDistributeDirective struct {
+	OMPLoopDirective
+}
+
+type OMPDistributeParallelForDirective struct {
+	OMPLoopDirective
+}
+
+type OMPDistributeParallelForSimdDirective struct {
+	OMPLoopDirective
+}
+
+type OMPDistributeSimdDirective struct {
+	OMPLoopDirective
+}
+
+type OMPFlushDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPForDirective struct {
+	OMPLoopDirective
+}
+
+type OMPForSimdDirective struct {
+	OMPLoopDirective
+}
+
+type OMPGenericLoopDirective struct {
+	OMPLoopDirective
+}
+
+type OMPInteropDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPMaskedDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPMasterDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPMasterTaskLoopDirective struct {
+	OMPLoopDirective
+}
+
+type OMPMasterTaskLoopSimdDirective struct {
+	OMPLoopDirective
+}
+
+type OMPMetaDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPOrderedDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPParallelDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPParallelForDirective struct {
+	OMPLoopDirective
+}
+
+type OMPParallelForSimdDirective struct {
+	OMPLoopDirective
+}
+
+type OMPParallelMasterDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPParallelMasterTaskLoopDirective struct {
+	OMPLoopDirective
+}
+
+type OMPParallelMasterTaskLoopSimdDirective struct {
+	OMPLoopDirective
+}
+
+type OMPParallelSectionsDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPScanDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPSectionDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPSectionsDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPSimdDirective struct {
+	OMPLoopDirective
+}
+
+type OMPSingleDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPTargetDataDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPTargetDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPTargetEnterDataDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPTargetExitDataDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPTargetParallelDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPTargetParallelForDirective struct {
+	OMPLoopDirective
+}
+
+type OMPTargetParallelForSimdDirective struct {
+	OMPLoopDirective
+}
+
+type OMPTargetSimdDirective struct {
+	OMPLoopDirective
+}
+
+type OMPTargetTeamsDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPTargetTeamsDistributeDirective struct {
+	OMPLoopDirective
+}
+
+type OMPTargetTeamsDistributeParallelForDirective struct {
+	OMPLoopDirective
+}
+
+type OMPTargetTeamsDistributeParallelForSimdDirective struct {
+	OMPLoopDirective
+}
+
+type OMPTargetTeamsDistributeSimdDirective struct {
+	OMPLoopDirective
+}
+
+type OMPTargetUpdateDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPTaskDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPTaskLoopDirective struct {
+	OMPLoopDirective
+}
+
+type OMPTaskLoopSimdDirective struct {
+	OMPLoopDirective
+}
+
+type OMPTaskgroupDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPTaskwaitDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPTaskyieldDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPTeamsDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPTeamsDistributeDirective struct {
+	OMPLoopDirective
+}
+
+type OMPTeamsDistributeParallelForDirective struct {
+	OMPLoopDirective
+}
+
+type OMPTeamsDistributeParallelForSimdDirective struct {
+	OMPLoopDirective
+}
+
+type OMPTeamsDistributeSimdDirective struct {
+	OMPLoopDirective
+}
+
+type OMPTileDirective struct {
+	OMPExecutableDirective
+}
+
+type OMPUnrollDirective struct {
+	OMPExecutableDirective
+}
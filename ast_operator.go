@@ -27,6 +27,10 @@ func (o *Operator) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return o.BaseNode.Unmarshal(v, ctx)
 }
 
+func (o *Operator) valueType() Type {
+	return o.Type
+}
+
 type BinaryOperator struct {
 	Operator
 	Opcode string `json:"opcode"`
@@ -37,6 +41,14 @@ func (o *BinaryOperator) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return o.Operator.Unmarshal(v, ctx)
 }
 
+func (o *BinaryOperator) LHS() Node {
+	return childAt(o.Inner, 0)
+}
+
+func (o *BinaryOperator) RHS() Node {
+	return childAt(o.Inner, 1)
+}
+
 type UnaryOperator struct {
 	Operator
 	IsPostfix bool   `json:"isPostfix"`
@@ -49,10 +61,26 @@ func (o *UnaryOperator) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return o.Operator.Unmarshal(v, ctx)
 }
 
+func (o *UnaryOperator) SubExpr() Node {
+	return childAt(o.Inner, 0)
+}
+
 type ConditionalOperator struct {
 	Operator
 }
 
+func (o *ConditionalOperator) Cond() Node {
+	return childAt(o.Inner, 0)
+}
+
+func (o *ConditionalOperator) TrueExpr() Node {
+	return childAt(o.Inner, 1)
+}
+
+func (o *ConditionalOperator) FalseExpr() Node {
+	return childAt(o.Inner, 2)
+}
+
 type CompoundAssignOperator struct {
 	Operator
 	Opcode            string `json:"opcode"`
@@ -67,6 +95,14 @@ func (o *CompoundAssignOperator) Unmarshal(v *fastjson.Value, ctx *ParseContext)
 	return o.Operator.Unmarshal(v, ctx)
 }
 
+func (o *CompoundAssignOperator) LHS() Node {
+	return childAt(o.Inner, 0)
+}
+
+func (o *CompoundAssignOperator) RHS() Node {
+	return childAt(o.Inner, 1)
+}
+
 type BinaryConditionalOperator struct {
 	Operator
 }
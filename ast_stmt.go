@@ -20,6 +20,7 @@ var StmtMap = map[string]func() Node{
 	"LabelStmt":      func() Node { return &LabelStmt{} },
 	"ContinueStmt":   func() Node { return &ContinueStmt{} },
 	"WhileStmt":      func() Node { return &WhileStmt{} },
+	"CapturedStmt":   func() Node { return &CapturedStmt{} },
 }
 
 type CompoundStmt struct {
@@ -34,20 +35,127 @@ type ReturnStmt struct {
 	BaseNode
 }
 
+// Value returns the returned expression, or nil for a bare return.
+func (s *ReturnStmt) Value() Node {
+	return childAt(s.Inner, 0)
+}
+
 type DeclStmt struct {
 	BaseNode
 }
 
 type IfStmt struct {
 	BaseNode
+	HasInit     bool `json:"hasInit"`
+	HasVar      bool `json:"hasVar"`
+	HasElse     bool `json:"hasElse"`
+	IsConstexpr bool `json:"isConstexpr"`
+}
+
+func (s *IfStmt) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	s.HasInit = v.GetBool("hasInit")
+	s.HasVar = v.GetBool("hasVar")
+	s.HasElse = v.GetBool("hasElse")
+	s.IsConstexpr = v.GetBool("isConstexpr")
+	return s.BaseNode.Unmarshal(v, ctx)
+}
+
+// Init returns the init statement, as in "if (int i = f(); i)", or nil if there is none.
+func (s *IfStmt) Init() Node {
+	if !s.HasInit {
+		return nil
+	}
+	return childAt(s.Inner, 0)
+}
+
+// ConditionVariable returns the DeclStmt declaring the condition variable, as in "if (int i = f())", or nil if there
+// is none.
+func (s *IfStmt) ConditionVariable() Node {
+	if !s.HasVar {
+		return nil
+	}
+	return childAt(s.Inner, boolInt(s.HasInit))
+}
+
+func (s *IfStmt) Cond() Node {
+	return childAt(s.Inner, boolInt(s.HasInit)+boolInt(s.HasVar))
+}
+
+func (s *IfStmt) Then() Node {
+	return childAt(s.Inner, boolInt(s.HasInit)+boolInt(s.HasVar)+1)
+}
+
+// Else returns the else branch, or nil if there is none.
+func (s *IfStmt) Else() Node {
+	if !s.HasElse {
+		return nil
+	}
+	return childAt(s.Inner, boolInt(s.HasInit)+boolInt(s.HasVar)+2)
 }
 
 type SwitchStmt struct {
 	BaseNode
+	HasInit bool `json:"hasInit"`
+	HasVar  bool `json:"hasVar"`
+}
+
+func (s *SwitchStmt) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	s.HasInit = v.GetBool("hasInit")
+	s.HasVar = v.GetBool("hasVar")
+	return s.BaseNode.Unmarshal(v, ctx)
+}
+
+// Init returns the init statement, or nil if there is none.
+func (s *SwitchStmt) Init() Node {
+	if !s.HasInit {
+		return nil
+	}
+	return childAt(s.Inner, 0)
+}
+
+// ConditionVariable returns the DeclStmt declaring the condition variable, or nil if there is none.
+func (s *SwitchStmt) ConditionVariable() Node {
+	if !s.HasVar {
+		return nil
+	}
+	return childAt(s.Inner, boolInt(s.HasInit))
+}
+
+func (s *SwitchStmt) Cond() Node {
+	return childAt(s.Inner, boolInt(s.HasInit)+boolInt(s.HasVar))
+}
+
+func (s *SwitchStmt) Body() Node {
+	return childAt(s.Inner, boolInt(s.HasInit)+boolInt(s.HasVar)+1)
 }
 
 type CaseStmt struct {
 	BaseNode
+	// IsGNURange is set for GNU case ranges, as in "case 1 ... 3:".
+	IsGNURange bool `json:"isGNURange"`
+}
+
+func (s *CaseStmt) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	s.IsGNURange = v.GetBool("isGNURange")
+	return s.BaseNode.Unmarshal(v, ctx)
+}
+
+// LHS returns the case value, or the start of the range for GNU case ranges.
+func (s *CaseStmt) LHS() Node {
+	return childAt(s.Inner, 0)
+}
+
+// RHS returns the end of the range for GNU case ranges, or nil otherwise.
+func (s *CaseStmt) RHS() Node {
+	if !s.IsGNURange {
+		return nil
+	}
+	return childAt(s.Inner, 1)
+}
+
+// SubStmt returns the statement following the case label.
+func (s *CaseStmt) SubStmt() Node {
+	return childAt(s.Inner, 1+boolInt(s.IsGNURange))
 }
 
 type AttributedStmt struct {
@@ -72,6 +180,18 @@ func (s *GotoStmt) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return s.BaseNode.Unmarshal(v, ctx)
 }
 
+// Target returns the declaration of the label jumped to. Clang doesn't emit the LabelDecls of labels in function
+// bodies into the tree, so in practice nil is returned, TargetStmt returns the labeled statement instead.
+func (s *GotoStmt) Target() *LabelDecl {
+	l, _ := lookupFrom(&s.BaseNode, s.TargetLabelDeclId).(*LabelDecl)
+	return l
+}
+
+// TargetStmt returns the labeled statement jumped to.
+func (s *GotoStmt) TargetStmt() *LabelStmt {
+	return labelFrom(&s.BaseNode, s.TargetLabelDeclId)
+}
+
 type DefaultStmt struct {
 	BaseNode
 }
@@ -80,8 +200,72 @@ type DoStmt struct {
 	BaseNode
 }
 
+func (s *DoStmt) Body() Node {
+	return childAt(s.Inner, 0)
+}
+
+func (s *DoStmt) Cond() Node {
+	return childAt(s.Inner, 1)
+}
+
+// Slots of the children of a ForStmt, clang emits an empty object for each slot without a child.
+const (
+	forInit = iota
+	forCondVar
+	forCond
+	forInc
+	forBody
+	forSlots
+)
+
 type ForStmt struct {
 	BaseNode
+
+	// slots holds the children by slot, since the placeholders of absent children are not kept in Inner.
+	slots [forSlots]Node
+}
+
+func (s *ForStmt) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	if err := s.BaseNode.Unmarshal(v, ctx); err != nil {
+		return err
+	}
+
+	i := 0
+	for slot, child := range v.GetArray("inner") {
+		if len(child.GetStringBytes("kind")) == 0 {
+			continue
+		}
+		if slot < forSlots && i < len(s.Inner) {
+			s.slots[slot] = s.Inner[i]
+		}
+		i++
+	}
+
+	return nil
+}
+
+// Init returns the init statement, or nil if there is none.
+func (s *ForStmt) Init() Node {
+	return s.slots[forInit]
+}
+
+// ConditionVariable returns the DeclStmt declaring the condition variable, or nil if there is none.
+func (s *ForStmt) ConditionVariable() Node {
+	return s.slots[forCondVar]
+}
+
+// Cond returns the condition, or nil if there is none.
+func (s *ForStmt) Cond() Node {
+	return s.slots[forCond]
+}
+
+// Inc returns the increment expression, or nil if there is none.
+func (s *ForStmt) Inc() Node {
+	return s.slots[forInc]
+}
+
+func (s *ForStmt) Body() Node {
+	return s.slots[forBody]
 }
 
 type LabelStmt struct {
@@ -96,10 +280,78 @@ func (s *LabelStmt) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return s.BaseNode.Unmarshal(v, ctx)
 }
 
+// Decl returns the declaration of the label. Clang doesn't always emit the LabelDecl into the tree, in which case nil
+// is returned.
+func (s *LabelStmt) Decl() *LabelDecl {
+	l, _ := lookupFrom(&s.BaseNode, s.DeclId).(*LabelDecl)
+	return l
+}
+
 type ContinueStmt struct {
 	BaseNode
 }
 
 type WhileStmt struct {
 	BaseNode
+	HasVar bool `json:"hasVar"`
+}
+
+func (s *WhileStmt) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	s.HasVar = v.GetBool("hasVar")
+	return s.BaseNode.Unmarshal(v, ctx)
+}
+
+// ConditionVariable returns the DeclStmt declaring the condition variable, or nil if there is none.
+func (s *WhileStmt) ConditionVariable() Node {
+	if !s.HasVar {
+		return nil
+	}
+	return childAt(s.Inner, 0)
+}
+
+func (s *WhileStmt) Cond() Node {
+	return childAt(s.Inner, boolInt(s.HasVar))
+}
+
+func (s *WhileStmt) Body() Node {
+	return childAt(s.Inner, boolInt(s.HasVar)+1)
+}
+
+// childAt returns the i'th child, or nil if there are not enough children.
+func childAt(children []Node, i int) Node {
+	if i < 0 || i >= len(children) {
+		return nil
+	}
+	return children[i]
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// CapturedStmt wraps a statement which is outlined into a separate function, such as the body of an OpenMP directive.
+type CapturedStmt struct {
+	BaseNode
+}
+
+// Decl returns the declaration of the outlined function.
+func (s *CapturedStmt) Decl() *CapturedDecl {
+	for _, c := range s.Inner {
+		if d, ok := c.(*CapturedDecl); ok {
+			return d
+		}
+	}
+	return nil
+}
+
+// Body returns the statement captured.
+func (s *CapturedStmt) Body() Node {
+	d := s.Decl()
+	if d == nil {
+		return nil
+	}
+	return d.Body()
 }
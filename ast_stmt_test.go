@@ -0,0 +1,145 @@
+package goclangast
+
+import (
+	"testing"
+)
+
+// testdata/stmts/stmts.json is the output of clang -Xclang -ast-dump=json -fsyntax-only for stmts.c.
+
+// stmtsFunc returns the named function of testdata/stmts/stmts.json.
+func stmtsFunc(t *testing.T, name string) *FunctionDecl {
+	t.Helper()
+
+	tu := parseTestdata(t, "testdata/stmts/stmts.json")
+	for _, n := range nodesOfKind(tu, "FunctionDecl") {
+		if f := n.(*FunctionDecl); f.Name == name {
+			return f
+		}
+	}
+	t.Fatalf("function %s not found", name)
+	return nil
+}
+
+// stmtsOf returns the statements of kind in the body of the named function of testdata/stmts/stmts.json.
+func stmtsOf(t *testing.T, function, kind string) []Node {
+	t.Helper()
+	return nodesOfKind(stmtsFunc(t, function), kind)
+}
+
+func TestForStmtSlots(t *testing.T) {
+	// for (;;) break; has placeholders for all slots but the body, which must not shift the body into another slot.
+	loops := stmtsOf(t, "apply", "ForStmt")
+	if len(loops) != 1 {
+		t.Fatalf("apply has %d for loops, want 1", len(loops))
+	}
+	loop := loops[0].(*ForStmt)
+	if loop.Init() != nil || loop.ConditionVariable() != nil || loop.Cond() != nil || loop.Inc() != nil {
+		t.Errorf("loop has init %v, condition variable %v, condition %v and increment %v, want none",
+			loop.Init(), loop.ConditionVariable(), loop.Cond(), loop.Inc())
+	}
+	if _, ok := loop.Body().(*BreakStmt); !ok {
+		t.Errorf("loop body is a %T, want a *BreakStmt", loop.Body())
+	}
+
+	// The loop of testdata/openmp/parallel.json only lacks a condition variable.
+	tu := parseTestdata(t, "testdata/openmp/parallel.json")
+	loop = nodesOfKind(tu, "ForStmt")[0].(*ForStmt)
+	if _, ok := loop.Init().(*DeclStmt); !ok {
+		t.Errorf("init is a %T, want a *DeclStmt", loop.Init())
+	}
+	if loop.ConditionVariable() != nil {
+		t.Errorf("condition variable is %v, want none", loop.ConditionVariable())
+	}
+	if op, ok := loop.Cond().(*BinaryOperator); !ok || op.Opcode != "<" {
+		t.Errorf("condition is %+v, want i < n", loop.Cond())
+	}
+	if op, ok := loop.Inc().(*UnaryOperator); !ok || op.Opcode != "++" {
+		t.Errorf("increment is %+v, want i++", loop.Inc())
+	}
+	if _, ok := loop.Body().(*ForStmt); !ok {
+		t.Errorf("body is a %T, want a *ForStmt", loop.Body())
+	}
+}
+
+func TestSwitchStmt(t *testing.T) {
+	switches := stmtsOf(t, "apply", "SwitchStmt")
+	if len(switches) != 1 {
+		t.Fatalf("apply has %d switch statements, want 1", len(switches))
+	}
+	s := switches[0].(*SwitchStmt)
+	if s.Init() != nil || s.ConditionVariable() != nil {
+		t.Errorf("switch has init %v and condition variable %v, want none", s.Init(), s.ConditionVariable())
+	}
+	if _, ok := s.Cond().(*ImplicitCastExpr); !ok {
+		t.Errorf("condition is a %T, want an *ImplicitCastExpr", s.Cond())
+	}
+	body, ok := s.Body().(*CompoundStmt)
+	if !ok {
+		t.Fatalf("body is a %T, want a *CompoundStmt", s.Body())
+	}
+
+	// The body holds case 0 and its statement, the break, case 1 holding case 2, and the default.
+	if len(body.Inner) != 4 {
+		t.Fatalf("body has %d statements, want 4", len(body.Inner))
+	}
+	case0, ok := body.Inner[0].(*CaseStmt)
+	if !ok {
+		t.Fatalf("first statement is a %T, want a *CaseStmt", body.Inner[0])
+	}
+	if v, ok := EvalInt(case0.LHS()); !ok || v.Int64() != 0 || case0.RHS() != nil {
+		t.Errorf("first case has value %v, %v and range end %v, want 0", v, ok, case0.RHS())
+	}
+	if op, ok := case0.SubStmt().(*BinaryOperator); !ok || op.Opcode != "=" {
+		t.Errorf("statement of the first case is %+v, want an assignment", case0.SubStmt())
+	}
+	if _, ok := body.Inner[1].(*BreakStmt); !ok {
+		t.Errorf("second statement is a %T, want a *BreakStmt", body.Inner[1])
+	}
+
+	case1, ok := body.Inner[2].(*CaseStmt)
+	if !ok {
+		t.Fatalf("third statement is a %T, want a *CaseStmt", body.Inner[2])
+	}
+	case2, ok := case1.SubStmt().(*CaseStmt)
+	if !ok {
+		t.Fatalf("statement of case 1 is a %T, want a *CaseStmt", case1.SubStmt())
+	}
+	if v, ok := EvalInt(case2.LHS()); !ok || v.Int64() != 2 {
+		t.Errorf("nested case has value %v, %v, want 2", v, ok)
+	}
+	if _, ok := case2.SubStmt().(*GotoStmt); !ok {
+		t.Errorf("statement of case 2 is a %T, want a *GotoStmt", case2.SubStmt())
+	}
+
+	def, ok := body.Inner[3].(*DefaultStmt)
+	if !ok {
+		t.Fatalf("last statement is a %T, want a *DefaultStmt", body.Inner[3])
+	}
+	if op, ok := childAt(def.Inner, 0).(*UnaryOperator); !ok || op.Opcode != "++" {
+		t.Errorf("statement of the default is %+v, want counter++", childAt(def.Inner, 0))
+	}
+}
+
+func TestGotoStmt(t *testing.T) {
+	gotos := stmtsOf(t, "apply", "GotoStmt")
+	if len(gotos) != 1 {
+		t.Fatalf("apply has %d goto statements, want 1", len(gotos))
+	}
+	g := gotos[0].(*GotoStmt)
+
+	label := g.TargetStmt()
+	if label == nil || label.Name != "out" || label.DeclId != g.TargetLabelDeclId {
+		t.Fatalf("goto jumps to %+v, want label out", label)
+	}
+	if _, ok := childAt(label.Inner, 0).(*ReturnStmt); !ok {
+		t.Errorf("labeled statement is a %T, want a *ReturnStmt", childAt(label.Inner, 0))
+	}
+
+	// Clang doesn't emit the LabelDecl into the tree.
+	if d := g.Target(); d != nil {
+		t.Errorf("target declaration is %+v, want nil", d)
+	}
+	if d := label.Decl(); d != nil {
+		t.Errorf("declaration of the label is %+v, want nil", d)
+	}
+}
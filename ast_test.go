@@ -0,0 +1,179 @@
+package goclangast
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"unsafe"
+)
+
+func TestParseContextZeroValue(t *testing.T) {
+	b, err := os.ReadFile("testdata/roundtrip/lines.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pctx ParseContext
+	tu, err := pctx.ParseTU(bytes.NewBuffer(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodesOfKind(tu, "FieldDecl")) != 2 {
+		t.Errorf("parsed tree has fields %v, want x and y", nodesOfKind(tu, "FieldDecl"))
+	}
+}
+
+func TestParseContextInternConcurrent(t *testing.T) {
+	pctx := NewParseContext()
+	words := []string{"FunctionDecl", "VarDecl", "int", "", "struct point"}
+
+	const workers = 8
+	got := make([][]string, workers)
+	var wg sync.WaitGroup
+	for i := range got {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				for _, w := range words {
+					got[i] = append(got[i], pctx.InternBytes([]byte(w)))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, w := range words {
+		want := got[0][i]
+		if want != w {
+			t.Fatalf("interned %q as %q", w, want)
+		}
+		for _, strs := range got {
+			for j := i; j < len(strs); j += len(words) {
+				if strs[j] != w || unsafe.StringData(strs[j]) != unsafe.StringData(want) {
+					t.Fatalf("%q is not deduplicated across goroutines", w)
+				}
+			}
+		}
+	}
+}
+
+func TestParseTUReader(t *testing.T) {
+	for _, path := range snapshotTestdata(t) {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			want := parseTestdata(t, path)
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			got, err := ParseTUReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(writeTestSnapshot(t, got), writeTestSnapshot(t, want)) {
+				t.Error("tree parsed from a reader differs from the tree parsed from a buffer")
+			}
+			if !reflect.DeepEqual(got.UnknownKinds, want.UnknownKinds) {
+				t.Errorf("unknown kinds are %v, want %v", got.UnknownKinds, want.UnknownKinds)
+			}
+
+			// The tree is linked and indexed like the one parsed from a buffer.
+			for _, n := range flatten(got)[1:] {
+				if n.Parent() == nil {
+					t.Fatalf("%s %s has no parent", n.GetBaseNode().Kind, n.GetBaseNode().ID)
+				}
+				if id := n.GetBaseNode().ID; id != "" && got.Lookup(id) != n {
+					t.Fatalf("looking up %s gives %v", id, got.Lookup(id))
+				}
+			}
+		})
+	}
+}
+
+func TestParseTUReaderErrors(t *testing.T) {
+	b, err := os.ReadFile("testdata/roundtrip/loops.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errRead := errors.New("read failed")
+	for _, tt := range []struct {
+		name string
+		r    io.Reader
+	}{
+		{"empty", strings.NewReader("")},
+		{"not an object", strings.NewReader(`[]`)},
+		{"truncated", bytes.NewReader(b[:len(b)/2])},
+		{"truncated top-level node", bytes.NewReader(b[:bytes.Index(b, []byte(`"inner"`))])},
+		{"invalid child", strings.NewReader(`{"id":"0x1","kind":"TranslationUnitDecl","inner":[{"kind":}]}`)},
+		{"no kind", strings.NewReader(`{"id":"0x1","inner":[]}`)},
+		{"read error", io.MultiReader(bytes.NewReader(b[:len(b)/2]), iotest.ErrReader(errRead))},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tu, err := ParseTUReader(tt.r)
+			if err == nil {
+				t.Fatalf("got TU %v, want an error", tu)
+			}
+		})
+	}
+
+	_, err = ParseTUReader(io.MultiReader(bytes.NewReader(b[:len(b)/2]), iotest.ErrReader(errRead)))
+	if !errors.Is(err, errRead) {
+		t.Errorf("error %v doesn't wrap the read error", err)
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	b, err := os.ReadFile("testdata/roundtrip/loops.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown kinds become UnknownNode's and are counted by default.
+	tu, err := NewParseContext().ParseTU(bytes.NewBuffer(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"BlockExpr": 1, "BlockDecl": 1}; !reflect.DeepEqual(tu.UnknownKinds, want) {
+		t.Errorf("unknown kinds are %v, want %v", tu.UnknownKinds, want)
+	}
+	for _, n := range nodesOfKind(tu, "BlockExpr") {
+		if _, ok := n.(*UnknownNode); !ok {
+			t.Errorf("BlockExpr is a %T, want *UnknownNode", n)
+		}
+	}
+
+	// Dumps without unknown kinds don't report any.
+	if tu := parseTestdata(t, "testdata/stmts/stmts.json"); len(tu.UnknownKinds) != 0 {
+		t.Errorf("unknown kinds are %v, want none", tu.UnknownKinds)
+	}
+
+	// In strict mode the first unknown kind fails the parse, from a buffer as well as from a reader.
+	strict := &ParseContext{Strict: true}
+	if tu, err := strict.ParseTU(bytes.NewBuffer(b)); err == nil || !strings.Contains(err.Error(), "BlockExpr") {
+		t.Errorf("strict parse gives TU %v and error %v, want an error about BlockExpr", tu, err)
+	}
+	if tu, err := strict.ParseTUReader(bytes.NewReader(b)); err == nil || !strings.Contains(err.Error(), "BlockExpr") {
+		t.Errorf("strict parse from a reader gives TU %v and error %v, want an error about BlockExpr", tu, err)
+	}
+
+	// Strict mode accepts dumps of known kinds only.
+	b, err = os.ReadFile("testdata/stmts/stmts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := strict.ParseTU(bytes.NewBuffer(b)); err != nil {
+		t.Errorf("strict parse of a dump without unknown kinds fails: %v", err)
+	}
+}
@@ -1,6 +1,10 @@
 package goclangast
 
-import "github.com/valyala/fastjson"
+import (
+	"strings"
+
+	"github.com/valyala/fastjson"
+)
 
 var TypeMap = map[string]func() Node{
 	"BuiltinType":       func() Node { return &BuiltinType{} },
@@ -13,6 +17,20 @@ var TypeMap = map[string]func() Node{
 	"FunctionProtoType": func() Node { return &FunctionProtoType{} },
 	"QualType":          func() Node { return &QualType{} },
 	"EnumType":          func() Node { return &EnumType{} },
+
+	"IncompleteArrayType":  func() Node { return &IncompleteArrayType{} },
+	"VariableArrayType":    func() Node { return &VariableArrayType{} },
+	"DecayedType":          func() Node { return &DecayedType{} },
+	"AttributedType":       func() Node { return &AttributedType{} },
+	"FunctionNoProtoType":  func() Node { return &FunctionNoProtoType{} },
+	"AtomicType":           func() Node { return &AtomicType{} },
+	"VectorType":           func() Node { return &VectorType{} },
+	"ExtVectorType":        func() Node { return &ExtVectorType{} },
+	"ComplexType":          func() Node { return &ComplexType{} },
+	"TypeOfExprType":       func() Node { return &TypeOfExprType{} },
+	"MacroQualifiedType":   func() Node { return &MacroQualifiedType{} },
+	"BTFTagAttributedType": func() Node { return &BTFTagAttributedType{} },
+	"BitIntType":           func() Node { return &BitIntType{} },
 }
 
 type Type struct {
@@ -60,18 +78,70 @@ func (t *RecordType) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return t.BaseType.Unmarshal(v, ctx)
 }
 
+// RecordDecl returns the declaration of the record, for C++ classes this is the RecordDecl embedded in the
+// CXXRecordDecl.
+func (t *RecordType) RecordDecl() *RecordDecl {
+	return AsRecordDecl(lookupFrom(&t.BaseNode, t.Decl.ID))
+}
+
 type PointerType struct {
 	BaseType
 }
 
-type ConstantArrayType struct {
+// ArrayType holds the fields common to all array types, the first inner node is the element type.
+type ArrayType struct {
 	BaseType
+	// SizeModifier is "static" for parameters declared as "a[static 4]" and "*" for "a[*]".
+	SizeModifier string `json:"sizeModifier"`
+	// IndexTypeQualifiers are the qualifiers of array parameters declared as "a[const 4]".
+	IndexTypeQualifiers string `json:"indexTypeQualifiers"`
+}
+
+func (t *ArrayType) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	t.SizeModifier = string(v.GetStringBytes("sizeModifier"))
+	t.IndexTypeQualifiers = string(v.GetStringBytes("indexTypeQualifiers"))
+	return t.BaseType.Unmarshal(v, ctx)
+}
+
+func (t *ArrayType) ElementType() Node {
+	return childAt(t.Inner, 0)
+}
+
+// asArrayType returns the ArrayType of one of the array type nodes, or nil for other nodes.
+func asArrayType(n Node) *ArrayType {
+	if t, ok := n.(interface{ arrayType() *ArrayType }); ok {
+		return t.arrayType()
+	}
+	return nil
+}
+
+func (t *ArrayType) arrayType() *ArrayType {
+	return t
+}
+
+type ConstantArrayType struct {
+	ArrayType
 	Size int `json:"size"`
 }
 
 func (t *ConstantArrayType) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	t.Size = v.GetInt("size")
-	return t.BaseType.Unmarshal(v, ctx)
+	return t.ArrayType.Unmarshal(v, ctx)
+}
+
+// IncompleteArrayType is an array without size, such as "int[]".
+type IncompleteArrayType struct {
+	ArrayType
+}
+
+// VariableArrayType is an array with a size only known at runtime, such as "int[n]".
+type VariableArrayType struct {
+	ArrayType
+}
+
+// SizeExpr returns the expression of the size, or nil for "[*]".
+func (t *VariableArrayType) SizeExpr() Node {
+	return childAt(t.Inner, 1)
 }
 
 type TypedefType struct {
@@ -87,6 +157,11 @@ func (t *TypedefType) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	return t.BaseType.Unmarshal(v, ctx)
 }
 
+func (t *TypedefType) TypedefDecl() *TypedefDecl {
+	d, _ := lookupFrom(&t.BaseNode, t.Decl.ID).(*TypedefDecl)
+	return d
+}
+
 type ElaboratedType struct {
 	BaseType
 	OwnedTagDecl Decl `json:"ownedTagDecl"`
@@ -104,18 +179,51 @@ type ParenType struct {
 	BaseType
 }
 
-type FunctionProtoType struct {
+// FunctionType holds the fields common to function types, the first inner node is the return type.
+type FunctionType struct {
 	BaseType
-	CC string `json:"cc"`
+	CC             string `json:"cc"`
+	NoReturn       bool   `json:"noreturn"`
+	ProducesResult bool   `json:"producesResult"`
+	RegParm        int    `json:"regParm"`
 }
 
-func (t *FunctionProtoType) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+func (t *FunctionType) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	t.CC = string(v.GetStringBytes("cc"))
+	t.NoReturn = v.GetBool("noreturn")
+	t.ProducesResult = v.GetBool("producesResult")
+	t.RegParm = v.GetInt("regParm")
 	return t.BaseType.Unmarshal(v, ctx)
 }
 
+func (t *FunctionType) ReturnType() Node {
+	return childAt(t.Inner, 0)
+}
+
+// FunctionProtoType is a function type with a prototype, the return type is followed by the parameter types.
+type FunctionProtoType struct {
+	FunctionType
+	Variadic bool `json:"variadic"`
+}
+
+func (t *FunctionProtoType) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	t.Variadic = v.GetBool("variadic")
+	return t.FunctionType.Unmarshal(v, ctx)
+}
+
+// FunctionNoProtoType is a function type without a prototype, such as "int ()" in C.
+type FunctionNoProtoType struct {
+	FunctionType
+}
+
 type QualType struct {
-	BaseNode
+	BaseType
+	Qualifiers string `json:"qualifiers"`
+}
+
+func (t *QualType) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	t.Qualifiers = string(v.GetStringBytes("qualifiers"))
+	return t.BaseType.Unmarshal(v, ctx)
 }
 
 type EnumType struct {
@@ -130,3 +238,173 @@ func (t *EnumType) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
 	}
 	return t.BaseType.Unmarshal(v, ctx)
 }
+
+func (t *EnumType) EnumDecl() *EnumDecl {
+	d, _ := lookupFrom(&t.BaseNode, t.Decl.ID).(*EnumDecl)
+	return d
+}
+
+// DecayedType is an array or function type which decayed to a pointer, such as the type of parameters declared as
+// arrays. The only inner node is the type as declared, Type holds the pointer type it decayed to.
+type DecayedType struct {
+	BaseType
+}
+
+// OriginalType returns the type as declared.
+func (t *DecayedType) OriginalType() Node {
+	return childAt(t.Inner, 0)
+}
+
+// AttributedType is a type with a type attribute, such as "int * _Nonnull". The only inner node is the type without
+// the attribute.
+type AttributedType struct {
+	BaseType
+}
+
+func (t *AttributedType) ModifiedType() Node {
+	return childAt(t.Inner, 0)
+}
+
+// Attribute returns the attribute as spelled in the type, such as "_Nonnull" or "noderef". Clang doesn't include the
+// attribute kind in the AST, so it is recovered from the type, it returns an empty string if that fails.
+func (t *AttributedType) Attribute() string {
+	s := strings.TrimSpace(t.Type.QualType)
+	for _, kw := range []string{"_Nonnull", "_Nullable_result", "_Nullable", "_Null_unspecified"} {
+		if strings.HasSuffix(s, kw) {
+			return kw
+		}
+	}
+	return lastTypeAttribute(s)
+}
+
+// lastTypeAttribute returns the contents of the last __attribute__((...)) in a type.
+func lastTypeAttribute(s string) string {
+	i := strings.LastIndex(s, "__attribute__((")
+	if i < 0 {
+		return ""
+	}
+	s = s[i+len("__attribute__(("):]
+
+	depth := 0
+	for j := 0; j < len(s); j++ {
+		switch s[j] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return s[:j]
+			}
+			depth--
+		}
+	}
+	return ""
+}
+
+// AtomicType is a type specified as "_Atomic(T)".
+type AtomicType struct {
+	BaseType
+}
+
+func (t *AtomicType) ValueType() Node {
+	return childAt(t.Inner, 0)
+}
+
+// Vector kinds of a VectorType, the kind of generic vectors declared with vector_size is empty.
+const (
+	VectorKindAltiVec                 = "altivec"
+	VectorKindAltiVecPixel            = "altivec pixel"
+	VectorKindAltiVecBool             = "altivec bool"
+	VectorKindNeon                    = "neon"
+	VectorKindNeonPoly                = "neon poly"
+	VectorKindSveFixedLengthData      = "fixed-length sve data vector"
+	VectorKindSveFixedLengthPredicate = "fixed-length sve predicate vector"
+)
+
+// VectorType is a vector declared with __attribute__((vector_size(N))) or one of the target specific vector types.
+type VectorType struct {
+	BaseType
+	NumElements int    `json:"numElements"`
+	VectorKind  string `json:"vectorKind"`
+}
+
+func (t *VectorType) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	t.NumElements = v.GetInt("numElements")
+	t.VectorKind = ctx.InternBytes(v.GetStringBytes("vectorKind"))
+	return t.BaseType.Unmarshal(v, ctx)
+}
+
+func (t *VectorType) ElementType() Node {
+	return childAt(t.Inner, 0)
+}
+
+// ExtVectorType is a vector declared with __attribute__((ext_vector_type(N))), as used by OpenCL.
+type ExtVectorType struct {
+	VectorType
+}
+
+// ComplexType is a type such as "_Complex double".
+type ComplexType struct {
+	BaseType
+}
+
+func (t *ComplexType) ElementType() Node {
+	return childAt(t.Inner, 0)
+}
+
+// TypeOfExprType is a type specified as "typeof(expr)", the only inner node is the expression.
+type TypeOfExprType struct {
+	BaseType
+}
+
+func (t *TypeOfExprType) Expr() Node {
+	return childAt(t.Inner, 0)
+}
+
+// MacroQualifiedType is a type with an attribute spelled through a macro, such as "int * __user".
+type MacroQualifiedType struct {
+	BaseType
+	MacroName string `json:"macroName"`
+}
+
+func (t *MacroQualifiedType) Unmarshal(v *fastjson.Value, ctx *ParseContext) error {
+	t.MacroName = string(v.GetStringBytes("macroName"))
+	return t.BaseType.Unmarshal(v, ctx)
+}
+
+func (t *MacroQualifiedType) UnderlyingType() Node {
+	return childAt(t.Inner, 0)
+}
+
+// BTFTagAttributedType is a type with a btf_type_tag attribute.
+type BTFTagAttributedType struct {
+	BaseType
+}
+
+func (t *BTFTagAttributedType) WrappedType() Node {
+	return childAt(t.Inner, 0)
+}
+
+// Tag returns the tag of the attribute, such as "user" for __attribute__((btf_type_tag("user"))). Clang doesn't include
+// the tag in the AST, so it is recovered from the type.
+func (t *BTFTagAttributedType) Tag() string {
+	attr := lastTypeAttribute(t.Type.QualType)
+	attr = strings.TrimPrefix(attr, "btf_type_tag(")
+	attr = strings.TrimSuffix(attr, ")")
+	return strings.Trim(attr, `"`)
+}
+
+// BitIntType is a type such as "_BitInt(7)" or "unsigned _BitInt(128)".
+type BitIntType struct {
+	BaseType
+}
+
+// Width returns the number of bits of the type, or 0 if it can't be determined from the type.
+func (t *BitIntType) Width() int {
+	w, _ := intTypeWidth(t.Type)
+	return w.bits
+}
+
+func (t *BitIntType) IsUnsigned() bool {
+	w, ok := intTypeWidth(t.Type)
+	return ok && !w.signed
+}
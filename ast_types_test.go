@@ -0,0 +1,186 @@
+package goclangast
+
+import (
+	"testing"
+)
+
+// testdata/types/types.json is the output of clang-15 --target=aarch64-linux-gnu -Xclang -ast-dump=json -fsyntax-only
+// for types.c, trimmed of most implicit declarations.
+
+// typedefType returns the type node of the named typedef in tu.
+func typedefType(t *testing.T, tu *TranslationUnitDecl, name string) Node {
+	t.Helper()
+
+	for _, n := range nodesOfKind(tu, "TypedefDecl") {
+		if d := n.(*TypedefDecl); d.Name == name {
+			if len(d.Inner) != 1 {
+				t.Fatalf("typedef %s has %d inner nodes, want 1", name, len(d.Inner))
+			}
+			return d.Inner[0]
+		}
+	}
+	t.Fatalf("typedef %s not found", name)
+	return nil
+}
+
+func TestTypeNodes(t *testing.T) {
+	tu := parseTestdata(t, "testdata/types/types.json")
+
+	kindOf := func(n Node) string {
+		if n == nil {
+			return "<nil>"
+		}
+		return n.GetBaseNode().Kind
+	}
+
+	if typ, ok := typedefType(t, tu, "incomplete_t").(*IncompleteArrayType); !ok || kindOf(typ.ElementType()) != "BuiltinType" {
+		t.Errorf("incomplete_t is %#v, want an incomplete array of int", typedefType(t, tu, "incomplete_t"))
+	}
+
+	if typ, ok := typedefType(t, tu, "atomic_t").(*AtomicType); !ok || kindOf(typ.ValueType()) != "BuiltinType" {
+		t.Errorf("atomic_t is %#v, want an atomic int", typedefType(t, tu, "atomic_t"))
+	}
+
+	for _, tt := range []struct {
+		name        string
+		numElements int
+		vectorKind  string
+	}{
+		{"v4si", 4, ""},
+		{"int32x4_t", 4, VectorKindNeon},
+	} {
+		typ, ok := typedefType(t, tu, tt.name).(*VectorType)
+		if !ok || typ.NumElements != tt.numElements || typ.VectorKind != tt.vectorKind ||
+			kindOf(typ.ElementType()) != "BuiltinType" {
+			t.Errorf("%s is %#v, want a vector of %d elements of kind %q", tt.name, typedefType(t, tu, tt.name),
+				tt.numElements, tt.vectorKind)
+		}
+	}
+
+	if typ, ok := typedefType(t, tu, "float2").(*ExtVectorType); !ok || typ.NumElements != 2 {
+		t.Errorf("float2 is %#v, want an ext vector of 2 elements", typedefType(t, tu, "float2"))
+	}
+
+	if typ, ok := typedefType(t, tu, "complex_t").(*ComplexType); !ok || kindOf(typ.ElementType()) != "BuiltinType" {
+		t.Errorf("complex_t is %#v, want a complex double", typedefType(t, tu, "complex_t"))
+	}
+
+	for _, tt := range []struct {
+		name     string
+		width    int
+		unsigned bool
+	}{
+		{"u7", 7, true},
+		{"i128", 128, false},
+	} {
+		typ, ok := typedefType(t, tu, tt.name).(*BitIntType)
+		if !ok || typ.Width() != tt.width || typ.IsUnsigned() != tt.unsigned {
+			t.Errorf("%s is %#v, want a _BitInt(%d), unsigned %v", tt.name, typedefType(t, tu, tt.name), tt.width,
+				tt.unsigned)
+		}
+	}
+
+	if typ, ok := typedefType(t, tu, "knr_t").(*FunctionNoProtoType); !ok || typ.CC != "cdecl" ||
+		kindOf(typ.ReturnType()) != "BuiltinType" {
+		t.Errorf("knr_t is %#v, want a function without prototype", typedefType(t, tu, "knr_t"))
+	}
+
+	ptr, ok := typedefType(t, tu, "user_ptr").(*PointerType)
+	if !ok {
+		t.Fatalf("user_ptr is %#v, want a pointer", typedefType(t, tu, "user_ptr"))
+	}
+	macro, ok := childAt(ptr.Inner, 0).(*MacroQualifiedType)
+	if !ok || macro.MacroName != "__user" {
+		t.Fatalf("pointee of user_ptr is %#v, want a type qualified by the macro __user", childAt(ptr.Inner, 0))
+	}
+	if tag, ok := macro.UnderlyingType().(*BTFTagAttributedType); !ok || tag.Tag() != "user" ||
+		kindOf(tag.WrappedType()) != "BuiltinType" {
+		t.Errorf("__user expands to %#v, want the btf_type_tag user", macro.UnderlyingType())
+	}
+
+	if typ, ok := typedefType(t, tu, "nonnull_ptr").(*AttributedType); !ok || typ.Attribute() != "_Nonnull" ||
+		kindOf(typ.ModifiedType()) != "PointerType" {
+		t.Errorf("nonnull_ptr is %#v, want a _Nonnull pointer", typedefType(t, tu, "nonnull_ptr"))
+	}
+
+	if typ, ok := typedefType(t, tu, "fatal_t").(*FunctionProtoType); !ok || !typ.NoReturn || typ.CC != "cdecl" {
+		t.Errorf("fatal_t is %#v, want a noreturn function", typedefType(t, tu, "fatal_t"))
+	}
+
+	params, ok := typedefType(t, tu, "params_t").(*FunctionProtoType)
+	if !ok || len(params.Inner) != 5 {
+		t.Fatalf("params_t is %#v, want a function of 4 parameters", typedefType(t, tu, "params_t"))
+	}
+	for i, tt := range []struct {
+		kind                string
+		size                int
+		sizeModifier        string
+		indexTypeQualifiers string
+	}{
+		{"ConstantArrayType", 4, "static", ""},
+		{"ConstantArrayType", 2, "", "const"},
+		{"VariableArrayType", 0, "*", ""},
+	} {
+		param := params.Inner[[]int{1, 2, 4}[i]]
+		decayed, ok := param.(*DecayedType)
+		if !ok {
+			t.Errorf("parameter %d of params_t is %#v, want a decayed array", i, param)
+			continue
+		}
+
+		orig := decayed.OriginalType()
+		array := asArrayType(orig)
+		if kindOf(orig) != tt.kind || array.SizeModifier != tt.sizeModifier ||
+			array.IndexTypeQualifiers != tt.indexTypeQualifiers {
+			t.Errorf("parameter %d of params_t was declared as %#v, want a %s with size modifier %q and qualifiers %q",
+				i, orig, tt.kind, tt.sizeModifier, tt.indexTypeQualifiers)
+		}
+		if c, ok := orig.(*ConstantArrayType); ok && c.Size != tt.size {
+			t.Errorf("parameter %d of params_t was declared with size %d, want %d", i, c.Size, tt.size)
+		}
+		if v, ok := orig.(*VariableArrayType); ok && v.SizeExpr() != nil {
+			t.Errorf("parameter %d of params_t was declared with size %#v, want [*]", i, v.SizeExpr())
+		}
+	}
+
+	if typ, ok := typedefType(t, tu, "global_t").(*TypeOfExprType); !ok || kindOf(typ.Expr()) != "DeclRefExpr" {
+		t.Errorf("global_t is %#v, want typeof of a reference to global", typedefType(t, tu, "global_t"))
+	}
+
+	vla, ok := typedefType(t, tu, "vla_t").(*VariableArrayType)
+	if !ok {
+		t.Fatalf("vla_t is %#v, want a variable length array", typedefType(t, tu, "vla_t"))
+	}
+	if size, ok := vla.SizeExpr().(*BinaryOperator); !ok || size.Opcode != "+" {
+		t.Errorf("size of vla_t is %#v, want n + 1", vla.SizeExpr())
+	}
+}
+
+func TestCTypeFromTypeNodes(t *testing.T) {
+	tu := parseTestdata(t, "testdata/types/types.json")
+
+	for _, tt := range []struct {
+		name string
+		want string
+	}{
+		{"incomplete_t", "int []"},
+		{"atomic_t", "_Atomic int"},
+		{"complex_t", "_Complex double"},
+		{"u7", "unsigned _BitInt(7)"},
+		{"knr_t", "int ()"},
+		{"user_ptr", "int *"},
+		{"nonnull_ptr", "int *"},
+		{"params_t", "void (int *, int *const, int, int *)"},
+		{"global_t", "int"},
+		{"vla_t", "int []"},
+	} {
+		ct, err := CTypeFromNode(typedefType(t, tu, tt.name))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := ct.String(); got != tt.want {
+			t.Errorf("%s has C type %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,730 @@
+// Package btf generates BPF Type Format (BTF) type information from a parsed translation unit. Struct and union
+// members are only encoded with offsets when the AST was created with goclangast.Options.RecordLayouts.
+package btf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/dylandreimerink/goclangast"
+)
+
+const (
+	btfMagic   = 0xeB9F
+	btfVersion = 1
+	headerLen  = 24
+)
+
+// Kind is the kind of a BTF type.
+type Kind uint8
+
+const (
+	KindUnknown Kind = iota
+	KindInt
+	KindPointer
+	KindArray
+	KindStruct
+	KindUnion
+	KindEnum
+	KindForward
+	KindTypedef
+	KindVolatile
+	KindConst
+	KindRestrict
+	KindFunc
+	KindFuncProto
+	KindVar
+	KindDatasec
+	KindFloat
+	KindDeclTag
+	KindTypeTag
+	KindEnum64
+)
+
+const (
+	intSigned = 1 << 0
+	intChar   = 1 << 1
+	intBool   = 1 << 2
+)
+
+const (
+	linkageStatic = 0
+	linkageGlobal = 1
+	linkageExtern = 2
+)
+
+type Config struct {
+	// Files limits the encoded declarations to those located in the given files, empty means all files. Types
+	// referenced by the encoded declarations are always included.
+	Files []string
+	// ByteOrder of the generated BTF, nil means little endian.
+	ByteOrder binary.ByteOrder
+	// PointerSize is the size of pointers on the target in bytes, zero means 8.
+	PointerSize int
+}
+
+// Generate encodes the records, enums, typedefs and functions in tu, and all types they reference, as BTF. The
+// returned blob contains the BTF header, type section and string section.
+func Generate(tu *goclangast.TranslationUnitDecl, cfg Config) ([]byte, error) {
+	if cfg.ByteOrder == nil {
+		cfg.ByteOrder = binary.LittleEndian
+	}
+	if cfg.PointerSize == 0 {
+		cfg.PointerSize = 8
+	}
+
+	b := builder{
+		cfg:     cfg,
+		strings: map[string]uint32{"": 0},
+		strtab:  []byte{0},
+		ids:     make(map[string]uint32),
+		decls:   make(map[scopedName]goclangast.Node),
+		anon:    make(map[string]goclangast.Node),
+		files:   make(map[string]bool),
+	}
+	for _, f := range cfg.Files {
+		b.files[f] = true
+	}
+
+	b.index(tu)
+
+	var err error
+	goclangast.PreOrderVisit(tu, func(n goclangast.Node, depth int) error {
+		if err != nil || !b.wanted(n) {
+			return err
+		}
+
+		switch n := n.(type) {
+		case *goclangast.RecordDecl:
+			if n.CompleteDefinition && n.Name != "" {
+				_, err = b.record(n)
+			}
+		case *goclangast.EnumDecl:
+			// Anonymous enums are kept for their constants, like clang does.
+			if len(n.Inner) > 0 {
+				_, err = b.enum(n)
+			}
+		case *goclangast.TypedefDecl:
+			if !n.IsImplicit {
+				_, err = b.typedef(n)
+			}
+		case *goclangast.FunctionDecl:
+			err = b.function(n)
+		}
+		if err != nil {
+			err = fmt.Errorf("%s: %w", n.GetBaseNode().Kind, err)
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b.encode(), nil
+}
+
+type btfType struct {
+	name     uint32
+	kind     Kind
+	kindFlag bool
+	vlen     int
+	// sizeOrType is the size of ints, floats, records and enums, or the referenced type of other kinds.
+	sizeOrType uint32
+	// extra holds the data following the type, such as members or parameters, as a sequence of u32 values.
+	extra []uint32
+}
+
+type builder struct {
+	cfg Config
+
+	types   []btfType
+	strings map[string]uint32
+	strtab  []byte
+
+	// ids caches the ID of encoded types. Records, enums and typedefs are keyed by their declaration, other types by
+	// the IDs of the types they are made of.
+	ids map[string]uint32
+
+	// decls holds the complete records and enums, and the typedefs, by the scope they are declared in.
+	decls map[scopedName]goclangast.Node
+	// anon holds the anonymous records and enums by location.
+	anon  map[string]goclangast.Node
+	files map[string]bool
+}
+
+// scopedName is the spelling of a record, enum or typedef, such as "struct foo" or "typedef foo_t", in the scope of
+// a translation unit, function or block.
+type scopedName struct {
+	scope goclangast.Node
+	name  string
+}
+
+// scopeOf returns the translation unit, function or block d is declared in. Records don't form a scope in C, so
+// records declared inside other records belong to the scope of the outer record.
+func scopeOf(d goclangast.Node) goclangast.Node {
+	for p := d.Parent(); p != nil; p = p.Parent() {
+		switch p.(type) {
+		case *goclangast.TranslationUnitDecl, *goclangast.FunctionDecl, *goclangast.CompoundStmt:
+			return p
+		}
+	}
+	return nil
+}
+
+func (b *builder) index(tu *goclangast.TranslationUnitDecl) {
+	declare := func(d goclangast.Node, anonymous bool, key string) {
+		if anonymous {
+			b.anon[key] = d
+			return
+		}
+		b.decls[scopedName{scopeOf(d), key}] = d
+	}
+
+	goclangast.PreOrderVisit(tu, func(n goclangast.Node, depth int) error {
+		switch n := n.(type) {
+		case *goclangast.RecordDecl:
+			if !n.CompleteDefinition {
+				return nil
+			}
+			declare(n, n.Name == "", n.Key())
+
+		case *goclangast.EnumDecl:
+			if len(n.Inner) == 0 {
+				return nil
+			}
+			declare(n, n.Name == "", n.Key())
+
+		case *goclangast.TypedefDecl:
+			if n.IsImplicit {
+				return nil
+			}
+			declare(n, n.Name == "", n.Key())
+
+			// Clang spells anonymous records and enums named by a typedef with the name of the typedef.
+			switch tag := n.TagDecl().(type) {
+			case *goclangast.RecordDecl:
+				if tag.Name == "" {
+					b.decls[scopedName{scopeOf(n), tag.TagUsed + " " + n.Name}] = tag
+				}
+			case *goclangast.EnumDecl:
+				if tag.Name == "" {
+					b.decls[scopedName{scopeOf(n), "enum " + n.Name}] = tag
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// lookup returns the declaration of the record, enum or typedef t used by the node from. Types built from type nodes
+// refer to their declaration. The types of fields and functions are only spelled out by clang, their declaration is
+// searched for in the scopes enclosing from like C name lookup does, which can't tell whether a declaration precedes
+// from. It returns nil if there is no complete declaration.
+func (b *builder) lookup(t *goclangast.CType, from goclangast.Node) goclangast.Node {
+	if complete(t.Decl) {
+		return t.Decl
+	}
+
+	key := t.Key()
+	if t.Anonymous {
+		return b.anon[key]
+	}
+
+	for n := from; n != nil; n = n.Parent() {
+		if d, ok := b.decls[scopedName{n, key}]; ok {
+			return d
+		}
+	}
+	return nil
+}
+
+// complete returns whether d is a complete record or enum declaration, or a typedef.
+func complete(d goclangast.Node) bool {
+	switch d := d.(type) {
+	case *goclangast.RecordDecl:
+		return d.CompleteDefinition
+	case *goclangast.EnumDecl:
+		return len(d.Inner) > 0
+	case *goclangast.TypedefDecl:
+		return true
+	}
+	return false
+}
+
+func declKey(d goclangast.Node) string {
+	return "decl " + d.GetBaseNode().ID
+}
+
+func (b *builder) wanted(n goclangast.Node) bool {
+	if len(b.files) == 0 {
+		return true
+	}
+
+	l := n.GetBaseNode().Loc
+	return l != nil && b.files[l.File]
+}
+
+func (b *builder) str(s string) uint32 {
+	if off, ok := b.strings[s]; ok {
+		return off
+	}
+
+	off := uint32(len(b.strtab))
+	b.strtab = append(b.strtab, s...)
+	b.strtab = append(b.strtab, 0)
+	b.strings[s] = off
+	return off
+}
+
+// add appends a type and returns its ID, type ID 0 is reserved for void.
+func (b *builder) add(t btfType) uint32 {
+	b.types = append(b.types, t)
+	return uint32(len(b.types))
+}
+
+// typeID returns the ID of t, used by the node from, encoding it and the types it refers to if needed.
+func (b *builder) typeID(t *goclangast.CType, from goclangast.Node) (uint32, error) {
+	if t.Qualifiers != 0 {
+		return b.qualified(t, from)
+	}
+
+	switch t.Kind {
+	case goclangast.CTypeBuiltin:
+		return b.builtin(t)
+
+	case goclangast.CTypePointer, goclangast.CTypeReference:
+		elem, err := b.typeID(t.Elem, from)
+		if err != nil {
+			return 0, err
+		}
+		return b.cached(fmt.Sprintf("* %d", elem), btfType{kind: KindPointer, sizeOrType: elem}), nil
+
+	case goclangast.CTypeArray:
+		elem, err := b.typeID(t.Elem, from)
+		if err != nil {
+			return 0, err
+		}
+		index, err := b.arrayIndexType()
+		if err != nil {
+			return 0, err
+		}
+		nelems := t.Size
+		if nelems < 0 {
+			nelems = 0
+		}
+		return b.cached(fmt.Sprintf("[%d] %d", nelems, elem),
+			btfType{kind: KindArray, extra: []uint32{elem, index, uint32(nelems)}}), nil
+
+	case goclangast.CTypeFunction:
+		return b.funcProto(t, nil, from)
+
+	case goclangast.CTypeRecord:
+		d, ok := b.lookup(t, from).(*goclangast.RecordDecl)
+		if !ok {
+			// Incomplete records are forward declared.
+			return b.cached("forward "+t.Key(), btfType{name: b.str(anonName(t)), kind: KindForward, kindFlag: t.Tag == "union"}), nil
+		}
+		return b.record(d)
+
+	case goclangast.CTypeEnum:
+		d, ok := b.lookup(t, from).(*goclangast.EnumDecl)
+		if !ok {
+			return b.cached("forward "+t.Key(), btfType{name: b.str(anonName(t)), kind: KindEnum, sizeOrType: 4}), nil
+		}
+		return b.enum(d)
+
+	case goclangast.CTypeTypedef:
+		d, ok := b.lookup(t, from).(*goclangast.TypedefDecl)
+		if !ok {
+			return 0, fmt.Errorf("unknown typedef %s", t.Name)
+		}
+		return b.typedef(d)
+	}
+
+	return 0, fmt.Errorf("unsupported type %s", t)
+}
+
+// cached returns the ID of the type with the given key, adding t if there is none.
+func (b *builder) cached(key string, t btfType) uint32 {
+	if id, ok := b.ids[key]; ok {
+		return id
+	}
+
+	id := b.add(t)
+	b.ids[key] = id
+	return id
+}
+
+// anonName returns the name of a record or enum type, which is empty for anonymous types.
+func anonName(t *goclangast.CType) string {
+	if t.Anonymous {
+		return ""
+	}
+	return t.Name
+}
+
+func (b *builder) typedef(d *goclangast.TypedefDecl) (uint32, error) {
+	key := declKey(d)
+	if id, ok := b.ids[key]; ok {
+		return id, nil
+	}
+
+	// Reserve the ID first, the target may refer back to the typedef through pointers.
+	id := b.add(btfType{name: b.str(d.Name), kind: KindTypedef})
+	b.ids[key] = id
+
+	ct, err := typedefCType(d)
+	if err != nil {
+		return 0, err
+	}
+	target, err := b.typeID(ct, d)
+	if err != nil {
+		return 0, err
+	}
+	b.types[id-1].sizeOrType = target
+	return id, nil
+}
+
+// typedefCType returns the type named by a typedef, built from its type node so records, enums and typedefs it
+// refers to carry their declaration. Type nodes CTypeFromNode doesn't support fall back to the spelling of the type.
+func typedefCType(d *goclangast.TypedefDecl) (*goclangast.CType, error) {
+	if children := d.Children(); len(children) > 0 {
+		if ct, err := goclangast.CTypeFromNode(children[0]); err == nil {
+			return ct, nil
+		}
+	}
+	return d.Type.CType()
+}
+
+// qualified encodes qualifiers as a chain of modifier types, ending in the unqualified type.
+func (b *builder) qualified(t *goclangast.CType, from goclangast.Node) (uint32, error) {
+	id, err := b.typeID(t.Unqualified(), from)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, q := range []struct {
+		qual goclangast.Qualifiers
+		kind Kind
+	}{
+		{goclangast.QualRestrict, KindRestrict},
+		{goclangast.QualConst, KindConst},
+		{goclangast.QualVolatile, KindVolatile},
+	} {
+		if t.Qualifiers&q.qual != 0 {
+			id = b.cached(fmt.Sprintf("%d %d", q.kind, id), btfType{kind: q.kind, sizeOrType: id})
+		}
+	}
+
+	return id, nil
+}
+
+type builtinInfo struct {
+	size     int
+	encoding uint32
+	float    bool
+}
+
+var builtins = map[string]builtinInfo{
+	"char":               {1, intChar, false},
+	"signed char":        {1, intChar | intSigned, false},
+	"unsigned char":      {1, 0, false},
+	"_Bool":              {1, intBool, false},
+	"bool":               {1, intBool, false},
+	"short":              {2, intSigned, false},
+	"unsigned short":     {2, 0, false},
+	"int":                {4, intSigned, false},
+	"unsigned int":       {4, 0, false},
+	"long":               {8, intSigned, false},
+	"unsigned long":      {8, 0, false},
+	"long long":          {8, intSigned, false},
+	"unsigned long long": {8, 0, false},
+	"__int128":           {16, intSigned, false},
+	"unsigned __int128":  {16, 0, false},
+	"float":              {4, 0, true},
+	"double":             {8, 0, true},
+	"long double":        {16, 0, true},
+	"_Float16":           {2, 0, true},
+}
+
+func (b *builder) builtin(t *goclangast.CType) (uint32, error) {
+	name := t.CanonicalName()
+	if name == "void" {
+		return 0, nil
+	}
+
+	info, ok := builtins[name]
+	if !ok {
+		return 0, fmt.Errorf("unsupported builtin type %s", t.Name)
+	}
+	if name == "long" || name == "unsigned long" {
+		info.size = b.cfg.PointerSize
+	}
+
+	if id, ok := b.ids[name]; ok {
+		return id, nil
+	}
+
+	var id uint32
+	if info.float {
+		id = b.add(btfType{name: b.str(name), kind: KindFloat, sizeOrType: uint32(info.size)})
+	} else {
+		id = b.add(btfType{
+			name:       b.str(name),
+			kind:       KindInt,
+			sizeOrType: uint32(info.size),
+			extra:      []uint32{info.encoding<<24 | uint32(info.size*8)},
+		})
+	}
+	b.ids[name] = id
+	return id, nil
+}
+
+// arrayIndexType returns the integer type used as index type of arrays, mirroring the one emitted by clang.
+func (b *builder) arrayIndexType() (uint32, error) {
+	const name = "__ARRAY_SIZE_TYPE__"
+	if id, ok := b.ids[name]; ok {
+		return id, nil
+	}
+
+	id := b.add(btfType{name: b.str(name), kind: KindInt, sizeOrType: 4, extra: []uint32{32}})
+	b.ids[name] = id
+	return id, nil
+}
+
+func (b *builder) record(d *goclangast.RecordDecl) (uint32, error) {
+	key := declKey(d)
+	if id, ok := b.ids[key]; ok {
+		return id, nil
+	}
+
+	// Records named by a typedef keep their empty name, the typedef refers to them separately.
+	name := d.Name
+	if d.Layout == nil {
+		// Records without layout are forward declared.
+		id := b.add(btfType{name: b.str(name), kind: KindForward, kindFlag: d.TagUsed == "union"})
+		b.ids[key] = id
+		return id, nil
+	}
+
+	kind := KindStruct
+	if d.TagUsed == "union" {
+		kind = KindUnion
+	}
+
+	// Reserve the ID first, members may refer back to the record through pointers.
+	id := b.add(btfType{name: b.str(name), kind: kind, sizeOrType: uint32(d.Layout.Size)})
+	b.ids[key] = id
+
+	var (
+		members  []uint32
+		widths   []int
+		bitfield bool
+	)
+	for _, c := range d.Inner {
+		f, ok := c.(*goclangast.FieldDecl)
+		if !ok || f.Layout == nil {
+			continue
+		}
+
+		ct, err := f.Type.CType()
+		if err != nil {
+			return 0, err
+		}
+		typ, err := b.typeID(ct, f)
+		if err != nil {
+			return 0, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+
+		if f.IsBitfield {
+			bitfield = true
+		}
+		members = append(members, b.str(f.Name), typ, uint32(f.Layout.BitOffset))
+		widths = append(widths, f.Layout.BitWidth)
+	}
+
+	if bitfield {
+		// With kind_flag set all member offsets carry the bitfield size in the upper 8 bits.
+		for i, w := range widths {
+			off := &members[i*3+2]
+			if *off >= 1<<24 {
+				return 0, fmt.Errorf("member offset %d too large for bitfield encoding", *off)
+			}
+			*off |= uint32(w) << 24
+		}
+	}
+
+	b.types[id-1].kindFlag = bitfield
+	b.types[id-1].vlen = len(members) / 3
+	b.types[id-1].extra = members
+	return id, nil
+}
+
+func (b *builder) enum(d *goclangast.EnumDecl) (uint32, error) {
+	key := declKey(d)
+	if id, ok := b.ids[key]; ok {
+		return id, nil
+	}
+
+	name := d.Name
+	consts, values := d.Constants()
+
+	var (
+		signed bool
+		wide   bool
+	)
+	for _, v := range values {
+		if v.Sign() < 0 {
+			signed = true
+		}
+	}
+	for _, v := range values {
+		if signed && (!v.IsInt64() || v.Int64() < -1<<31 || v.Int64() > 1<<31-1) {
+			wide = true
+		}
+		if !signed && (!v.IsUint64() || v.Uint64() > 1<<32-1) {
+			wide = true
+		}
+	}
+
+	bt := btfType{name: b.str(name), kind: KindEnum, kindFlag: signed, vlen: len(consts), sizeOrType: 4}
+	if wide {
+		bt.kind = KindEnum64
+		bt.sizeOrType = 8
+	}
+
+	for i, c := range consts {
+		v := twosComplement64(values[i])
+		if wide {
+			bt.extra = append(bt.extra, b.str(c.Name), uint32(v), uint32(v>>32))
+		} else {
+			bt.extra = append(bt.extra, b.str(c.Name), uint32(v))
+		}
+	}
+
+	id := b.add(bt)
+	b.ids[key] = id
+	return id, nil
+}
+
+func twosComplement64(v *big.Int) uint64 {
+	if v.IsInt64() {
+		return uint64(v.Int64())
+	}
+	return v.Uint64()
+}
+
+// funcProto encodes a function type used by the node from, names are used for the parameters if given.
+func (b *builder) funcProto(t *goclangast.CType, names []string, from goclangast.Node) (uint32, error) {
+	ret, err := b.typeID(t.Elem, from)
+	if err != nil {
+		return 0, err
+	}
+
+	var params []uint32
+	for i, p := range t.Params {
+		typ, err := b.typeID(p, from)
+		if err != nil {
+			return 0, err
+		}
+
+		var name uint32
+		if i < len(names) {
+			name = b.str(names[i])
+		}
+		params = append(params, name, typ)
+	}
+	if t.Variadic {
+		params = append(params, 0, 0)
+	}
+
+	bt := btfType{kind: KindFuncProto, vlen: len(params) / 2, sizeOrType: ret, extra: params}
+	if names != nil {
+		return b.add(bt), nil
+	}
+	return b.cached(fmt.Sprintf("func %d %v", ret, params), bt), nil
+}
+
+func (b *builder) function(d *goclangast.FunctionDecl) error {
+	if _, ok := b.ids["func "+d.Name]; ok {
+		return nil
+	}
+
+	ct, err := d.Type.CType()
+	if err != nil {
+		return err
+	}
+	if ct.Kind != goclangast.CTypeFunction {
+		return fmt.Errorf("function %s has type %s", d.Name, ct)
+	}
+
+	var (
+		names   []string
+		hasBody bool
+	)
+	for _, c := range d.Inner {
+		switch c := c.(type) {
+		case *goclangast.ParmVarDecl:
+			names = append(names, c.Name)
+		case *goclangast.CompoundStmt:
+			hasBody = true
+		}
+	}
+
+	proto, err := b.funcProto(ct, names, d)
+	if err != nil {
+		return fmt.Errorf("function %s: %w", d.Name, err)
+	}
+
+	linkage := linkageGlobal
+	switch {
+	case d.StorageClass == "static":
+		linkage = linkageStatic
+	case !hasBody:
+		linkage = linkageExtern
+	}
+
+	b.ids["func "+d.Name] = b.add(btfType{name: b.str(d.Name), kind: KindFunc, vlen: linkage, sizeOrType: proto})
+	return nil
+}
+
+func (b *builder) encode() []byte {
+	var types bytes.Buffer
+	bo := b.cfg.ByteOrder
+	for _, t := range b.types {
+		info := uint32(t.vlen&0xffff) | uint32(t.kind&0x1f)<<24
+		if t.kindFlag {
+			info |= 1 << 31
+		}
+
+		_ = binary.Write(&types, bo, []uint32{t.name, info, t.sizeOrType})
+		_ = binary.Write(&types, bo, t.extra)
+	}
+
+	var out bytes.Buffer
+	_ = binary.Write(&out, bo, struct {
+		Magic   uint16
+		Version uint8
+		Flags   uint8
+		HdrLen  uint32
+		TypeOff uint32
+		TypeLen uint32
+		StrOff  uint32
+		StrLen  uint32
+	}{
+		Magic:   btfMagic,
+		Version: btfVersion,
+		HdrLen:  headerLen,
+		TypeOff: 0,
+		TypeLen: uint32(types.Len()),
+		StrOff:  uint32(types.Len()),
+		StrLen:  uint32(len(b.strtab)),
+	})
+	out.Write(types.Bytes())
+	out.Write(b.strtab)
+
+	return out.Bytes()
+}
@@ -0,0 +1,204 @@
+package btf
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	ebpfbtf "github.com/cilium/ebpf/btf"
+
+	"github.com/dylandreimerink/goclangast"
+)
+
+// testLayouts are the layouts clang computes for the records in testdata/types.c on x86_64, in bits, by record name
+// in order of declaration.
+var testLayouts = map[string][]struct {
+	size    int
+	offsets []int
+}{
+	"pair":   {{128, []int{0, 64}}, {8, []int{0}}, {16, []int{0}}},
+	"flags":  {{64, []int{0, 3, 32}}},
+	"u":      {{32, []int{0, 0}}},
+	"holder": {{8, []int{0}}},
+}
+
+func loadTestTU(t *testing.T) *goclangast.TranslationUnitDecl {
+	t.Helper()
+
+	b, err := os.ReadFile("testdata/types.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tu, err := goclangast.ParseTU(bytes.NewBuffer(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]int)
+	goclangast.PreOrderVisit(tu, func(n goclangast.Node, depth int) error {
+		d, ok := n.(*goclangast.RecordDecl)
+		if !ok {
+			return nil
+		}
+
+		l := testLayouts[d.Name][seen[d.Name]]
+		seen[d.Name]++
+
+		d.Layout = &goclangast.RecordLayout{Size: l.size / 8}
+		i := 0
+		for _, c := range d.Inner {
+			if f, ok := c.(*goclangast.FieldDecl); ok {
+				f.Layout = &goclangast.FieldLayout{
+					ByteOffset: l.offsets[i] / 8,
+					BitOffset:  l.offsets[i],
+					BitWidth:   f.BitfieldWidth(),
+				}
+				i++
+			}
+		}
+		return nil
+	})
+
+	return tu
+}
+
+func TestGenerateRoundTrip(t *testing.T) {
+	blob, err := Generate(loadTestTU(t), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := ebpfbtf.LoadSpecFromReader(bytes.NewReader(blob))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type member struct {
+		name     string
+		offset   ebpfbtf.Bits
+		bitfield ebpfbtf.Bits
+	}
+	checkMembers := func(name string, got []ebpfbtf.Member, want []member) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("%s has %d members, want %d", name, len(got), len(want))
+		}
+		for i, m := range got {
+			if m.Name != want[i].name || m.Offset != want[i].offset || m.BitfieldSize != want[i].bitfield {
+				t.Errorf("%s member %d is %s at %d size %d, want %s at %d size %d", name, i,
+					m.Name, m.Offset, m.BitfieldSize, want[i].name, want[i].offset, want[i].bitfield)
+			}
+		}
+	}
+
+	// The global and the function local structs pair are distinct types, told apart by their first member.
+	pairs, err := spec.AnyTypesByName("pair")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byMember := make(map[string]*ebpfbtf.Struct)
+	for _, typ := range pairs {
+		s, ok := typ.(*ebpfbtf.Struct)
+		if !ok {
+			t.Fatalf("pair is %T, want *btf.Struct", typ)
+		}
+		byMember[s.Members[0].Name] = s
+	}
+	global, local := byMember["a"], byMember["c"]
+	if global == nil || local == nil || byMember["s"] == nil {
+		t.Fatalf("want a global and two local structs pair, got %v", pairs)
+	}
+	if global.Size != 16 {
+		t.Errorf("struct pair has size %d, want 16", global.Size)
+	}
+	checkMembers("struct pair", global.Members, []member{{"a", 0, 0}, {"b", 64, 0}})
+	if b, ok := global.Members[1].Type.(*ebpfbtf.Int); !ok || b.Size != 8 || b.Encoding != ebpfbtf.Signed {
+		t.Errorf("long is %v, want signed 8 byte int", global.Members[1].Type)
+	}
+	checkMembers("local struct pair", local.Members, []member{{"c", 0, 0}})
+
+	var holder *ebpfbtf.Struct
+	if err := spec.TypeByName("holder", &holder); err != nil {
+		t.Fatal(err)
+	}
+	if holder.Members[0].Type != local {
+		t.Errorf("holder.p is %v, want the local struct pair", holder.Members[0].Type)
+	}
+
+	var pairT *ebpfbtf.Typedef
+	if err := spec.TypeByName("pair_t", &pairT); err != nil {
+		t.Fatal(err)
+	}
+	if pairT.Type != global {
+		t.Errorf("pair_t is %v, want the global struct pair", pairT.Type)
+	}
+
+	// outer_t is declared before the struct pair local to g, so it names the global one.
+	var outerT *ebpfbtf.Typedef
+	if err := spec.TypeByName("outer_t", &outerT); err != nil {
+		t.Fatal(err)
+	}
+	if outerT.Type != global {
+		t.Errorf("outer_t is %v, want the global struct pair", outerT.Type)
+	}
+
+	var fn *ebpfbtf.Func
+	if err := spec.TypeByName("fn", &fn); err != nil {
+		t.Fatal(err)
+	}
+	proto := fn.Type.(*ebpfbtf.FuncProto)
+	if len(proto.Params) != 1 || proto.Params[0].Name != "p" {
+		t.Fatalf("fn has params %v, want p", proto.Params)
+	}
+	if ptr, ok := proto.Params[0].Type.(*ebpfbtf.Pointer); !ok || ptr.Target != global {
+		t.Errorf("fn parameter p is %v, want a pointer to the global struct pair", proto.Params[0].Type)
+	}
+
+	// Bitfields set kind_flag, so the bitfield size is encoded in the member offset.
+	var flags *ebpfbtf.Struct
+	if err := spec.TypeByName("flags", &flags); err != nil {
+		t.Fatal(err)
+	}
+	checkMembers("struct flags", flags.Members, []member{{"x", 0, 3}, {"y", 3, 5}, {"z", 32, 0}})
+
+	var u *ebpfbtf.Union
+	if err := spec.TypeByName("u", &u); err != nil {
+		t.Fatal(err)
+	}
+	checkMembers("union u", u.Members, []member{{"i", 0, 0}, {"f", 0, 0}})
+
+	var (
+		anon *ebpfbtf.Enum
+		big  *ebpfbtf.Enum
+	)
+	iter := spec.Iterate()
+	for iter.Next() {
+		if e, ok := iter.Type.(*ebpfbtf.Enum); ok {
+			switch e.Name {
+			case "":
+				anon = e
+			case "big":
+				big = e
+			}
+		}
+	}
+
+	if anon == nil {
+		t.Fatal("anonymous enum is missing")
+	}
+	if anon.Size != 4 || len(anon.Values) != 2 || anon.Values[0] != (ebpfbtf.EnumValue{Name: "A", Value: 1}) ||
+		anon.Values[1] != (ebpfbtf.EnumValue{Name: "B", Value: 2}) {
+		t.Errorf("anonymous enum is %+v, want A = 1, B = 2", anon)
+	}
+
+	if big == nil {
+		t.Fatal("enum big is missing")
+	}
+	minusOne := int64(-1)
+	if big.Size != 8 || !big.Signed || len(big.Values) != 2 ||
+		big.Values[0] != (ebpfbtf.EnumValue{Name: "SMALL", Value: uint64(minusOne)}) ||
+		big.Values[1] != (ebpfbtf.EnumValue{Name: "HUGE", Value: 1 << 32}) {
+		t.Errorf("enum big is %+v, want a signed Enum64 with SMALL = -1, HUGE = 1 << 32", big)
+	}
+}
@@ -0,0 +1,41 @@
+struct pair {
+	int a;
+	long b;
+};
+
+struct flags {
+	unsigned int x : 3;
+	unsigned int y : 5;
+	int z;
+};
+
+union u {
+	int i;
+	float f;
+};
+
+enum { A = 1, B = 2 };
+
+enum big { SMALL = -1, HUGE = 0x100000000 };
+
+typedef struct pair pair_t;
+
+int fn(struct pair *p)
+{
+	struct pair {
+		char c;
+	};
+	struct holder {
+		struct pair p;
+	} h;
+
+	return 0;
+}
+
+void g(void)
+{
+	typedef struct pair outer_t;
+	struct pair {
+		short s;
+	};
+}
@@ -0,0 +1,406 @@
+package goclangast
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache entries start with cacheMagic and the format version, followed by the dependencies of the translation unit
+// with the hash of their contents, the diagnostics as JSON and a snapshot of the AST.
+const (
+	cacheMagic   = "GCAC"
+	cacheVersion = 1
+)
+
+// cachedAST loads the AST from opts.CacheDir, or runs clang and stores the result if there is no valid entry.
+func cachedAST(ctx context.Context, path string, opts Options) (*TranslationUnitDecl, []Diagnostic, error) {
+	key, err := cacheKey(ctx, path, opts)
+	if err != nil {
+		// Without a key, for example because the source file doesn't exist, leave reporting the problem to clang.
+		return runAST(ctx, path, opts, nil)
+	}
+
+	entry := filepath.Join(opts.CacheDir, key+".ast")
+	if ast, diags, err := loadCacheEntry(entry, opts); err == nil {
+		return ast, diags, nil
+	}
+
+	err = os.MkdirAll(opts.CacheDir, 0o755)
+	if err != nil {
+		return runAST(ctx, path, opts, nil)
+	}
+
+	depFile, err := os.CreateTemp(opts.CacheDir, "*.d")
+	if err != nil {
+		return runAST(ctx, path, opts, nil)
+	}
+	depFile.Close()
+	defer os.Remove(depFile.Name())
+
+	depPath, err := filepath.Abs(depFile.Name())
+	if err != nil {
+		return runAST(ctx, path, opts, nil)
+	}
+
+	start := time.Now()
+	ast, diags, err := runAST(ctx, path, opts, []string{"-MD", "-MF", depPath})
+	if err != nil {
+		return ast, diags, err
+	}
+
+	_ = storeCacheEntry(entry, depPath, start, ast, diags, opts)
+
+	return ast, diags, nil
+}
+
+// clangEnvVars are the environment variables which affect the output of clang.
+var clangEnvVars = map[string]bool{
+	"CPATH":                      true,
+	"C_INCLUDE_PATH":             true,
+	"CPLUS_INCLUDE_PATH":         true,
+	"OBJC_INCLUDE_PATH":          true,
+	"OBJCPLUS_INCLUDE_PATH":      true,
+	"SDKROOT":                    true,
+	"CCC_OVERRIDE_OPTIONS":       true,
+	"COMPILER_PATH":              true,
+	"SOURCE_DATE_EPOCH":          true,
+	"MACOSX_DEPLOYMENT_TARGET":   true,
+	"IPHONEOS_DEPLOYMENT_TARGET": true,
+}
+
+// cacheKey hashes everything which determines the output of clang, other than the included headers, which are
+// validated when loading the entry.
+func cacheKey(ctx context.Context, path string, opts Options) (string, error) {
+	version, err := clangVersion(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+
+	dir := opts.Dir
+	if dir == "" {
+		dir, err = os.Getwd()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	src, err := os.ReadFile(resolvePath(dir, path))
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	field := func(s string) {
+		h.Write(binary.AppendUvarint(nil, uint64(len(s))))
+		h.Write([]byte(s))
+	}
+
+	field(cacheMagic)
+	field(strconv.Itoa(cacheVersion))
+	field(version)
+	field(dir)
+	field(path)
+	field(strconv.FormatBool(opts.RecordLayouts))
+	field(strconv.FormatBool(opts.OpenMPClauses))
+
+	field(strconv.Itoa(len(opts.Args)))
+	for _, arg := range opts.Args {
+		field(arg)
+	}
+
+	// An explicit environment is hashed as a whole, the environment of the process only for the variables clang reads,
+	// so that unrelated changes don't invalidate the cache.
+	env := opts.Env
+	if env == nil {
+		for _, kv := range os.Environ() {
+			name, _, _ := strings.Cut(kv, "=")
+			if clangEnvVars[name] {
+				env = append(env, kv)
+			}
+		}
+	}
+	field(strconv.Itoa(len(env)))
+	for _, kv := range env {
+		field(kv)
+	}
+
+	field(string(src))
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type clangBinary struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// clangVersions caches the output of clang --version per clang binary.
+var clangVersions sync.Map
+
+// clangVersion returns the output of clang --version, it is rerun when the binary changes.
+func clangVersion(ctx context.Context, opts Options) (string, error) {
+	path, err := exec.LookPath(opts.ClangPath)
+	if err != nil {
+		return "", err
+	}
+
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	bin := clangBinary{path: path, size: info.Size(), modTime: info.ModTime()}
+	if version, ok := clangVersions.Load(bin); ok {
+		return version.(string), nil
+	}
+
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return "", err
+	}
+
+	// The version output includes the path of the binary, which is part of the key already.
+	version := path + "\n" + string(out)
+	clangVersions.Store(bin, version)
+	return version, nil
+}
+
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) || dir == "" {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func hashFile(path string) ([sha256.Size]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(b), nil
+}
+
+var errCacheStale = errors.New("stale cache entry")
+
+// loadCacheEntry loads an entry, it returns an error if the entry doesn't exist, can't be read or any of its
+// dependencies changed.
+func loadCacheEntry(entry string, opts Options) (*TranslationUnitDecl, []Diagnostic, error) {
+	b, err := os.ReadFile(entry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !bytes.HasPrefix(b, []byte(cacheMagic)) {
+		return nil, nil, fmt.Errorf("not a cache entry")
+	}
+	b = b[len(cacheMagic):]
+
+	uvarint := func() uint64 {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			b = nil
+			return 0
+		}
+		b = b[n:]
+		return v
+	}
+	bytesN := func(n uint64) []byte {
+		if n > uint64(len(b)) {
+			b = nil
+			return nil
+		}
+		v := b[:n]
+		b = b[n:]
+		return v
+	}
+
+	if uvarint() != cacheVersion {
+		return nil, nil, errCacheStale
+	}
+
+	deps := uvarint()
+	for i := uint64(0); i < deps; i++ {
+		dep := string(bytesN(uvarint()))
+		sum := bytesN(sha256.Size)
+		if b == nil {
+			return nil, nil, fmt.Errorf("corrupt cache entry")
+		}
+
+		cur, err := hashFile(resolvePath(opts.Dir, dep))
+		if err != nil || !bytes.Equal(cur[:], sum) {
+			return nil, nil, errCacheStale
+		}
+	}
+
+	diagJSON := bytesN(uvarint())
+	if b == nil {
+		return nil, nil, fmt.Errorf("corrupt cache entry")
+	}
+
+	var diags []Diagnostic
+	err = json.Unmarshal(diagJSON, &diags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pctx := opts.ParseContext
+	if pctx == nil {
+		pctx = NewParseContext()
+	}
+
+	ast, err := pctx.ReadSnapshot(bytes.NewReader(b))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ast, diags, nil
+}
+
+// storeCacheEntry writes an entry for the AST, the dependencies are read from the dependency file clang wrote. Nothing
+// is stored if a dependency was modified after clang started, since the AST might not reflect its contents.
+func storeCacheEntry(entry, depPath string, start time.Time, ast *TranslationUnitDecl, diags []Diagnostic, opts Options) error {
+	depBytes, err := os.ReadFile(depPath)
+	if err != nil {
+		return err
+	}
+
+	deps := parseDepFile(depBytes)
+	if len(deps) == 0 {
+		return fmt.Errorf("no dependencies in %s", depPath)
+	}
+
+	// Modification times may be truncated to the second, so anything modified in the second clang started in counts.
+	cutoff := start.Truncate(time.Second)
+
+	out := binary.AppendUvarint([]byte(cacheMagic), cacheVersion)
+	out = binary.AppendUvarint(out, uint64(len(deps)))
+	for _, dep := range deps {
+		path := resolvePath(opts.Dir, dep)
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !info.ModTime().Before(cutoff) {
+			return fmt.Errorf("%s modified while parsing", path)
+		}
+
+		sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+
+		out = binary.AppendUvarint(out, uint64(len(dep)))
+		out = append(out, dep...)
+		out = append(out, sum[:]...)
+	}
+
+	diagJSON, err := json.Marshal(diags)
+	if err != nil {
+		return err
+	}
+	out = binary.AppendUvarint(out, uint64(len(diagJSON)))
+	out = append(out, diagJSON...)
+
+	buf := bytes.NewBuffer(out)
+	err = WriteSnapshot(buf, ast)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so concurrent readers never observe a partial entry.
+	tmp, err := os.CreateTemp(filepath.Dir(entry), "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(buf.Bytes())
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), entry)
+}
+
+// parseDepFile returns the prerequisites of the make rule written by clang's -MD flag.
+func parseDepFile(b []byte) []string {
+	s := string(b)
+
+	// Skip the target, the separator is the first colon followed by whitespace, so drive letters aren't mistaken for
+	// it.
+	i := 0
+	for {
+		j := strings.IndexByte(s[i:], ':')
+		if j < 0 {
+			return nil
+		}
+		i += j + 1
+		if i == len(s) || s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r' {
+			break
+		}
+	}
+	s = s[i:]
+
+	var (
+		deps []string
+		cur  strings.Builder
+	)
+	flush := func() {
+		if cur.Len() > 0 {
+			deps = append(deps, cur.String())
+			cur.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '\n' || s[i+1] == '\r'):
+			// Line continuation.
+			flush()
+			i++
+			if s[i] == '\r' && i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+		case c == '\\' && i+1 < len(s) && (s[i+1] == ' ' || s[i+1] == '#'):
+			cur.WriteByte(s[i+1])
+			i++
+		case c == '$' && i+1 < len(s) && s[i+1] == '$':
+			cur.WriteByte('$')
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			flush()
+		case c == '\n':
+			// The end of the rule, anything after it are phony targets for the headers.
+			flush()
+			return deps
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+
+	return deps
+}
@@ -0,0 +1,195 @@
+package goclangast
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDepFile(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"simple", "a.o: a.c a.h\n", []string{"a.c", "a.h"}},
+		{"no newline", "a.o: a.c", []string{"a.c"}},
+		{"escaped spaces", `a.o: my\ dir/a.c include/b\ c.h` + "\n", []string{"my dir/a.c", "include/b c.h"}},
+		{"escaped hash", `a.o: a\#1.h` + "\n", []string{"a#1.h"}},
+		{"dollar", "a.o: $$HOME/a.c cost$$.h\n", []string{"$HOME/a.c", "cost$.h"}},
+		{"continuations", "a.o: a.c \\\n  /usr/include/stdio.h \\\n  a.h\n", []string{"a.c", "/usr/include/stdio.h", "a.h"}},
+		{"CRLF continuations", "a.o: a.c \\\r\n  a.h\r\n", []string{"a.c", "a.h"}},
+		{"continuation without space", "a.o: a.c\\\n a.h\n", []string{"a.c", "a.h"}},
+		{"multiple targets", "a.o a.d: a.c a.h\n", []string{"a.c", "a.h"}},
+		{"escaped target", `my\ a.o: a.c` + "\n", []string{"a.c"}},
+		{"drive letters", "C:/out/a.o: C:/src/a.c C:\\inc\\a.h\n", []string{"C:/src/a.c", `C:\inc\a.h`}},
+		{"phony targets", "a.o: a.c a.h\n\na.h:\n", []string{"a.c", "a.h"}},
+		{"tabs", "a.o:\ta.c\ta.h\n", []string{"a.c", "a.h"}},
+		{"no prerequisites", "a.o:\n", nil},
+		{"no rule", "", nil},
+	} {
+		if got := parseDepFile([]byte(tt.in)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// cacheTest is a source file with a header in a temporary directory, and a clang stub which counts its runs, writes
+// the dependency file asked for with -MF and prints the dump of testdata/roundtrip/loops.c.
+type cacheTest struct {
+	t    *testing.T
+	dir  string
+	runs string
+	opts Options
+}
+
+func newCacheTest(t *testing.T) *cacheTest {
+	if runtime.GOOS == "windows" {
+		t.Skip("the clang stub is a shell script")
+	}
+
+	dump, err := filepath.Abs("testdata/roundtrip/loops.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	ct := &cacheTest{t: t, dir: dir, runs: filepath.Join(dir, "runs")}
+
+	script := fmt.Sprintf(`#!/bin/sh
+if [ "$1" = --version ]; then
+	echo "clang version 0.0.0 (stub)"
+	exit 0
+fi
+echo run >> %q
+while [ $# -gt 0 ]; do
+	if [ "$1" = -MF ]; then
+		printf 'a.o: a.c \\\n  a.h\n' > "$2"
+	fi
+	shift
+done
+exec cat %q
+`, ct.runs, dump)
+	clang := filepath.Join(dir, "clang")
+	if err := os.WriteFile(clang, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ct.write("a.c", `#include "a.h"`)
+	ct.write("a.h", "int a;")
+	ct.opts = Options{ClangPath: clang, Dir: dir, CacheDir: filepath.Join(dir, "cache")}
+	return ct
+}
+
+// write writes a file in the test directory, dated in the past since entries aren't stored for files which might
+// have changed while clang ran.
+func (ct *cacheTest) write(name, content string) {
+	path := filepath.Join(ct.dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		ct.t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, past, past); err != nil {
+		ct.t.Fatal(err)
+	}
+}
+
+// parse parses a.c and checks whether clang ran.
+func (ct *cacheTest) parse(step string, opts Options, wantRun bool) {
+	ct.t.Helper()
+
+	before := ct.countRuns()
+	tu, _, err := NewASTContext(context.Background(), "a.c", opts)
+	if err != nil {
+		ct.t.Fatalf("%s: %v", step, err)
+	}
+	if len(nodesOfKind(tu, "ForStmt")) != 3 {
+		ct.t.Fatalf("%s: the AST isn't the one of loops.c", step)
+	}
+
+	if ran := ct.countRuns() > before; ran != wantRun {
+		ct.t.Errorf("%s: clang ran: %v, want %v", step, ran, wantRun)
+	}
+}
+
+func (ct *cacheTest) countRuns() int {
+	b, err := os.ReadFile(ct.runs)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		ct.t.Fatal(err)
+	}
+	return strings.Count(string(b), "run")
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	ct := newCacheTest(t)
+
+	ct.parse("first parse", ct.opts, true)
+	ct.parse("identical rerun", ct.opts, false)
+
+	ct.write("a.h", "long a;")
+	ct.parse("header edited", ct.opts, true)
+	ct.parse("rerun after header edit", ct.opts, false)
+
+	ct.write("a.c", `#include "a.h" /* edited */`)
+	ct.parse("source edited", ct.opts, true)
+
+	withEnv := ct.opts
+	withEnv.Env = []string{"PATH=" + os.Getenv("PATH")}
+	ct.parse("explicit environment", withEnv, true)
+	ct.parse("rerun with environment", withEnv, false)
+	withEnv.Env = append(withEnv.Env, "CPATH=/opt/include")
+	ct.parse("environment changed", withEnv, true)
+
+	withArgs := ct.opts
+	withArgs.Args = []string{"-DDEBUG"}
+	ct.parse("arguments changed", withArgs, true)
+	ct.parse("rerun with arguments", withArgs, false)
+
+	if err := os.Remove(filepath.Join(ct.dir, "a.h")); err != nil {
+		t.Fatal(err)
+	}
+	ct.parse("header removed", ct.opts, true)
+}
+
+func TestCacheCorruptEntry(t *testing.T) {
+	ct := newCacheTest(t)
+	ct.parse("first parse", ct.opts, true)
+
+	entries, err := filepath.Glob(filepath.Join(ct.opts.CacheDir, "*.ast"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("found %d cache entries, want 1", len(entries))
+	}
+
+	b, err := os.ReadFile(entries[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, corrupt := range []struct {
+		name string
+		b    []byte
+	}{
+		{"truncated", b[:len(b)/2]},
+		{"garbage", []byte("not a cache entry")},
+		{"empty", nil},
+	} {
+		if err := os.WriteFile(entries[0], corrupt.b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		ct.parse(corrupt.name+" entry", ct.opts, true)
+		// The entry is replaced by a valid one.
+		ct.parse("rerun after "+corrupt.name+" entry", ct.opts, false)
+	}
+}
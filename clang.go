@@ -2,38 +2,261 @@ package goclangast
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"io"
 	"os/exec"
+	"time"
 )
 
 type Options struct {
 	ClangPath string
 	Args      []string
+	// Timeout bounds the time clang is allowed to run, zero means no timeout.
+	Timeout time.Duration
+	// Env is the environment clang is started with, nil means the environment of the current process.
+	Env []string
+	// Dir is the working directory of clang, empty means the working directory of the current process.
+	Dir string
+	// ParseContext is used to parse the output of clang, nil means a new ParseContext is created for every call.
+	ParseContext *ParseContext
+	// RecordLayouts runs clang a second time to fill RecordDecl.Layout and FieldDecl.Layout, this requires clang 15 or
+	// newer.
+	RecordLayouts bool
+	// OpenMPClauses runs clang a second time to set the Kind of OMPClause's, which the JSON dump doesn't include. Without
+	// it, accessors such as OMPExecutableDirective.Private report that the clause kinds are unknown.
+	OpenMPClauses bool
+	// CacheDir is a directory in which parsed ASTs are cached, empty disables caching. Entries are keyed on the clang
+	// binary, the arguments, Env or the variables of the process environment clang reads, and the contents of the
+	// source file, and are only reused if none of the headers it included changed, see NewASTContext.
+	CacheDir string
 }
 
-func NewASTOptions(path string, opts Options) (*TranslationUnitDecl, error) {
+type RunErrorReason int
+
+const (
+	// RunFailed indicates that clang exited with an error or could not be started.
+	RunFailed RunErrorReason = iota
+	// RunTimeout indicates that clang was killed because Options.Timeout or the context deadline passed.
+	RunTimeout
+	// RunCanceled indicates that clang was killed because the context was canceled.
+	RunCanceled
+)
+
+func (r RunErrorReason) String() string {
+	switch r {
+	case RunFailed:
+		return "failed"
+	case RunTimeout:
+		return "timeout"
+	case RunCanceled:
+		return "canceled"
+	}
+
+	return fmt.Sprintf("RunErrorReason(%d)", int(r))
+}
+
+// RunError is returned when the clang process did not complete successfully.
+type RunError struct {
+	Reason      RunErrorReason
+	Err         error
+	Diagnostics []Diagnostic
+}
+
+func (e *RunError) Error() string {
+	for _, diag := range e.Diagnostics {
+		if diag.Severity == SeverityError || diag.Severity == SeverityFatalError {
+			return fmt.Sprintf("run: %s: %v: %s", e.Reason, e.Err, diag.Message)
+		}
+	}
+
+	return fmt.Sprintf("run: %s: %v", e.Reason, e.Err)
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// NewASTContext runs clang on the file at path and parses the resulting AST, returning it together with the
+// diagnostics clang emitted. The clang process is killed when ctx is done or when opts.Timeout passes, in which case a
+// *RunError is returned.
+//
+// If opts.CacheDir is set, the AST is loaded from the cache when an entry exists for the same clang binary, arguments
+// and source file, and all headers in its include closure, as reported by clang's dependency output, are unchanged.
+// Otherwise clang is run and the result is stored in the cache, failing to store it is not an error.
+func NewASTContext(ctx context.Context, path string, opts Options) (*TranslationUnitDecl, []Diagnostic, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	if opts.CacheDir != "" {
+		return cachedAST(ctx, path, opts)
+	}
+
+	return runAST(ctx, path, opts, nil)
+}
+
+// runAST runs clang with the extra arguments and parses the AST.
+func runAST(ctx context.Context, path string, opts Options, extraArgs []string) (*TranslationUnitDecl, []Diagnostic, error) {
 	args := []string{"-Xclang", "-ast-dump=json", "-fsyntax-only"}
+	args = append(args, diagnosticArgs...)
 	args = append(args, opts.Args...)
+	args = append(args, extraArgs...)
 	args = append(args, path)
-	cmd := exec.Command(opts.ClangPath, args...)
+	cmd := exec.CommandContext(ctx, opts.ClangPath, args...)
+	cmd.Env = opts.Env
+	cmd.Dir = opts.Dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, newRunError(ctx, err, nil)
+	}
 
-	var b bytes.Buffer
+	err = cmd.Start()
+	if err != nil {
+		return nil, nil, newRunError(ctx, err, nil)
+	}
 
-	cmd.Stdout = &b
-	cmd.Stderr = os.Stderr
+	pctx := opts.ParseContext
+	if pctx == nil {
+		pctx = NewParseContext()
+	}
+
+	// The AST is parsed while clang is still writing it, so the dump never has to be held in memory as a whole.
+	ast, parseErr := pctx.ParseTUReader(stdout)
+	if parseErr != nil {
+		// Drain the pipe so clang doesn't block on a full pipe before Wait returns.
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+
+	err = cmd.Wait()
+	diags := parseDiagnostics(stderr.Bytes())
+	if err != nil {
+		return nil, diags, newRunError(ctx, err, diags)
+	}
+
+	if parseErr != nil {
+		return nil, diags, fmt.Errorf("parse: %v", parseErr)
+	}
+
+	if opts.RecordLayouts {
+		err = dumpRecordLayouts(ctx, ast, path, opts)
+		if err != nil {
+			return nil, diags, err
+		}
+	}
+
+	if opts.OpenMPClauses {
+		ompDiags, err := dumpOpenMPClauses(ctx, ast, path, opts)
+		diags = append(diags, ompDiags...)
+		if err != nil {
+			return nil, diags, err
+		}
+	}
+
+	return ast, diags, nil
+}
+
+// dumpRecordLayouts runs clang a second time to dump the layout of all complete records and merges it into the AST.
+func dumpRecordLayouts(ctx context.Context, ast *TranslationUnitDecl, path string, opts Options) error {
+	args := []string{
+		"-Xclang", "-fdump-record-layouts",
+		"-Xclang", "-fdump-record-layouts-simple",
+		"-Xclang", "-fdump-record-layouts-complete",
+		"-fsyntax-only",
+	}
+	args = append(args, opts.Args...)
+	args = append(args, path)
+	cmd := exec.CommandContext(ctx, opts.ClangPath, args...)
+	cmd.Env = opts.Env
+	cmd.Dir = opts.Dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
 
 	err := cmd.Run()
 	if err != nil {
-		return nil, fmt.Errorf("run: %v", err)
+		return newRunError(ctx, err, parseDiagnostics(stderr.Bytes()))
 	}
 
-	ast, err := ParseTU(&b)
+	layouts, err := parseRecordLayouts(&stdout)
+	if err != nil {
+		return fmt.Errorf("record layouts: %w", err)
+	}
+
+	applyRecordLayouts(ast, layouts)
+
+	return nil
+}
+
+// dumpOpenMPClauses runs clang a second time to dump the AST as text, which unlike the JSON dump names the clauses of
+// OpenMP directives, and sets the kind of the clauses in the AST. Differences between the two dumps are returned as
+// warnings.
+func dumpOpenMPClauses(ctx context.Context, ast *TranslationUnitDecl, path string, opts Options) ([]Diagnostic, error) {
+	args := []string{"-Xclang", "-ast-dump", "-fsyntax-only", "-fno-color-diagnostics"}
+	args = append(args, opts.Args...)
+	args = append(args, path)
+	cmd := exec.CommandContext(ctx, opts.ClangPath, args...)
+	cmd.Env = opts.Env
+	cmd.Dir = opts.Dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return nil, fmt.Errorf("parse: %v", err)
+		return nil, newRunError(ctx, err, nil)
 	}
 
-	return ast, nil
+	err = cmd.Start()
+	if err != nil {
+		return nil, newRunError(ctx, err, nil)
+	}
+
+	// The text dump is usually much larger than the information we need from it, so it is parsed while clang writes it.
+	directives, parseErr := parseOpenMPClauses(stdout)
+	if parseErr != nil {
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+
+	err = cmd.Wait()
+	if err != nil {
+		return nil, newRunError(ctx, err, parseDiagnostics(stderr.Bytes()))
+	}
+
+	if parseErr != nil {
+		return nil, fmt.Errorf("openmp clauses: %w", parseErr)
+	}
+
+	return applyOpenMPClauses(ast, directives), nil
+}
+
+func newRunError(ctx context.Context, err error, diags []Diagnostic) *RunError {
+	reason := RunFailed
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		reason = RunTimeout
+	case errors.Is(ctx.Err(), context.Canceled):
+		reason = RunCanceled
+	}
+
+	return &RunError{
+		Reason:      reason,
+		Err:         err,
+		Diagnostics: diags,
+	}
+}
+
+func NewASTOptions(path string, opts Options) (*TranslationUnitDecl, error) {
+	ast, _, err := NewASTContext(context.Background(), path, opts)
+	return ast, err
 }
 
 func NewAST(path string) (*TranslationUnitDecl, error) {
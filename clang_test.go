@@ -0,0 +1,127 @@
+package goclangast
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeClangStub writes a shell script with the given body standing in for clang and returns its path.
+func writeClangStub(t *testing.T, body string) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the clang stub is a shell script")
+	}
+
+	clang := filepath.Join(t.TempDir(), "clang")
+	if err := os.WriteFile(clang, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return clang
+}
+
+func TestNewRunError(t *testing.T) {
+	errExit := errors.New("exit status 1")
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, tt := range []struct {
+		name string
+		ctx  context.Context
+		want RunErrorReason
+	}{
+		{"failed", context.Background(), RunFailed},
+		{"deadline", expired, RunTimeout},
+		{"canceled", canceled, RunCanceled},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newRunError(tt.ctx, errExit, nil)
+			if err.Reason != tt.want {
+				t.Errorf("reason is %s, want %s", err.Reason, tt.want)
+			}
+			if !errors.Is(err, errExit) {
+				t.Errorf("%v doesn't wrap %v", err, errExit)
+			}
+			if want := "run: " + tt.want.String() + ": exit status 1"; err.Error() != want {
+				t.Errorf("error is %q, want %q", err.Error(), want)
+			}
+		})
+	}
+
+	// The message of the first error is part of the error, warnings aren't.
+	err := newRunError(context.Background(), errExit, []Diagnostic{
+		{Severity: SeverityWarning, Message: "unused variable 'x'"},
+		{Severity: SeverityError, Message: "use of undeclared identifier 'y'"},
+		{Severity: SeverityError, Message: "expected ';' after expression"},
+	})
+	if want := "run: failed: exit status 1: use of undeclared identifier 'y'"; err.Error() != want {
+		t.Errorf("error is %q, want %q", err.Error(), want)
+	}
+
+	if s := RunErrorReason(7).String(); s != "RunErrorReason(7)" {
+		t.Errorf("unknown reason is %q", s)
+	}
+}
+
+func TestNewASTContextRunError(t *testing.T) {
+	fail := writeClangStub(t, `echo "t.c:3:2: error: use of undeclared identifier 'y' [Semantic Issue]" >&2
+exit 1`)
+	hang := writeClangStub(t, "exec sleep 10")
+
+	for _, tt := range []struct {
+		name        string
+		opts        Options
+		cancelAfter time.Duration
+		want        RunErrorReason
+		diags       int
+	}{
+		{"failed", Options{ClangPath: fail}, 0, RunFailed, 1},
+		{"not found", Options{ClangPath: filepath.Join(t.TempDir(), "clang")}, 0, RunFailed, 0},
+		{"timeout", Options{ClangPath: hang, Timeout: 100 * time.Millisecond}, 0, RunTimeout, 0},
+		{"canceled", Options{ClangPath: hang}, 100 * time.Millisecond, RunCanceled, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancelAfter > 0 {
+				time.AfterFunc(tt.cancelAfter, cancel)
+			}
+
+			start := time.Now()
+			tu, diags, err := NewASTContext(ctx, "t.c", tt.opts)
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("clang was killed after %s", elapsed)
+			}
+
+			var runErr *RunError
+			if !errors.As(err, &runErr) {
+				t.Fatalf("got TU %v and error %v, want a *RunError", tu, err)
+			}
+			if runErr.Reason != tt.want {
+				t.Errorf("reason is %s, want %s", runErr.Reason, tt.want)
+			}
+			if len(diags) != tt.diags || len(runErr.Diagnostics) != tt.diags {
+				t.Errorf("got diagnostics %+v and %+v, want %d", diags, runErr.Diagnostics, tt.diags)
+			}
+		})
+	}
+
+	_, _, err := NewASTContext(context.Background(), "t.c", Options{ClangPath: fail})
+	if err == nil || !strings.Contains(err.Error(), "use of undeclared identifier 'y'") {
+		t.Errorf("error %v doesn't include the error diagnostic", err)
+	}
+	_, _, err = NewASTContext(context.Background(), "t.c", Options{ClangPath: filepath.Join(t.TempDir(), "clang")})
+	if !errors.Is(err, os.ErrNotExist) && !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("error %v doesn't wrap the error starting clang", err)
+	}
+}
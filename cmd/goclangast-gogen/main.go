@@ -0,0 +1,99 @@
+// Command goclangast-gogen generates Go mirrors of the types and constants declared in a C header. It is meant to be
+// used with go:generate, for example:
+//
+//	//go:generate go run github.com/dylandreimerink/goclangast/cmd/goclangast-gogen -pkg foo -o foo_gen.go foo.h -- -I include
+//
+// Arguments after "--" are passed to clang.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dylandreimerink/goclangast"
+	"github.com/dylandreimerink/goclangast/gogen"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "goclangast-gogen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		pkg       = flag.String("pkg", os.Getenv("GOPACKAGE"), "name of the generated package, defaults to $GOPACKAGE")
+		out       = flag.String("o", "", "output file, defaults to stdout")
+		clang     = flag.String("clang", "clang", "path to clang")
+		files     = flag.String("files", "", "comma separated list of files to generate declarations for, defaults to the header")
+		all       = flag.Bool("all", false, "generate declarations from all included files")
+		ptrSize   = flag.Int("ptrsize", 8, "size of pointers on the target in bytes")
+		clangArgs []string
+	)
+
+	args := os.Args[1:]
+	for i, arg := range args {
+		if arg == "--" {
+			clangArgs = args[i+1:]
+			args = args[:i]
+			break
+		}
+	}
+
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return err
+	}
+
+	if flag.NArg() != 1 {
+		return fmt.Errorf("expected exactly one header, got %d", flag.NArg())
+	}
+	header := flag.Arg(0)
+
+	if *pkg == "" {
+		return fmt.Errorf("missing -pkg")
+	}
+
+	tu, diags, err := goclangast.NewASTContext(context.Background(), header, goclangast.Options{
+		ClangPath:     *clang,
+		Args:          append([]string{"-x", "c"}, clangArgs...),
+		RecordLayouts: true,
+	})
+	for _, d := range diags {
+		if d.Loc != nil {
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: %s: %s\n", d.Loc.File, d.Loc.Line, d.Loc.Col, d.Severity, d.Message)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", d.Severity, d.Message)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	cfg := gogen.Config{
+		Package:     *pkg,
+		PointerSize: *ptrSize,
+	}
+	switch {
+	case *files != "":
+		cfg.Files = strings.Split(*files, ",")
+	case !*all:
+		cfg.Files = []string{header, filepath.Clean(header)}
+	}
+
+	src, err := gogen.Generate(tu, cfg)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+
+	return os.WriteFile(*out, src, 0o644)
+}
@@ -0,0 +1,146 @@
+// Command goclangast-query prints the AST nodes matching a selector, see package query for the syntax. For example:
+//
+//	goclangast-query 'CallExpr:ref(FunctionDecl[Name=bpf_map_lookup_elem])' prog.c -- -I include
+//
+// Arguments after "--" are passed to clang. With -ast the input is a JSON AST dump as created by
+// "clang -Xclang -ast-dump=json -fsyntax-only" instead of a source file, "-" reads the dump from stdin.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dylandreimerink/goclangast"
+	"github.com/dylandreimerink/goclangast/query"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "goclangast-query: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		clang     = flag.String("clang", "clang", "path to clang")
+		ast       = flag.Bool("ast", false, "read a JSON AST dump instead of a source file")
+		count     = flag.Bool("c", false, "only print the number of matches")
+		clangArgs []string
+	)
+
+	args := os.Args[1:]
+	for i, arg := range args {
+		if arg == "--" {
+			clangArgs = args[i+1:]
+			args = args[:i]
+			break
+		}
+	}
+
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return err
+	}
+
+	if flag.NArg() != 2 {
+		return fmt.Errorf("usage: goclangast-query [flags] <selector> <file> [-- clang args]")
+	}
+
+	q, err := query.Compile(flag.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	tu, err := load(flag.Arg(1), *ast, *clang, clangArgs)
+	if err != nil {
+		return err
+	}
+
+	matches := q.All(tu)
+	if *count {
+		fmt.Println(len(matches))
+		return nil
+	}
+
+	for _, n := range matches {
+		fmt.Println(describe(n))
+	}
+
+	return nil
+}
+
+func load(path string, ast bool, clang string, clangArgs []string) (*goclangast.TranslationUnitDecl, error) {
+	if !ast {
+		tu, diags, err := goclangast.NewASTContext(context.Background(), path, goclangast.Options{
+			ClangPath: clang,
+			Args:      clangArgs,
+		})
+		for _, d := range diags {
+			if d.Severity == goclangast.SeverityError || d.Severity == goclangast.SeverityFatalError {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", d.Severity, d.Message)
+			}
+		}
+		return tu, err
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	return goclangast.ParseTUReader(r)
+}
+
+// describe formats the location, kind and name of a node.
+func describe(n goclangast.Node) string {
+	bn := n.GetBaseNode()
+
+	s := bn.Kind
+	if name := nodeName(n); name != "" {
+		s += " " + name
+	}
+
+	if l := bn.Loc; l != nil && l.File != "" {
+		s = fmt.Sprintf("%s:%d:%d: %s", l.File, l.Line, l.Col, s)
+	} else if r := bn.Range; r != nil && r.Begin != nil && r.Begin.File != "" {
+		s = fmt.Sprintf("%s:%d:%d: %s", r.Begin.File, r.Begin.Line, r.Begin.Col, s)
+	}
+
+	return s
+}
+
+func nodeName(n goclangast.Node) string {
+	switch n := n.(type) {
+	case *goclangast.FunctionDecl:
+		return n.Name
+	case *goclangast.VarDecl:
+		return n.Name
+	case *goclangast.ParmVarDecl:
+		return n.Name
+	case *goclangast.FieldDecl:
+		return n.Name
+	case *goclangast.RecordDecl:
+		return n.Name
+	case *goclangast.TypedefDecl:
+		return n.Name
+	case *goclangast.EnumDecl:
+		return n.Name
+	case *goclangast.EnumConstantDecl:
+		return n.Name
+	case *goclangast.DeclRefExpr:
+		return n.ReferencedDecl.Name
+	case *goclangast.CallExpr:
+		if d := n.CalleeDecl(); d != nil {
+			return d.Name
+		}
+	}
+	return ""
+}
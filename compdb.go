@@ -0,0 +1,215 @@
+package goclangast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CompileCommand is a single entry of a compile_commands.json compilation database.
+type CompileCommand struct {
+	Directory string   `json:"directory"`
+	File      string   `json:"file"`
+	Arguments []string `json:"arguments"`
+	Command   string   `json:"command"`
+	Output    string   `json:"output"`
+}
+
+func LoadCompilationDatabase(path string) ([]CompileCommand, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cmds []CompileCommand
+	err = json.Unmarshal(b, &cmds)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+
+	return cmds, nil
+}
+
+// Path returns the absolute path of the source file of the command.
+func (c CompileCommand) Path() string {
+	if filepath.IsAbs(c.File) {
+		return filepath.Clean(c.File)
+	}
+
+	return filepath.Join(c.Directory, c.File)
+}
+
+// Args returns the compiler arguments of the command without the compiler itself, the source file and any flags
+// which produce output, so they can be used as Options.Args.
+func (c CompileCommand) Args() ([]string, error) {
+	args := c.Arguments
+	if len(args) == 0 {
+		var err error
+		args, err = splitCommand(c.Command)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command for %s", c.File)
+	}
+
+	path := c.Path()
+	var out []string
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch arg {
+		case "-c", "-M", "-MM", "-MD", "-MMD", "-MP", "-MG":
+			continue
+		case "-o", "-MF", "-MT", "-MQ", "-MJ":
+			i++
+			continue
+		}
+
+		if isGluedOutputFlag(arg) ||
+			strings.HasPrefix(arg, "-MF") ||
+			strings.HasPrefix(arg, "-MJ") ||
+			strings.HasPrefix(arg, "-MT") ||
+			strings.HasPrefix(arg, "-MQ") ||
+			strings.HasPrefix(arg, "-Wp,-MD") ||
+			strings.HasPrefix(arg, "-Wp,-MMD") {
+			continue
+		}
+
+		if !strings.HasPrefix(arg, "-") {
+			argPath := arg
+			if !filepath.IsAbs(argPath) {
+				argPath = filepath.Join(c.Directory, argPath)
+			}
+			if filepath.Clean(argPath) == path {
+				continue
+			}
+		}
+
+		out = append(out, arg)
+	}
+
+	return out, nil
+}
+
+// outputLikeFlags are the clang flags starting with -o which aren't -o followed by the path of the output file.
+var outputLikeFlags = []string{"-objcmt-", "-object", "-objc-isystem", "-objcxx-isystem"}
+
+// isGluedOutputFlag reports whether arg is -o with the path of the output file in the same argument, such as
+// -obuild/main.o.
+func isGluedOutputFlag(arg string) bool {
+	if len(arg) <= len("-o") || !strings.HasPrefix(arg, "-o") {
+		return false
+	}
+
+	for _, prefix := range outputLikeFlags {
+		if strings.HasPrefix(arg, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// Options returns a copy of base with the arguments and working directory of the command.
+func (c CompileCommand) Options(base Options) (Options, error) {
+	args, err := c.Args()
+	if err != nil {
+		return base, err
+	}
+
+	base.Args = append(append([]string(nil), base.Args...), args...)
+	base.Dir = c.Directory
+	return base, nil
+}
+
+// splitCommand splits a shell command line into arguments, handling quotes and backslash escapes.
+func splitCommand(cmd string) ([]string, error) {
+	var (
+		args    []string
+		cur     strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range cmd {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in command: %s", cmd)
+	}
+
+	if inArg {
+		args = append(args, cur.String())
+	}
+
+	return args, nil
+}
+
+type CompileUnit struct {
+	Command     CompileCommand
+	TU          *TranslationUnitDecl
+	Diagnostics []Diagnostic
+	Err         error
+}
+
+// NewASTCompilationDatabase builds an AST for every entry of the compile_commands.json at dbPath. Failures of
+// individual entries are reported in CompileUnit.Err, an error is only returned if the database could not be loaded or
+// ctx is done.
+func NewASTCompilationDatabase(ctx context.Context, dbPath string, opts Options) ([]CompileUnit, error) {
+	cmds, err := LoadCompilationDatabase(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	units := make([]CompileUnit, 0, len(cmds))
+	for _, cmd := range cmds {
+		unit := CompileUnit{Command: cmd}
+
+		cmdOpts, err := cmd.Options(opts)
+		if err != nil {
+			unit.Err = err
+			units = append(units, unit)
+			continue
+		}
+
+		unit.TU, unit.Diagnostics, unit.Err = NewASTContext(ctx, cmd.Path(), cmdOpts)
+		units = append(units, unit)
+
+		if ctx.Err() != nil {
+			return units, ctx.Err()
+		}
+	}
+
+	return units, nil
+}
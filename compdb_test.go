@@ -0,0 +1,53 @@
+package goclangast
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompileCommandArgs(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		cmd  CompileCommand
+		want []string
+	}{
+		{
+			name: "separate output",
+			cmd: CompileCommand{
+				Directory: "/src",
+				File:      "main.c",
+				Arguments: []string{"cc", "-Iinclude", "-c", "-o", "build/main.o", "main.c"},
+			},
+			want: []string{"-Iinclude"},
+		},
+		{
+			name: "glued output",
+			cmd: CompileCommand{
+				Directory: "/src",
+				File:      "/src/main.c",
+				Command:   `cc -DNAME="a b" -obuild/main.o -MD -MFbuild/main.d -MJ build/main.json -c main.c`,
+			},
+			want: []string{"-DNAME=a b"},
+		},
+		{
+			name: "flags starting with -o",
+			cmd: CompileCommand{
+				Directory: "/src",
+				File:      "main.m",
+				Arguments: []string{"clang", "-objcmt-migrate-literals", "-objc-isystem", "/objc", "-MJbuild/main.json",
+					"-o", "main.o", "main.m"},
+			},
+			want: []string{"-objcmt-migrate-literals", "-objc-isystem", "/objc"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cmd.Args()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got args %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,1101 @@
+package goclangast
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type CTypeKind int
+
+const (
+	CTypeInvalid CTypeKind = iota
+	CTypeBuiltin
+	CTypePointer
+	CTypeReference
+	CTypeArray
+	CTypeFunction
+	CTypeRecord
+	CTypeEnum
+	CTypeTypedef
+)
+
+func (k CTypeKind) String() string {
+	switch k {
+	case CTypeBuiltin:
+		return "builtin"
+	case CTypePointer:
+		return "pointer"
+	case CTypeReference:
+		return "reference"
+	case CTypeArray:
+		return "array"
+	case CTypeFunction:
+		return "function"
+	case CTypeRecord:
+		return "record"
+	case CTypeEnum:
+		return "enum"
+	case CTypeTypedef:
+		return "typedef"
+	}
+
+	return "invalid"
+}
+
+type Qualifiers uint8
+
+const (
+	QualConst Qualifiers = 1 << iota
+	QualVolatile
+	QualRestrict
+	QualAtomic
+)
+
+func (q Qualifiers) String() string {
+	var parts []string
+	if q&QualConst != 0 {
+		parts = append(parts, "const")
+	}
+	if q&QualVolatile != 0 {
+		parts = append(parts, "volatile")
+	}
+	if q&QualRestrict != 0 {
+		parts = append(parts, "restrict")
+	}
+	if q&QualAtomic != 0 {
+		parts = append(parts, "_Atomic")
+	}
+	return strings.Join(parts, " ")
+}
+
+// CType is a structured representation of a C type.
+type CType struct {
+	Kind       CTypeKind
+	Qualifiers Qualifiers
+
+	// Name is the spelling of a builtin type, the tag name of a record or enum or the name of a typedef. For anonymous
+	// records and enums it holds clang's description, for example "(unnamed struct at a.c:3:5)".
+	Name string
+	// Tag is "struct" or "union" for records and "enum" for enums.
+	Tag       string
+	Anonymous bool
+	// Bits is the width of _BitInt(N) types, 0 for other types and for widths which depend on a template parameter.
+	Bits int
+	// AddressSpace is the address space set by __attribute__((address_space(N))), 0 is the default address space.
+	AddressSpace int
+	// Decl is the declaration of a record, enum or typedef type built by CTypeFromNode. It is nil for types parsed
+	// from their spelling, which can only be matched to their declaration by Key.
+	Decl Node
+
+	// Elem is the pointee type of pointers and references, the element type of arrays and the return type of
+	// functions.
+	Elem *CType
+	// Size is the number of elements of an array, -1 if the array is incomplete or variable length.
+	Size int
+
+	Params   []*CType
+	Variadic bool
+	// NoProto is set for functions declared without a prototype, such as "int ()".
+	NoProto bool
+}
+
+func (t *CType) IsConst() bool {
+	return t.Qualifiers&QualConst != 0
+}
+
+func (t *CType) IsVolatile() bool {
+	return t.Qualifiers&QualVolatile != 0
+}
+
+func (t *CType) IsRestrict() bool {
+	return t.Qualifiers&QualRestrict != 0
+}
+
+// Unqualified returns a copy of t without qualifiers and address space.
+func (t *CType) Unqualified() *CType {
+	c := *t
+	c.Qualifiers = 0
+	c.AddressSpace = 0
+	return &c
+}
+
+// qualifiers returns the qualifiers of t as spelled by clang, including the address space.
+func (t *CType) qualifiers() string {
+	s := t.Qualifiers.String()
+	if t.AddressSpace != 0 {
+		if s != "" {
+			s += " "
+		}
+		s += fmt.Sprintf("__attribute__((address_space(%d)))", t.AddressSpace)
+	}
+	return s
+}
+
+// CanonicalName returns the name of a builtin type as clang spells it canonically, for example "unsigned long" for
+// "long unsigned int" and "int" for "signed". It returns Name for other types.
+func (t *CType) CanonicalName() string {
+	if t.Kind != CTypeBuiltin {
+		return t.Name
+	}
+
+	var (
+		words    []string
+		unsigned bool
+		signed   bool
+	)
+	for _, w := range strings.Fields(t.Name) {
+		switch w {
+		case "unsigned":
+			unsigned = true
+		case "signed":
+			signed = true
+		case "int":
+		default:
+			words = append(words, w)
+		}
+	}
+
+	if len(words) == 0 {
+		words = []string{"int"}
+	}
+
+	s := strings.Join(words, " ")
+	switch {
+	case unsigned:
+		s = "unsigned " + s
+	case signed && s == "char":
+		s = "signed char"
+	}
+	return s
+}
+
+// Key identifies a record, enum or typedef type. It is the spelling of the type, such as "struct foo" or
+// "typedef foo_t", or for anonymous records and enums the location clang describes them by, such as "a.c:3:5". Key
+// is empty for other types. The Key methods of RecordDecl, EnumDecl and TypedefDecl return the key of the type they
+// declare.
+func (t *CType) Key() string {
+	if t.Anonymous {
+		if loc, ok := anonLoc(t.Name); ok {
+			return loc
+		}
+		return t.Name
+	}
+
+	switch t.Kind {
+	case CTypeRecord, CTypeEnum:
+		return t.Tag + " " + t.Name
+	case CTypeTypedef:
+		return "typedef " + t.Name
+	}
+	return ""
+}
+
+// Key returns the key of the record type, see CType.Key.
+func (d *RecordDecl) Key() string {
+	if d.Name == "" {
+		return declLoc(&d.BaseNode)
+	}
+	return d.TagUsed + " " + d.Name
+}
+
+// Key returns the key of the enum type, see CType.Key.
+func (d *EnumDecl) Key() string {
+	if d.Name == "" {
+		return declLoc(&d.BaseNode)
+	}
+	return "enum " + d.Name
+}
+
+// Key returns the key of the typedef type, see CType.Key.
+func (d *TypedefDecl) Key() string {
+	return "typedef " + d.Name
+}
+
+var anonLocRegex = regexp.MustCompile(`at (.+):(\d+):(\d+)\)$`)
+
+// anonLoc returns the location in clang's description of an anonymous record or enum, "a.c:3:5" for
+// "(unnamed struct at a.c:3:5)".
+func anonLoc(s string) (string, bool) {
+	m := anonLocRegex.FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+	return fmt.Sprintf("%s:%s:%s", m[1], m[2], m[3]), true
+}
+
+// declLoc returns the location clang uses to describe an anonymous declaration, the file, line and column of the
+// declaration, or of the macro expansion it was declared in.
+func declLoc(bn *BaseNode) string {
+	l := bn.Loc
+	if l == nil {
+		return ""
+	}
+
+	file, line, col := l.File, l.Line, l.Col
+	if l.PresumedFile != "" {
+		file = l.PresumedFile
+	}
+
+	// The file and line of the expansion location are elided when they equal those of the spelling location, which in
+	// turn are elided when they equal those of the location before.
+	if e := l.ExpansionLoc; e != nil {
+		col = e.Col
+		if sp := l.SpellingLoc; sp != nil {
+			if sp.File != "" {
+				file = sp.File
+			}
+			if sp.Line != 0 {
+				line = sp.Line
+			}
+		}
+		if e.File != "" {
+			file = e.File
+		}
+		if e.PresumedFile != "" {
+			file = e.PresumedFile
+		}
+		if e.Line != 0 {
+			line = e.Line
+		}
+	}
+
+	return fmt.Sprintf("%s:%d:%d", file, line, col)
+}
+
+func (t *CType) String() string {
+	return t.format("")
+}
+
+func (t *CType) format(inner string) string {
+	switch t.Kind {
+	case CTypePointer, CTypeReference:
+		s := "*"
+		if t.Kind == CTypeReference {
+			s = "&"
+		}
+		if q := t.qualifiers(); q != "" {
+			s += q
+			if inner != "" {
+				s += " "
+			}
+		}
+		s += inner
+		if t.Elem.Kind == CTypeArray || t.Elem.Kind == CTypeFunction {
+			s = "(" + s + ")"
+		}
+		return t.Elem.format(s)
+
+	case CTypeArray:
+		size := ""
+		if t.Size >= 0 {
+			size = strconv.Itoa(t.Size)
+		}
+		return t.Elem.format(inner + "[" + size + "]")
+
+	case CTypeFunction:
+		var params []string
+		for _, p := range t.Params {
+			params = append(params, p.String())
+		}
+		if t.Variadic {
+			params = append(params, "...")
+		}
+		if len(params) == 0 && !t.NoProto {
+			params = append(params, "void")
+		}
+		return t.Elem.format(inner + "(" + strings.Join(params, ", ") + ")")
+	}
+
+	s := t.Name
+	if t.Tag != "" && !t.Anonymous {
+		s = t.Tag + " " + s
+	}
+	if q := t.qualifiers(); q != "" {
+		s = q + " " + s
+	}
+	if inner != "" {
+		s += " " + inner
+	}
+	return s
+}
+
+// CType parses the qualified type.
+func (t Type) CType() (*CType, error) {
+	return ParseCType(t.QualType)
+}
+
+// DesugaredCType parses the desugared type, or the qualified type if it has no sugar.
+func (t Type) DesugaredCType() (*CType, error) {
+	if t.DesugaredQualType != "" {
+		return ParseCType(t.DesugaredQualType)
+	}
+
+	return ParseCType(t.QualType)
+}
+
+func (e *Expr) CType() (*CType, error) {
+	return e.Type.CType()
+}
+
+func (o *Operator) CType() (*CType, error) {
+	return o.Type.CType()
+}
+
+func (d *VarDecl) CType() (*CType, error) {
+	return d.Type.CType()
+}
+
+func (d *FieldDecl) CType() (*CType, error) {
+	return d.Type.CType()
+}
+
+func (d *ParmVarDecl) CType() (*CType, error) {
+	return d.Type.CType()
+}
+
+func (d *FunctionDecl) CType() (*CType, error) {
+	return d.Type.CType()
+}
+
+// ParseCType parses a type as spelled by clang in the qualType fields of the AST, for example "const struct foo *".
+func ParseCType(s string) (*CType, error) {
+	p := ctypeParser{src: s}
+	p.toks, p.offs = tokenizeCType(s)
+	t, err := p.typeName()
+	if err != nil {
+		return nil, fmt.Errorf("parse type '%s': %w", s, err)
+	}
+
+	if !p.eof() {
+		return nil, fmt.Errorf("parse type '%s': unexpected '%s'", s, p.peek())
+	}
+
+	return t, nil
+}
+
+// CTypeFromNode builds a CType from one of the type nodes in TypeMap, as found in the inner nodes of a TypedefDecl.
+func CTypeFromNode(n Node) (*CType, error) {
+	child := func() (*CType, error) {
+		children := n.Children()
+		if len(children) == 0 {
+			return nil, fmt.Errorf("%s without inner type", n.GetBaseNode().Kind)
+		}
+		return CTypeFromNode(children[0])
+	}
+
+	switch n := n.(type) {
+	case *BuiltinType:
+		return &CType{Kind: CTypeBuiltin, Name: n.Type.QualType}, nil
+
+	case *PointerType:
+		elem, err := child()
+		if err != nil {
+			return nil, err
+		}
+		return &CType{Kind: CTypePointer, Elem: elem}, nil
+
+	case *ConstantArrayType:
+		elem, err := child()
+		if err != nil {
+			return nil, err
+		}
+		return &CType{Kind: CTypeArray, Elem: elem, Size: n.Size}, nil
+
+	case *IncompleteArrayType, *VariableArrayType:
+		elem, err := child()
+		if err != nil {
+			return nil, err
+		}
+		return &CType{Kind: CTypeArray, Elem: elem, Size: -1}, nil
+
+	case *DecayedType:
+		t, err := child()
+		if err != nil {
+			return nil, err
+		}
+		if t.Kind != CTypeArray {
+			return &CType{Kind: CTypePointer, Elem: t}, nil
+		}
+
+		// The qualifiers of array parameters declared as "a[const 4]" qualify the pointer.
+		p := &CType{Kind: CTypePointer, Elem: t.Elem}
+		if a := asArrayType(n.OriginalType()); a != nil && a.IndexTypeQualifiers != "" {
+			q, err := ParseCType(a.IndexTypeQualifiers + " int")
+			if err != nil {
+				return nil, err
+			}
+			p.Qualifiers = q.Qualifiers
+		}
+		return p, nil
+
+	case *FunctionNoProtoType:
+		ret, err := child()
+		if err != nil {
+			return nil, err
+		}
+		return &CType{Kind: CTypeFunction, Elem: ret, NoProto: true}, nil
+
+	case *AtomicType:
+		t, err := child()
+		if err != nil {
+			return nil, err
+		}
+		t = cloneCType(t)
+		t.Qualifiers |= QualAtomic
+		return t, nil
+
+	case *TypeOfExprType:
+		if n.Type.DesugaredQualType != "" {
+			return ParseCType(n.Type.DesugaredQualType)
+		}
+		// Without a desugared type the underlying type is the type of the expression.
+		if e, ok := n.Expr().(interface{ valueType() Type }); ok {
+			return e.valueType().DesugaredCType()
+		}
+		return ParseCType(n.Type.QualType)
+
+	case *ComplexType:
+		return &CType{Kind: CTypeBuiltin, Name: n.Type.QualType}, nil
+
+	case *BitIntType:
+		return ParseCType(n.Type.QualType)
+
+	case *FunctionProtoType:
+		var types []*CType
+		for _, c := range n.Children() {
+			t, err := CTypeFromNode(c)
+			if err != nil {
+				return nil, err
+			}
+			types = append(types, t)
+		}
+		if len(types) == 0 {
+			return nil, fmt.Errorf("FunctionProtoType without return type")
+		}
+		return &CType{Kind: CTypeFunction, Elem: types[0], Params: types[1:], Variadic: n.Variadic}, nil
+
+	case *ParenType, *ElaboratedType, *AttributedType, *MacroQualifiedType, *BTFTagAttributedType:
+		return child()
+
+	case *QualType:
+		t, err := child()
+		if err != nil {
+			return nil, err
+		}
+		q, err := ParseCType(n.Qualifiers + " int")
+		if err != nil {
+			return nil, err
+		}
+		t = cloneCType(t)
+		t.Qualifiers |= q.Qualifiers
+		if q.AddressSpace != 0 {
+			t.AddressSpace = q.AddressSpace
+		}
+		return t, nil
+
+	case *TypedefType:
+		t := &CType{Kind: CTypeTypedef, Name: n.Decl.Name}
+		if d := n.TypedefDecl(); d != nil {
+			t.Decl = d
+		}
+		return t, nil
+
+	// The spelling of record and enum types carries the tag and the description of anonymous types.
+	case *RecordType:
+		t, err := ParseCType(n.Type.QualType)
+		if err != nil {
+			return nil, err
+		}
+		if d := n.RecordDecl(); d != nil {
+			t.Decl = d
+		}
+		return t, nil
+
+	case *EnumType:
+		t, err := ParseCType(n.Type.QualType)
+		if err != nil {
+			return nil, err
+		}
+		if d := n.EnumDecl(); d != nil {
+			t.Decl = d
+		}
+		return t, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type node %s", n.GetBaseNode().Kind)
+}
+
+func cloneCType(t *CType) *CType {
+	c := *t
+	return &c
+}
+
+var ctypeBuiltins = map[string]bool{
+	"void": true, "char": true, "short": true, "int": true, "long": true, "float": true, "double": true,
+	"signed": true, "unsigned": true, "_Bool": true, "bool": true, "_Complex": true, "__int128": true,
+	"_Float16": true, "__fp16": true, "__bf16": true, "_Float128": true, "__float128": true, "__ibm128": true,
+	"wchar_t": true, "char8_t": true, "char16_t": true, "char32_t": true, "_Accum": true, "_Fract": true,
+	"_Sat": true, "__int128_t": true, "__uint128_t": true,
+}
+
+var ctypeQualifiers = map[string]Qualifiers{
+	"const":        QualConst,
+	"volatile":     QualVolatile,
+	"restrict":     QualRestrict,
+	"__restrict":   QualRestrict,
+	"__restrict__": QualRestrict,
+	"_Atomic":      QualAtomic,
+}
+
+// ctypeIgnored are keywords which can appear in qualType strings but don't affect the shape of the type.
+var ctypeIgnored = map[string]bool{
+	"_Nonnull": true, "_Nullable": true, "_Null_unspecified": true, "_Nullable_result": true,
+	"__ptr32": true, "__ptr64": true, "__sptr": true, "__uptr": true, "__unaligned": true,
+}
+
+func tokenizeCType(s string) ([]string, []int) {
+	var (
+		toks []string
+		offs []int
+	)
+	for i := 0; i < len(s); {
+		c := s[i]
+		n := 1
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+			continue
+		case isIdentByte(c):
+			for i+n < len(s) && isIdentByte(s[i+n]) {
+				n++
+			}
+		case strings.HasPrefix(s[i:], "..."):
+			n = 3
+		case strings.HasPrefix(s[i:], "::"), strings.HasPrefix(s[i:], "&&"):
+			n = 2
+		}
+		toks = append(toks, s[i:i+n])
+		offs = append(offs, i)
+		i += n
+	}
+	return toks, offs
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+type ctypeParser struct {
+	src  string
+	toks []string
+	offs []int
+	pos  int
+}
+
+func (p *ctypeParser) eof() bool {
+	return p.pos >= len(p.toks)
+}
+
+func (p *ctypeParser) peek() string {
+	if p.eof() {
+		return ""
+	}
+	return p.toks[p.pos]
+}
+
+func (p *ctypeParser) peekAt(n int) string {
+	if p.pos+n >= len(p.toks) {
+		return ""
+	}
+	return p.toks[p.pos+n]
+}
+
+func (p *ctypeParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *ctypeParser) expect(tok string) error {
+	if p.peek() != tok {
+		if p.eof() {
+			return fmt.Errorf("expected '%s', got end of type", tok)
+		}
+		return fmt.Errorf("expected '%s', got '%s'", tok, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+// balanced consumes a token sequence enclosed by open and close, including nested pairs, and returns its source text.
+func (p *ctypeParser) balanced(open, close string) (string, error) {
+	start := p.pos
+	if err := p.expect(open); err != nil {
+		return "", err
+	}
+
+	for depth := 1; depth > 0; {
+		if p.eof() {
+			return "", fmt.Errorf("unbalanced '%s'", open)
+		}
+		switch p.next() {
+		case open:
+			depth++
+		case close:
+			depth--
+		}
+	}
+
+	last := p.pos - 1
+	return p.src[p.offs[start] : p.offs[last]+len(p.toks[last])], nil
+}
+
+// attribute parses a GNU attribute such as __attribute__((aligned(8))). Only the address space affects the type, it
+// is returned for __attribute__((address_space(N))) and 0 is returned for other attributes.
+func (p *ctypeParser) attribute() (int, error) {
+	p.next()
+	start := p.pos
+	if _, err := p.balanced("(", ")"); err != nil {
+		return 0, err
+	}
+
+	toks := p.toks[start:p.pos]
+	for i, tok := range toks {
+		if tok != "address_space" && tok != "__address_space__" {
+			continue
+		}
+		if i+3 >= len(toks) || toks[i+1] != "(" || toks[i+3] != ")" {
+			return 0, fmt.Errorf("unsupported address space")
+		}
+		as, err := strconv.Atoi(toks[i+2])
+		if err != nil {
+			return 0, fmt.Errorf("unsupported address space '%s'", toks[i+2])
+		}
+		return as, nil
+	}
+
+	return 0, nil
+}
+
+// typeName parses specifiers and qualifiers followed by an abstract declarator.
+func (p *ctypeParser) typeName() (*CType, error) {
+	base, err := p.specifiers()
+	if err != nil {
+		return nil, err
+	}
+
+	decl, err := p.declarator()
+	if err != nil {
+		return nil, err
+	}
+
+	return decl(base), nil
+}
+
+func (p *ctypeParser) specifiers() (*CType, error) {
+	var (
+		t         *CType
+		quals     Qualifiers
+		addrSpace int
+		bits      int
+		builtins  []string
+	)
+
+	for !p.eof() {
+		tok := p.peek()
+
+		if q, ok := ctypeQualifiers[tok]; ok {
+			p.next()
+			if tok == "_Atomic" && p.peek() == "(" {
+				p.next()
+				inner, err := p.typeName()
+				if err != nil {
+					return nil, err
+				}
+				if err = p.expect(")"); err != nil {
+					return nil, err
+				}
+				t = inner
+			}
+			quals |= q
+			continue
+		}
+
+		if ctypeIgnored[tok] {
+			p.next()
+			continue
+		}
+
+		if tok == "__attribute__" || tok == "__attribute" {
+			as, err := p.attribute()
+			if err != nil {
+				return nil, err
+			}
+			if as != 0 {
+				addrSpace = as
+			}
+			continue
+		}
+
+		// _BitInt(N), spelled _ExtInt(N) by older versions of clang.
+		if (tok == "_BitInt" || tok == "_ExtInt") && p.peekAt(1) == "(" {
+			p.next()
+			width, err := p.balanced("(", ")")
+			if err != nil {
+				return nil, err
+			}
+			// The width isn't known if it depends on a template parameter, as in "_BitInt(N)".
+			if n, err := strconv.Atoi(width[1 : len(width)-1]); err == nil {
+				if n <= 0 {
+					return nil, fmt.Errorf("invalid width %s", width)
+				}
+				bits = n
+			}
+			builtins = append(builtins, tok+width)
+			continue
+		}
+
+		if ctypeBuiltins[tok] {
+			builtins = append(builtins, p.next())
+			continue
+		}
+
+		if t != nil || len(builtins) > 0 {
+			break
+		}
+
+		switch tok {
+		case "struct", "union", "class", "enum":
+			p.next()
+			rec, err := p.tagged(tok)
+			if err != nil {
+				return nil, err
+			}
+			t = rec
+			continue
+
+		case "(":
+			// Anonymous records spelled without tag keyword, "(unnamed struct at a.c:3:5)".
+			next := p.peekAt(1)
+			if next != "unnamed" && next != "anonymous" {
+				return nil, fmt.Errorf("unexpected '('")
+			}
+			rec, err := p.tagged("")
+			if err != nil {
+				return nil, err
+			}
+			t = rec
+			continue
+
+		case "typeof", "__typeof__", "__typeof", "decltype":
+			p.next()
+			raw, err := p.balanced("(", ")")
+			if err != nil {
+				return nil, err
+			}
+			t = &CType{Kind: CTypeTypedef, Name: tok + raw}
+			continue
+		}
+
+		if isIdentByte(tok[0]) || tok == "::" {
+			name, err := p.qualifiedName()
+			if err != nil {
+				return nil, err
+			}
+			t = &CType{Kind: CTypeTypedef, Name: name}
+			continue
+		}
+
+		break
+	}
+
+	if len(builtins) > 0 {
+		t = &CType{Kind: CTypeBuiltin, Name: strings.Join(builtins, " "), Bits: bits}
+	}
+
+	if t == nil {
+		if quals == 0 {
+			return nil, fmt.Errorf("missing type specifier")
+		}
+		// Qualifiers without type, such as "const", imply int.
+		t = &CType{Kind: CTypeBuiltin, Name: "int"}
+	}
+
+	t.Qualifiers |= quals
+	if addrSpace != 0 {
+		t.AddressSpace = addrSpace
+	}
+	return t, nil
+}
+
+func (p *ctypeParser) tagged(tag string) (*CType, error) {
+	kind := CTypeRecord
+	if tag == "enum" {
+		kind = CTypeEnum
+	}
+
+	if p.peek() == "(" {
+		raw, err := p.balanced("(", ")")
+		if err != nil {
+			return nil, err
+		}
+
+		if tag == "" {
+			switch {
+			case strings.Contains(raw, " union "):
+				tag = "union"
+			case strings.Contains(raw, " enum "):
+				tag, kind = "enum", CTypeEnum
+			default:
+				tag = "struct"
+			}
+		}
+
+		return &CType{Kind: kind, Tag: tag, Name: raw, Anonymous: true}, nil
+	}
+
+	name, err := p.qualifiedName()
+	if err != nil {
+		return nil, err
+	}
+
+	return &CType{Kind: kind, Tag: tag, Name: name}, nil
+}
+
+// qualifiedName parses an identifier, including C++ scopes and template arguments.
+func (p *ctypeParser) qualifiedName() (string, error) {
+	var sb strings.Builder
+	for {
+		if p.peek() == "::" {
+			sb.WriteString(p.next())
+		}
+
+		tok := p.peek()
+		if tok == "" || !isIdentByte(tok[0]) {
+			return "", fmt.Errorf("expected identifier, got '%s'", tok)
+		}
+		sb.WriteString(p.next())
+
+		if p.peek() == "<" {
+			args, err := p.balanced("<", ">")
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(args)
+		}
+
+		if p.peek() != "::" {
+			return sb.String(), nil
+		}
+	}
+}
+
+// declarator parses an abstract declarator and returns a function which applies it to the base type. Pointers bind
+// to the base type first, then array and function suffixes, and finally any parenthesized inner declarator.
+func (p *ctypeParser) declarator() (func(*CType) *CType, error) {
+	type ptr struct {
+		kind      CTypeKind
+		quals     Qualifiers
+		addrSpace int
+	}
+	var (
+		ptrs      []ptr
+		addrSpace int
+	)
+
+	for {
+		tok := p.peek()
+		var kind CTypeKind
+		switch tok {
+		case "*", "^":
+			kind = CTypePointer
+		case "&", "&&":
+			kind = CTypeReference
+		case "__attribute__", "__attribute":
+			// An address space after a pointer applies to the pointer, "int *__attribute__((address_space(1)))".
+			as, err := p.attribute()
+			if err != nil {
+				return nil, err
+			}
+			if as != 0 {
+				if len(ptrs) == 0 {
+					addrSpace = as
+				} else {
+					ptrs[len(ptrs)-1].addrSpace = as
+				}
+			}
+			continue
+		default:
+			if ctypeIgnored[tok] {
+				p.next()
+				continue
+			}
+		}
+		if kind == CTypeInvalid {
+			break
+		}
+		p.next()
+
+		var quals Qualifiers
+		for {
+			if q, ok := ctypeQualifiers[p.peek()]; ok {
+				quals |= q
+				p.next()
+				continue
+			}
+			if ctypeIgnored[p.peek()] {
+				p.next()
+				continue
+			}
+			break
+		}
+		ptrs = append(ptrs, ptr{kind: kind, quals: quals})
+	}
+
+	inner := func(t *CType) *CType { return t }
+	if p.peek() == "(" {
+		switch p.peekAt(1) {
+		case "*", "^", "&", "&&", "(", "__attribute__":
+			p.next()
+			var err error
+			inner, err = p.declarator()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var suffixes []func(*CType) *CType
+	for {
+		switch p.peek() {
+		case "[":
+			p.next()
+			size := -1
+			if p.peek() != "]" {
+				// Variable length arrays carry the size expression, which is skipped.
+				var expr []string
+				for depth := 0; !p.eof() && (depth > 0 || p.peek() != "]"); {
+					tok := p.next()
+					switch tok {
+					case "[":
+						depth++
+					case "]":
+						depth--
+					}
+					expr = append(expr, tok)
+				}
+				if len(expr) == 1 {
+					if n, err := strconv.Atoi(expr[0]); err == nil {
+						size = n
+					}
+				}
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			suffixes = append(suffixes, func(t *CType) *CType {
+				return &CType{Kind: CTypeArray, Elem: t, Size: size}
+			})
+			continue
+
+		case "(":
+			fn, err := p.params()
+			if err != nil {
+				return nil, err
+			}
+			suffixes = append(suffixes, func(t *CType) *CType {
+				f := cloneCType(fn)
+				f.Elem = t
+				return f
+			})
+			continue
+
+		case "__attribute__", "__attribute":
+			as, err := p.attribute()
+			if err != nil {
+				return nil, err
+			}
+			if as != 0 {
+				return nil, fmt.Errorf("address space on array or function type")
+			}
+			continue
+		}
+		break
+	}
+
+	return func(t *CType) *CType {
+		if addrSpace != 0 {
+			t = cloneCType(t)
+			t.AddressSpace = addrSpace
+		}
+		for _, ptr := range ptrs {
+			t = &CType{Kind: ptr.kind, Qualifiers: ptr.quals, AddressSpace: ptr.addrSpace, Elem: t}
+		}
+		for i := len(suffixes) - 1; i >= 0; i-- {
+			t = suffixes[i](t)
+		}
+		return inner(t)
+	}, nil
+}
+
+// params parses a function parameter list, the return type is filled in by the caller.
+func (p *ctypeParser) params() (*CType, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	fn := &CType{Kind: CTypeFunction}
+	if p.peek() == ")" {
+		p.next()
+		fn.NoProto = true
+		return fn, nil
+	}
+
+	if p.peek() == "void" && p.peekAt(1) == ")" {
+		p.pos += 2
+		return fn, nil
+	}
+
+	for {
+		if p.peek() == "..." {
+			p.next()
+			fn.Variadic = true
+		} else {
+			param, err := p.typeName()
+			if err != nil {
+				return nil, err
+			}
+			fn.Params = append(fn.Params, param)
+		}
+
+		if p.peek() == "," {
+			p.next()
+			continue
+		}
+		break
+	}
+
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	// Trailing C++ method qualifiers, such as "void () const".
+	for {
+		if _, ok := ctypeQualifiers[p.peek()]; ok {
+			p.next()
+			continue
+		}
+		if p.peek() == "noexcept" || p.peek() == "&" || p.peek() == "&&" {
+			p.next()
+			continue
+		}
+		break
+	}
+
+	return fn, nil
+}
@@ -0,0 +1,192 @@
+package goclangast
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseCType parses types as spelled by clang in qualType fields and checks the structure and the spelling of
+// the result.
+func TestParseCType(t *testing.T) {
+	intT := &CType{Kind: CTypeBuiltin, Name: "int"}
+	charT := &CType{Kind: CTypeBuiltin, Name: "char"}
+	ptr := func(elem *CType) *CType { return &CType{Kind: CTypePointer, Elem: elem} }
+	array := func(elem *CType, size int) *CType { return &CType{Kind: CTypeArray, Elem: elem, Size: size} }
+	fn := func(ret *CType, params ...*CType) *CType {
+		return &CType{Kind: CTypeFunction, Elem: ret, Params: params}
+	}
+
+	for _, tt := range []struct {
+		in   string
+		want *CType
+		// str is the expected spelling of the result if it differs from the input.
+		str string
+	}{
+		{in: "int", want: intT},
+		{in: "const char *", want: ptr(&CType{Kind: CTypeBuiltin, Name: "char", Qualifiers: QualConst})},
+		{in: "char *const", want: &CType{Kind: CTypePointer, Qualifiers: QualConst, Elem: charT}},
+		{in: "unsigned long long", want: &CType{Kind: CTypeBuiltin, Name: "unsigned long long"}},
+		{in: "__int128 unsigned", want: &CType{Kind: CTypeBuiltin, Name: "__int128 unsigned"}},
+		{in: "_Complex double", want: &CType{Kind: CTypeBuiltin, Name: "_Complex double"}},
+		{in: "_Complex float *", want: ptr(&CType{Kind: CTypeBuiltin, Name: "_Complex float"})},
+		{in: "_BitInt(7)", want: &CType{Kind: CTypeBuiltin, Name: "_BitInt(7)", Bits: 7}},
+		{in: "unsigned _BitInt(128)", want: &CType{Kind: CTypeBuiltin, Name: "unsigned _BitInt(128)", Bits: 128}},
+		{in: "_ExtInt(24) *", want: ptr(&CType{Kind: CTypeBuiltin, Name: "_ExtInt(24)", Bits: 24})},
+		{in: "const _BitInt(3) [4]", want: array(&CType{
+			Kind: CTypeBuiltin, Name: "_BitInt(3)", Bits: 3, Qualifiers: QualConst,
+		}, 4)},
+		{in: "_BitInt(N)", want: &CType{Kind: CTypeBuiltin, Name: "_BitInt(N)"}},
+		{in: "struct foo *", want: ptr(&CType{Kind: CTypeRecord, Tag: "struct", Name: "foo"})},
+		{in: "enum color", want: &CType{Kind: CTypeEnum, Tag: "enum", Name: "color"}},
+		{in: "struct (unnamed struct at f.c:3:5)", want: &CType{
+			Kind: CTypeRecord, Tag: "struct", Name: "(unnamed struct at f.c:3:5)", Anonymous: true,
+		}, str: "(unnamed struct at f.c:3:5)"},
+		{in: "(unnamed union at /tmp/f.c:10:2) *", want: ptr(&CType{
+			Kind: CTypeRecord, Tag: "union", Name: "(unnamed union at /tmp/f.c:10:2)", Anonymous: true,
+		})},
+		{in: "enum (unnamed enum at f.c:1:1)", want: &CType{
+			Kind: CTypeEnum, Tag: "enum", Name: "(unnamed enum at f.c:1:1)", Anonymous: true,
+		}, str: "(unnamed enum at f.c:1:1)"},
+		{in: "typeof (x)", want: &CType{Kind: CTypeTypedef, Name: "typeof(x)"}, str: "typeof(x)"},
+		{in: "__typeof__(a + 1) *", want: ptr(&CType{Kind: CTypeTypedef, Name: "__typeof__(a + 1)"})},
+		{in: "int (*)(int, char *)", want: ptr(fn(intT, intT, ptr(charT)))},
+		{in: "void (*(*)(int))(void)", want: ptr(fn(ptr(fn(&CType{Kind: CTypeBuiltin, Name: "void"})), intT))},
+		{in: "int (*(*)[3])[4]", want: ptr(array(ptr(array(intT, 4)), 3))},
+		{in: "int *[5]", want: array(ptr(intT), 5)},
+		{in: "int [n + 1]", want: array(intT, -1), str: "int []"},
+		{in: "int [n][m * 2]", want: array(array(intT, -1), -1), str: "int [][]"},
+		{in: "int (*)[n + 1]", want: ptr(array(intT, -1)), str: "int (*)[]"},
+		{in: "int (int, ...)", want: &CType{Kind: CTypeFunction, Elem: intT, Params: []*CType{intT}, Variadic: true}},
+		{in: "int ()", want: &CType{Kind: CTypeFunction, Elem: intT, NoProto: true}},
+		{in: "int *__attribute__((btf_type_tag(\"user\")))", want: ptr(intT), str: "int *"},
+		{in: "int __attribute__((aligned(8)))", want: intT, str: "int"},
+		{in: "void (*)(void) __attribute__((noreturn))", want: ptr(fn(&CType{Kind: CTypeBuiltin, Name: "void"})),
+			str: "void (*)(void)"},
+		{in: "int *_Nonnull", want: ptr(intT), str: "int *"},
+		{in: "__attribute__((address_space(1))) int *", want: ptr(&CType{Kind: CTypeBuiltin, Name: "int", AddressSpace: 1})},
+		{in: "const __attribute__((address_space(3))) char *", want: ptr(&CType{
+			Kind: CTypeBuiltin, Name: "char", Qualifiers: QualConst, AddressSpace: 3,
+		})},
+		{in: "int *__attribute__((address_space(2)))", want: &CType{Kind: CTypePointer, Elem: intT, AddressSpace: 2}},
+		{in: "_Atomic(int) *", want: ptr(&CType{Kind: CTypeBuiltin, Name: "int", Qualifiers: QualAtomic}),
+			str: "_Atomic int *"},
+		{in: "ns::vector<int, 4> &", want: &CType{
+			Kind: CTypeReference, Elem: &CType{Kind: CTypeTypedef, Name: "ns::vector<int, 4>"},
+		}},
+	} {
+		got, err := ParseCType(tt.in)
+		if err != nil {
+			t.Errorf("ParseCType(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCType(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+
+		str := tt.str
+		if str == "" {
+			str = tt.in
+		}
+		if got.String() != str {
+			t.Errorf("ParseCType(%q).String() = %q, want %q", tt.in, got.String(), str)
+		}
+	}
+}
+
+func TestParseCTypeErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"int (",
+		"int [4",
+		"struct",
+		"_BitInt(0)",
+		"int [4] __attribute__((address_space(1)))",
+		"__attribute__((address_space(x))) int",
+		"int )",
+	} {
+		if got, err := ParseCType(in); err == nil {
+			t.Errorf("ParseCType(%q) = %s, want an error", in, got)
+		}
+	}
+}
+
+func TestCanonicalName(t *testing.T) {
+	for in, want := range map[string]string{
+		"int":                 "int",
+		"signed":              "int",
+		"unsigned":            "unsigned int",
+		"long unsigned int":   "unsigned long",
+		"long long int":       "long long",
+		"signed char":         "signed char",
+		"signed short":        "short",
+		"unsigned char":       "unsigned char",
+		"long double":         "long double",
+		"unsigned _BitInt(7)": "unsigned _BitInt(7)",
+		"const unsigned int":  "unsigned int",
+		"struct foo":          "foo",
+	} {
+		ct, err := ParseCType(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ct.CanonicalName(); got != want {
+			t.Errorf("CanonicalName of %q is %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCTypeKey(t *testing.T) {
+	for in, want := range map[string]string{
+		"struct foo":                      "struct foo",
+		"const union bar":                 "union bar",
+		"enum baz":                        "enum baz",
+		"foo_t":                           "typedef foo_t",
+		"struct (unnamed at a.c:3:5)":     "a.c:3:5",
+		"enum (unnamed enum at b.h:7:1)":  "b.h:7:1",
+		"(anonymous union at /x/c.c:1:2)": "/x/c.c:1:2",
+		"int":                             "",
+		"struct foo *":                    "",
+	} {
+		ct, err := ParseCType(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ct.Key(); got != want {
+			t.Errorf("Key of %q is %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestDeclKey checks that declarations have the key of the types referring to them, and that CTypeFromNode resolves
+// the declaration of the types it builds.
+func TestDeclKey(t *testing.T) {
+	tu := parseTestdata(t, "testdata/stmts/stmts.json")
+
+	point := tu.Inner[1].(*RecordDecl)
+	pointT := tu.Inner[2].(*TypedefDecl)
+	if point.Key() != "struct point" || pointT.Key() != "typedef point_t" {
+		t.Errorf("got keys %q and %q, want struct point and typedef point_t", point.Key(), pointT.Key())
+	}
+
+	ct, err := CTypeFromNode(pointT.Inner[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct.Decl != point || ct.Key() != point.Key() {
+		t.Errorf("the type of point_t has declaration %v and key %q, want struct point", ct.Decl, ct.Key())
+	}
+
+	// Types parsed from their spelling don't know their declaration.
+	ct, err = pointT.Type.CType()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct.Decl != nil || ct.Key() != point.Key() {
+		t.Errorf("the spelled type of point_t has declaration %v and key %q, want none and struct point", ct.Decl, ct.Key())
+	}
+
+	anon := &RecordDecl{BaseNode: BaseNode{Loc: &Loc{File: "a.c", Line: 3, Col: 5}}, TagUsed: "struct"}
+	if got := anon.Key(); got != "a.c:3:5" {
+		t.Errorf("anonymous struct has key %q, want a.c:3:5", got)
+	}
+}
@@ -0,0 +1,87 @@
+package goclangast
+
+import (
+	"bytes"
+	"testing"
+)
+
+// decodeTestJSON is the relevant part of the output of clang -Xclang -ast-dump=json -fsyntax-only
+// -fparse-all-comments for:
+//
+//	int x;
+//	/** \return the size of x */
+//	unsigned long f(void) { return sizeof x; }
+const decodeTestJSON = `{
+ "id": "0x1", "kind": "TranslationUnitDecl", "loc": {}, "range": {"begin": {}, "end": {}},
+ "inner": [
+  {"id": "0x2", "kind": "VarDecl", "loc": {"offset": 4, "file": "t.c", "line": 1, "col": 5, "tokLen": 1},
+   "range": {"begin": {"offset": 0, "col": 1, "tokLen": 3}, "end": {"offset": 4, "col": 5, "tokLen": 1}},
+   "name": "x", "type": {"qualType": "int"}},
+  {"id": "0x3", "kind": "FunctionDecl", "loc": {"offset": 50, "line": 3, "col": 15, "tokLen": 1},
+   "range": {"begin": {"offset": 36, "col": 1, "tokLen": 8}, "end": {"offset": 77, "col": 42, "tokLen": 1}},
+   "name": "f", "type": {"qualType": "unsigned long (void)"},
+   "inner": [
+    {"id": "0x4", "kind": "CompoundStmt",
+     "range": {"begin": {"offset": 58, "col": 23, "tokLen": 1}, "end": {"offset": 77, "col": 42, "tokLen": 1}},
+     "inner": [
+      {"id": "0x5", "kind": "ReturnStmt",
+       "range": {"begin": {"offset": 60, "col": 25, "tokLen": 6}, "end": {"offset": 74, "col": 39, "tokLen": 1}},
+       "inner": [
+        {"id": "0x6", "kind": "UnaryExprOrTypeTraitExpr",
+         "range": {"begin": {"offset": 67, "col": 32, "tokLen": 6}, "end": {"offset": 74, "col": 39, "tokLen": 1}},
+         "type": {"qualType": "unsigned long"}, "valueCategory": "prvalue", "name": "sizeof",
+         "inner": [
+          {"id": "0x7", "kind": "DeclRefExpr",
+           "range": {"begin": {"offset": 74, "col": 39, "tokLen": 1}, "end": {"offset": 74, "col": 39, "tokLen": 1}},
+           "type": {"qualType": "int"}, "valueCategory": "lvalue",
+           "referencedDecl": {"id": "0x2", "kind": "VarDecl", "name": "x", "type": {"qualType": "int"}},
+           "nonOdrUseReason": "unevaluated"}
+         ]}
+       ]}
+     ]},
+    {"id": "0x8", "kind": "FullComment", "loc": {"offset": 11, "line": 2, "col": 5, "tokLen": 1},
+     "range": {"begin": {"offset": 11, "col": 5, "tokLen": 1}, "end": {"offset": 32, "col": 26, "tokLen": 1}},
+     "inner": [
+      {"id": "0x9", "kind": "BlockCommandComment", "loc": {"offset": 11, "col": 5, "tokLen": 1},
+       "range": {"begin": {"offset": 10, "col": 4, "tokLen": 1}, "end": {"offset": 32, "col": 26, "tokLen": 1}},
+       "name": "return"}
+     ]}
+   ]}
+ ]
+}`
+
+// TestDecodeFields checks fields which are decoded from keys other than their JSON tag would suggest, or which need
+// an Unmarshal of their own.
+func TestDecodeFields(t *testing.T) {
+	tu, err := ParseTU(bytes.NewBufferString(decodeTestJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		ref *DeclRefExpr
+		cmd *BlockCommandComment
+	)
+	PreOrderVisit(tu, func(n Node, depth int) error {
+		switch n := n.(type) {
+		case *DeclRefExpr:
+			ref = n
+		case *BlockCommandComment:
+			cmd = n
+		}
+		return nil
+	})
+
+	if ref == nil || cmd == nil {
+		t.Fatalf("got DeclRefExpr %v and BlockCommandComment %v, want both", ref, cmd)
+	}
+	if ref.NonOdrUseReason != "unevaluated" {
+		t.Errorf("DeclRefExpr.NonOdrUseReason is %q, want unevaluated", ref.NonOdrUseReason)
+	}
+	if ref.ReferencedDecl.Kind != "VarDecl" || ref.ReferencedDecl.Name != "x" {
+		t.Errorf("DeclRefExpr refers to %+v, want the VarDecl x", ref.ReferencedDecl)
+	}
+	if cmd.Name != "return" {
+		t.Errorf("BlockCommandComment.Name is %q, want return", cmd.Name)
+	}
+}
@@ -0,0 +1,134 @@
+package goclangast
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type DiagnosticSeverity string
+
+const (
+	SeverityNote       DiagnosticSeverity = "note"
+	SeverityRemark     DiagnosticSeverity = "remark"
+	SeverityWarning    DiagnosticSeverity = "warning"
+	SeverityError      DiagnosticSeverity = "error"
+	SeverityFatalError DiagnosticSeverity = "fatal error"
+)
+
+// Diagnostic is a single warning, error or note emitted by clang.
+type Diagnostic struct {
+	Severity DiagnosticSeverity `json:"severity"`
+	// Loc is nil for diagnostics without a source location, such as command line errors.
+	Loc     *Loc   `json:"loc"`
+	Message string `json:"message"`
+	// Option is the flag controlling the diagnostic, for example "-Wunused-variable".
+	Option   string  `json:"option"`
+	Category string  `json:"category"`
+	FixIts   []FixIt `json:"fixIts"`
+}
+
+type FixIt struct {
+	Range       Range  `json:"range"`
+	Replacement string `json:"replacement"`
+}
+
+// diagnosticArgs makes clang emit diagnostics in a form parseDiagnostics understands.
+var diagnosticArgs = []string{
+	"-fno-color-diagnostics",
+	"-fno-caret-diagnostics",
+	"-fdiagnostics-show-option",
+	"-fdiagnostics-show-category=name",
+	"-fdiagnostics-parseable-fixits",
+}
+
+const (
+	// diagOptionsPattern matches the options clang appends to a message, optionally followed by the category, for
+	// example "-Wunused-variable,Unused Entity Issue" or "-Werror,-Wformat-security".
+	diagOptionsPattern = `-[WR][\w#=+-]*(?:,-[WR][\w#=+-]*)*(?:,[A-Z][^\[\],]*)?`
+	// diagCategoryPattern matches the category of diagnostics without an option, such as "Semantic Issue", and not
+	// anything else a message may end with in brackets.
+	diagCategoryPattern = `[A-Z][^\[\],]* Issue|Deprecations`
+)
+
+var (
+	diagLocRegex    = regexp.MustCompile(`^(.+?):(\d+):(\d+): (fatal error|error|warning|note|remark): (.*)$`)
+	diagNoLocRegex  = regexp.MustCompile(`^(?:[^:\s]+: )?(fatal error|error|warning|note|remark): (.*)$`)
+	diagFixItRegex  = regexp.MustCompile(`^fix-it:("(?:[^"\\]|\\.)*"):\{(\d+):(\d+)-(\d+):(\d+)\}:("(?:[^"\\]|\\.)*")$`)
+	diagSuffixRegex = regexp.MustCompile(` \[(` + diagOptionsPattern + `|` + diagCategoryPattern + `)\]$`)
+)
+
+// parseDiagnostics parses the diagnostics clang writes to stderr. Lines are split in place, so there is no limit on
+// their length.
+func parseDiagnostics(b []byte) []Diagnostic {
+	var diags []Diagnostic
+
+	for len(b) > 0 {
+		var rawLine []byte
+		rawLine, b, _ = bytes.Cut(b, []byte("\n"))
+		line := string(bytes.TrimSuffix(rawLine, []byte("\r")))
+
+		if m := diagFixItRegex.FindStringSubmatch(line); m != nil {
+			if len(diags) == 0 {
+				continue
+			}
+
+			file, err := strconv.Unquote(m[1])
+			if err != nil {
+				continue
+			}
+			replacement, err := strconv.Unquote(m[6])
+			if err != nil {
+				continue
+			}
+
+			last := &diags[len(diags)-1]
+			last.FixIts = append(last.FixIts, FixIt{
+				Range: Range{
+					Begin: &Loc{File: file, Line: atoi(m[2]), Col: atoi(m[3])},
+					End:   &Loc{File: file, Line: atoi(m[4]), Col: atoi(m[5])},
+				},
+				Replacement: replacement,
+			})
+			continue
+		}
+
+		var diag Diagnostic
+		if m := diagLocRegex.FindStringSubmatch(line); m != nil {
+			diag.Loc = &Loc{
+				File: m[1],
+				Line: atoi(m[2]),
+				Col:  atoi(m[3]),
+			}
+			diag.Severity = DiagnosticSeverity(m[4])
+			diag.Message = m[5]
+		} else if m := diagNoLocRegex.FindStringSubmatch(line); m != nil {
+			diag.Severity = DiagnosticSeverity(m[1])
+			diag.Message = m[2]
+		} else {
+			// "In file included from", "N warnings generated." and other informational lines.
+			continue
+		}
+
+		if m := diagSuffixRegex.FindStringSubmatchIndex(diag.Message); m != nil {
+			for _, part := range strings.Split(diag.Message[m[2]:m[3]], ",") {
+				if strings.HasPrefix(part, "-W") || strings.HasPrefix(part, "-R") {
+					diag.Option = part
+				} else {
+					diag.Category = part
+				}
+			}
+			diag.Message = diag.Message[:m[0]]
+		}
+
+		diags = append(diags, diag)
+	}
+
+	return diags
+}
+
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
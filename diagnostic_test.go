@@ -0,0 +1,68 @@
+package goclangast
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	stderr := `In file included from t.c:1:
+./t.h:2:6: warning: unused variable 'x' [-Wunused-variable,Unused Entity Issue]
+t.c:3:2: error: use of undeclared identifier 'y' [Semantic Issue]
+t.c:4:9: warning: format string is not a string literal (potentially insecure) [-Werror,-Wformat-security,Format String Issue]
+fix-it:"t.c":{4:9-4:9}:"\"%s\", "
+t.c:5:1: warning: array index 3 is past the end of the array (which contains 2 elements) [-Warray-bounds]
+t.c:6:3: note: declared here as [x, y]
+t.c:7:3: error: expected ';' after expression [foo]
+clang: warning: argument unused during compilation: '-c' [-Wunused-command-line-argument]
+2 warnings and 2 errors generated.
+`
+	want := []Diagnostic{
+		{Severity: SeverityWarning, Loc: &Loc{File: "./t.h", Line: 2, Col: 6}, Message: "unused variable 'x'",
+			Option: "-Wunused-variable", Category: "Unused Entity Issue"},
+		{Severity: SeverityError, Loc: &Loc{File: "t.c", Line: 3, Col: 2}, Message: "use of undeclared identifier 'y'",
+			Category: "Semantic Issue"},
+		{Severity: SeverityWarning, Loc: &Loc{File: "t.c", Line: 4, Col: 9},
+			Message: "format string is not a string literal (potentially insecure)", Option: "-Wformat-security",
+			Category: "Format String Issue", FixIts: []FixIt{{
+				Range: Range{
+					Begin: &Loc{File: "t.c", Line: 4, Col: 9},
+					End:   &Loc{File: "t.c", Line: 4, Col: 9},
+				},
+				Replacement: `"%s", `,
+			}}},
+		{Severity: SeverityWarning, Loc: &Loc{File: "t.c", Line: 5, Col: 1},
+			Message: "array index 3 is past the end of the array (which contains 2 elements)", Option: "-Warray-bounds"},
+		// Brackets which aren't options or a category are part of the message.
+		{Severity: SeverityNote, Loc: &Loc{File: "t.c", Line: 6, Col: 3}, Message: "declared here as [x, y]"},
+		{Severity: SeverityError, Loc: &Loc{File: "t.c", Line: 7, Col: 3}, Message: "expected ';' after expression [foo]"},
+		{Severity: SeverityWarning, Message: "argument unused during compilation: '-c'",
+			Option: "-Wunused-command-line-argument"},
+	}
+
+	got := parseDiagnostics([]byte(stderr))
+	if len(got) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("diagnostic %d is %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseDiagnosticsLongLine(t *testing.T) {
+	stderr := "t.c:1:1: error: first\n" + strings.Repeat("x", 2*1024*1024) + "\nt.c:2:1: error: second\n"
+
+	long := "t.c:3:1: warning: " + strings.Repeat("x", 2*1024*1024)
+	stderr += long + "\n"
+
+	got := parseDiagnostics([]byte(stderr))
+	if len(got) != 3 || got[0].Message != "first" || got[1].Message != "second" {
+		t.Fatalf("got %d diagnostics, want first, second and the long one", len(got))
+	}
+	if got[2].Message != long[len("t.c:3:1: warning: "):] {
+		t.Errorf("the long diagnostic is truncated to %d bytes", len(got[2].Message))
+	}
+}
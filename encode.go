@@ -0,0 +1,391 @@
+package goclangast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// MarshalJSON encodes the translation unit in the shape of clang's -ast-dump=json output.
+func (d *TranslationUnitDecl) MarshalJSON() ([]byte, error) {
+	return MarshalNode(d)
+}
+
+// MarshalNode encodes n and its descendants in the shape of clang's -ast-dump=json output, such that the result can be
+// parsed again by ParseTU. Fields holding their zero value are omitted, as clang does for most fields.
+func MarshalNode(n Node) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeNode(&buf, n); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Encode writes the JSON encoding of n to w, see MarshalNode.
+func Encode(w io.Writer, n Node) error {
+	b, err := MarshalNode(n)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+type encodeField struct {
+	key   string
+	index []int
+}
+
+// encodePlans caches the fields to encode per struct type.
+var encodePlans sync.Map
+
+// baseNodeKeys are encoded by encodeNode itself.
+var baseNodeKeys = map[string]bool{"id": true, "kind": true, "loc": true, "range": true, "inner": true}
+
+func encodePlan(t reflect.Type) []encodeField {
+	if plan, ok := encodePlans.Load(t); ok {
+		return plan.([]encodeField)
+	}
+
+	var plan []encodeField
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+
+		key, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		plan = append(plan, encodeField{key: key, index: f.Index})
+	}
+
+	encodePlans.Store(t, plan)
+	return plan
+}
+
+func encodeNode(buf *bytes.Buffer, n Node) error {
+	bn := n.GetBaseNode()
+
+	buf.WriteByte('{')
+	first := true
+	key := func(k string) {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		appendString(buf, k)
+		buf.WriteByte(':')
+	}
+
+	if bn.ID != "" {
+		key("id")
+		appendString(buf, bn.ID)
+	}
+	key("kind")
+	appendString(buf, bn.Kind)
+
+	if bn.Loc != nil {
+		key("loc")
+		encodeLoc(buf, bn.Loc)
+	}
+	if bn.Range != nil && bn.Range.Begin != nil && bn.Range.End != nil {
+		key("range")
+		buf.WriteString(`{"begin":`)
+		encodeLoc(buf, bn.Range.Begin)
+		buf.WriteString(`,"end":`)
+		encodeLoc(buf, bn.Range.End)
+		buf.WriteByte('}')
+	}
+
+	v := reflect.ValueOf(n).Elem()
+	for _, f := range encodePlan(v.Type()) {
+		if baseNodeKeys[f.key] {
+			continue
+		}
+
+		fv := v.FieldByIndex(f.index)
+		if fv.IsZero() {
+			continue
+		}
+
+		key(f.key)
+		if err := encodeValue(buf, fv); err != nil {
+			return fmt.Errorf("%s.%s: %w", bn.Kind, f.key, err)
+		}
+	}
+
+	if u, ok := n.(*UnknownNode); ok {
+		keys := make([]string, 0, len(u.Attributes))
+		for k := range u.Attributes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			key(k)
+			buf.Write(u.Attributes[k])
+		}
+	}
+
+	children := bn.Inner
+	if f, ok := n.(*ForStmt); ok {
+		children = f.encodeSlots()
+	}
+
+	if len(children) > 0 {
+		key("inner")
+		buf.WriteByte('[')
+		for i, child := range children {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+
+			if child == nil {
+				buf.WriteString("{}")
+				continue
+			}
+
+			if err := encodeNode(buf, child); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	}
+
+	buf.WriteByte('}')
+	return nil
+}
+
+// encodeSlots returns the children of the statement by slot, with nil for absent children, as clang emits them. If the
+// slots don't match the children, for example because Inner was modified, the children are returned as is.
+func (s *ForStmt) encodeSlots() []Node {
+	if !s.slotsConsistent() {
+		return s.Inner
+	}
+	return s.slots[:]
+}
+
+// slotsConsistent reports whether the filled slots are the children in Inner.
+func (s *ForStmt) slotsConsistent() bool {
+	n := 0
+	for _, slot := range s.slots {
+		if slot == nil {
+			continue
+		}
+		if n >= len(s.Inner) || s.Inner[n] != slot {
+			return false
+		}
+		n++
+	}
+	return n == len(s.Inner)
+}
+
+func encodeLoc(buf *bytes.Buffer, l *Loc) {
+	buf.WriteByte('{')
+	first := true
+	key := func(k string) {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		appendString(buf, k)
+		buf.WriteByte(':')
+	}
+
+	if l.SpellingLoc != nil {
+		key("spellingLoc")
+		encodeLoc(buf, l.SpellingLoc)
+	}
+	if l.ExpansionLoc != nil {
+		key("expansionLoc")
+		encodeLoc(buf, l.ExpansionLoc)
+	}
+	if l.Offset != 0 {
+		key("offset")
+		buf.WriteString(strconv.Itoa(l.Offset))
+	}
+	if l.File != "" {
+		key("file")
+		appendString(buf, l.File)
+	}
+	if l.Line != 0 {
+		key("line")
+		buf.WriteString(strconv.Itoa(l.Line))
+	}
+	if l.PresumedFile != "" {
+		key("presumedFile")
+		appendString(buf, l.PresumedFile)
+	}
+	if l.Col != 0 {
+		key("col")
+		buf.WriteString(strconv.Itoa(l.Col))
+	}
+	if l.TokLen != 0 {
+		key("tokLen")
+		buf.WriteString(strconv.Itoa(l.TokLen))
+	}
+	if l.IncludedFromFile != "" {
+		key("includedFrom")
+		buf.WriteString(`{"file":`)
+		appendString(buf, l.IncludedFromFile)
+		buf.WriteByte('}')
+	}
+	if l.IsMacroArgExpansion {
+		key("isMacroArgExpansion")
+		buf.WriteString("true")
+	}
+
+	buf.WriteByte('}')
+}
+
+var (
+	nodeType       = reflect.TypeOf((*Node)(nil)).Elem()
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+)
+
+func encodeValue(buf *bytes.Buffer, v reflect.Value) error {
+	if v.Type() == rawMessageType {
+		buf.Write(v.Bytes())
+		return nil
+	}
+
+	if v.Type().Implements(nodeType) && (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) {
+		if v.IsNil() {
+			buf.WriteString("{}")
+			return nil
+		}
+		return encodeNode(buf, v.Interface().(Node))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		appendString(buf, v.String())
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		buf.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		if l, ok := v.Interface().(*Loc); ok {
+			encodeLoc(buf, l)
+			return nil
+		}
+		return encodeValue(buf, v.Elem())
+
+	case reflect.Slice, reflect.Array:
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeValue(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", v.Type().Key())
+		}
+
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			appendString(buf, k.String())
+			buf.WriteByte(':')
+			if err := encodeValue(buf, v.MapIndex(k)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+
+	case reflect.Struct:
+		buf.WriteByte('{')
+		first := true
+		for _, f := range encodePlan(v.Type()) {
+			fv := v.FieldByIndex(f.index)
+			if fv.IsZero() {
+				continue
+			}
+
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+
+			appendString(buf, f.key)
+			buf.WriteByte(':')
+			if err := encodeValue(buf, fv); err != nil {
+				return fmt.Errorf("%s: %w", f.key, err)
+			}
+		}
+		buf.WriteByte('}')
+
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+// appendString writes s as a JSON string. Unlike encoding/json it doesn't escape HTML characters, like clang.
+func appendString(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+
+	buf.WriteByte('"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c == '\n':
+			buf.WriteString(`\n`)
+		case c == '\r':
+			buf.WriteString(`\r`)
+		case c == '\t':
+			buf.WriteString(`\t`)
+		case c < 0x20:
+			buf.WriteString(`\u00`)
+			buf.WriteByte(hex[c>>4])
+			buf.WriteByte(hex[c&0xf])
+		case c < utf8.RuneSelf:
+			buf.WriteByte(c)
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				buf.WriteString(`�`)
+			} else {
+				buf.WriteString(s[i : i+size])
+			}
+			i += size
+			continue
+		}
+		i++
+	}
+	buf.WriteByte('"')
+}
@@ -0,0 +1,241 @@
+package goclangast
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// The dumps in testdata/roundtrip are the output of clang -Xclang -ast-dump=json -fsyntax-only -fblocks for the C
+// files next to them, trimmed of most implicit declarations.
+
+func parseTestdata(t *testing.T, path string) *TranslationUnitDecl {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tu, err := ParseTU(bytes.NewBuffer(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tu
+}
+
+// roundTrip encodes tu and parses the result.
+func roundTrip(t *testing.T, tu *TranslationUnitDecl) *TranslationUnitDecl {
+	t.Helper()
+
+	b, err := MarshalNode(tu)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tu, err = ParseTU(bytes.NewBuffer(b))
+	if err != nil {
+		t.Fatalf("parse encoded tree: %v", err)
+	}
+	return tu
+}
+
+// flatten returns the nodes of the tree in pre-order.
+func flatten(n Node) []Node {
+	var nodes []Node
+	PreOrderVisit(n, func(n Node, depth int) error {
+		nodes = append(nodes, n)
+		return nil
+	})
+	return nodes
+}
+
+// shallow returns a copy of n without its links to other nodes in the tree.
+func shallow(n Node) Node {
+	v := reflect.New(reflect.TypeOf(n).Elem())
+	v.Elem().Set(reflect.ValueOf(n).Elem())
+
+	c := v.Interface().(Node)
+	bn := c.GetBaseNode()
+	bn.Inner, bn.parent, bn.prev, bn.next = nil, nil, nil, nil
+	if tu, ok := c.(*TranslationUnitDecl); ok {
+		tu.index, tu.labels = nil, nil
+	}
+	return c
+}
+
+func nodesOfKind(n Node, kind string) []Node {
+	var nodes []Node
+	for _, n := range flatten(n) {
+		if n.GetBaseNode().Kind == kind {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	paths, err := filepath.Glob("testdata/roundtrip/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no round trip test data")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			want := parseTestdata(t, path)
+
+			var buf bytes.Buffer
+			if err := Encode(&buf, want); err != nil {
+				t.Fatal(err)
+			}
+			encoded := bytes.Clone(buf.Bytes())
+
+			got, err := ParseTU(&buf)
+			if err != nil {
+				t.Fatalf("parse encoded tree: %v", err)
+			}
+
+			wantNodes, gotNodes := flatten(want), flatten(got)
+			if len(wantNodes) != len(gotNodes) {
+				t.Fatalf("encoded tree has %d nodes, want %d", len(gotNodes), len(wantNodes))
+			}
+			for i := range wantNodes {
+				if !reflect.DeepEqual(shallow(wantNodes[i]), shallow(gotNodes[i])) {
+					bn := wantNodes[i].GetBaseNode()
+					t.Fatalf("node %d (%s %s) differs after round trip", i, bn.Kind, bn.ID)
+				}
+				if len(wantNodes[i].Children()) != len(gotNodes[i].Children()) {
+					t.Fatalf("node %d (%s) has %d children after round trip, want %d", i,
+						wantNodes[i].GetBaseNode().Kind, len(gotNodes[i].Children()), len(wantNodes[i].Children()))
+				}
+			}
+			if !reflect.DeepEqual(want.UnknownKinds, got.UnknownKinds) {
+				t.Errorf("unknown kinds are %v after round trip, want %v", got.UnknownKinds, want.UnknownKinds)
+			}
+
+			reencoded, err := MarshalNode(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(encoded, reencoded) {
+				t.Error("encoding the round tripped tree gives different JSON")
+			}
+		})
+	}
+}
+
+func TestEncodeForStmtSlots(t *testing.T) {
+	tu := roundTrip(t, parseTestdata(t, "testdata/roundtrip/loops.json"))
+
+	// Slots that are set, in the order init, condition variable, condition, increment, body.
+	want := [][5]bool{
+		{true, false, true, true, true},
+		{false, false, false, false, true},
+		{false, false, true, false, true},
+	}
+
+	loops := nodesOfKind(tu, "ForStmt")
+	if len(loops) != len(want) {
+		t.Fatalf("got %d for loops, want %d", len(loops), len(want))
+	}
+	for i, n := range loops {
+		f := n.(*ForStmt)
+		got := [5]bool{f.Init() != nil, f.ConditionVariable() != nil, f.Cond() != nil, f.Inc() != nil, f.Body() != nil}
+		if got != want[i] {
+			t.Errorf("for loop %d has slots %v, want %v", i, got, want[i])
+		}
+	}
+
+	if cond, ok := loops[2].(*ForStmt).Cond().(*BinaryOperator); !ok || cond.Opcode != ">" {
+		t.Errorf("condition of the last for loop is %#v, want n > 0", loops[2].(*ForStmt).Cond())
+	}
+}
+
+func TestEncodeUnknownNode(t *testing.T) {
+	got := roundTrip(t, parseTestdata(t, "testdata/roundtrip/loops.json"))
+
+	if got.UnknownKinds["BlockExpr"] != 1 || got.UnknownKinds["BlockDecl"] != 1 {
+		t.Errorf("unknown kinds are %v, want one BlockExpr and one BlockDecl", got.UnknownKinds)
+	}
+
+	blocks := nodesOfKind(got, "BlockExpr")
+	if len(blocks) != 1 {
+		t.Fatalf("got %d BlockExpr nodes, want 1", len(blocks))
+	}
+	block, ok := blocks[0].(*UnknownNode)
+	if !ok {
+		t.Fatalf("BlockExpr is a %T, want *UnknownNode", blocks[0])
+	}
+
+	wantAttrs := map[string]json.RawMessage{
+		"type":          json.RawMessage(`{"qualType":"int (^)(int)"}`),
+		"valueCategory": json.RawMessage(`"prvalue"`),
+	}
+	if !reflect.DeepEqual(block.Attributes, wantAttrs) {
+		t.Errorf("BlockExpr has attributes %s, want %s", block.Attributes, wantAttrs)
+	}
+
+	// The children of unknown nodes are decoded as usual.
+	decl := block.Children()[0]
+	if _, ok := decl.GetBaseNode().Inner[0].(*ParmVarDecl); !ok {
+		t.Errorf("first child of the BlockDecl is a %T, want *ParmVarDecl", decl.GetBaseNode().Inner[0])
+	}
+}
+
+func TestEncodeElidedLocs(t *testing.T) {
+	for _, tt := range []struct {
+		path  string
+		kind  string
+		index int
+		loc   func(n Node) *Loc
+		want  Loc
+	}{
+		// The file and line are elided in the dump as they equal those of the location before.
+		{
+			path: "loops.json", kind: "ParmVarDecl",
+			loc:  func(n Node) *Loc { return n.GetBaseNode().Loc },
+			want: Loc{Offset: 28, File: "loops.c", Line: 3, Col: 13, TokLen: 1},
+		},
+		// Locations in macro expansions keep the elided spelling and expansion locations.
+		{
+			path: "loops.json", kind: "VarDecl",
+			loc: func(n Node) *Loc { return n.GetBaseNode().Range.End },
+			want: Loc{
+				File: "loops.c", Line: 5, Col: 2,
+				SpellingLoc:  &Loc{Offset: 13, Line: 1, Col: 14, TokLen: 1},
+				ExpansionLoc: &Loc{Offset: 42, Line: 5, Col: 10, TokLen: 4},
+			},
+		},
+		{
+			path: "lines.json", kind: "FieldDecl", index: 1,
+			loc:  func(n Node) *Loc { return n.GetBaseNode().Loc },
+			want: Loc{Offset: 23, File: "./lines.h", Line: 2, Col: 9, TokLen: 1, IncludedFromFile: "lines.c"},
+		},
+		{
+			path: "lines.json", kind: "VarDecl",
+			loc:  func(n Node) *Loc { return n.GetBaseNode().Loc },
+			want: Loc{Offset: 57, File: "lines.c", Line: 4, PresumedFile: "generated.c", Col: 14, TokLen: 6},
+		},
+		{
+			path: "lines.json", kind: "VarDecl",
+			loc:  func(n Node) *Loc { return n.GetBaseNode().Range.Begin },
+			want: Loc{Offset: 44, File: "lines.c", Line: 4, Col: 1, TokLen: 6},
+		},
+	} {
+		tu := roundTrip(t, parseTestdata(t, filepath.Join("testdata/roundtrip", tt.path)))
+
+		nodes := nodesOfKind(tu, tt.kind)
+		if len(nodes) <= tt.index {
+			t.Fatalf("%s has %d %s nodes, want more than %d", tt.path, len(nodes), tt.kind, tt.index)
+		}
+		if got := tt.loc(nodes[tt.index]); !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("%s: %s %d has loc %+v, want %+v", tt.path, tt.kind, tt.index, *got, tt.want)
+		}
+	}
+}
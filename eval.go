@@ -0,0 +1,76 @@
+package goclangast
+
+import "math/big"
+
+// EvalInt evaluates an integer constant expression, as far as it has been folded by clang or consists of literals,
+// casts and unary operators.
+func EvalInt(n Node) (*big.Int, bool) {
+	switch n := n.(type) {
+	case *ConstantExpr:
+		if v, ok := new(big.Int).SetString(n.Value, 0); ok {
+			return v, true
+		}
+		if len(n.Inner) > 0 {
+			return EvalInt(n.Inner[0])
+		}
+
+	case *IntegerLiteral:
+		return n.Int()
+
+	case *CharacterLiteral:
+		return n.Int(), true
+
+	case *ImplicitCastExpr, *CStyleCastExpr, *ParenExpr:
+		if children := n.Children(); len(children) == 1 {
+			return EvalInt(children[0])
+		}
+
+	case *UnaryOperator:
+		if len(n.Inner) != 1 {
+			return nil, false
+		}
+		v, ok := EvalInt(n.Inner[0])
+		if !ok {
+			return nil, false
+		}
+		switch n.Opcode {
+		case "-":
+			return v.Neg(v), true
+		case "+":
+			return v, true
+		case "~":
+			return v.Not(v), true
+		}
+	}
+
+	return nil, false
+}
+
+// Constants returns the constants of the enum with their values, constants without initializer are one more than the
+// previous constant.
+func (d *EnumDecl) Constants() ([]*EnumConstantDecl, []*big.Int) {
+	var (
+		consts []*EnumConstantDecl
+		values []*big.Int
+		next   = big.NewInt(0)
+	)
+	for _, c := range d.Inner {
+		ec, ok := c.(*EnumConstantDecl)
+		if !ok {
+			continue
+		}
+
+		value := next
+		if len(ec.Inner) > 0 {
+			if v, ok := EvalInt(ec.Inner[0]); ok {
+				value = v
+			}
+		}
+
+		consts = append(consts, ec)
+		values = append(values, value)
+		next = new(big.Int).Add(value, big.NewInt(1))
+	}
+
+	return consts, values
+}
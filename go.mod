@@ -3,6 +3,7 @@ module github.com/dylandreimerink/goclangast
 go 1.20
 
 require (
+	github.com/cilium/ebpf v0.11.0
 	github.com/philpearl/intern v0.0.1
 	github.com/valyala/fastjson v1.6.4
 )
@@ -10,4 +11,6 @@ require (
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/philpearl/stringbank v1.2.0 // indirect
+	golang.org/x/exp v0.0.0-20230224173230-c95f2b4c22f2 // indirect
+	golang.org/x/sys v0.10.0 // indirect
 )
@@ -0,0 +1,985 @@
+// Package gogen generates Go mirrors of the C structs, unions, enums, typedefs and constants in a parsed translation
+// unit. The AST must have been created with goclangast.Options.RecordLayouts so the generated structs can match the C
+// layout, including padding.
+//
+// Bitfields are mirrored as byte arrays with accessors which assume the bit order of little-endian targets, such as
+// x86 and arm64, where the first bitfield starts at the least significant bit of the first byte. The generated code is
+// wrong for big-endian targets.
+package gogen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dylandreimerink/goclangast"
+)
+
+type Config struct {
+	// Package is the name of the generated package.
+	Package string
+	// Files limits the generated declarations to those located in the given files, empty means all files.
+	Files []string
+	// PointerSize is the size of pointers on the target in bytes, zero means 8.
+	PointerSize int
+}
+
+// Generate returns formatted Go source mirroring the declarations in tu.
+func Generate(tu *goclangast.TranslationUnitDecl, cfg Config) ([]byte, error) {
+	if cfg.PointerSize == 0 {
+		cfg.PointerSize = 8
+	}
+
+	g := generator{
+		cfg:      cfg,
+		records:  make(map[string]*goclangast.RecordDecl),
+		enums:    make(map[string]*goclangast.EnumDecl),
+		typedefs: make(map[string]*goclangast.TypedefDecl),
+		names:    make(map[string]string),
+		used:     make(map[string]bool),
+		files:    make(map[string]bool),
+		structs:  make(map[*goclangast.RecordDecl]*goStruct),
+	}
+	for _, f := range cfg.Files {
+		g.files[f] = true
+	}
+
+	g.index(tu)
+	g.assignNames(tu)
+
+	if err := g.generate(tu); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by goclangast/gogen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", cfg.Package)
+	if g.needUnsafe {
+		fmt.Fprintf(&out, "import \"unsafe\"\n\n")
+	}
+	out.Write(g.buf.Bytes())
+	if g.needBitfields {
+		out.WriteString(bitfieldHelpers)
+	}
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return out.Bytes(), fmt.Errorf("format generated code: %w", err)
+	}
+
+	return src, nil
+}
+
+type generator struct {
+	cfg Config
+	buf bytes.Buffer
+
+	// records, enums and typedefs are indexed by the Key of the type they declare.
+	records  map[string]*goclangast.RecordDecl
+	enums    map[string]*goclangast.EnumDecl
+	typedefs map[string]*goclangast.TypedefDecl
+
+	// names holds the Go names of all generated types by type key.
+	names map[string]string
+	used  map[string]bool
+	files map[string]bool
+
+	// structs caches the Go layout of generated structs, which is needed for the alignment of fields of their type.
+	structs map[*goclangast.RecordDecl]*goStruct
+
+	needUnsafe    bool
+	needBitfields bool
+}
+
+// errSkipFunction prunes the body of a function from the traversal in visitDecls.
+var errSkipFunction = errors.New("skip function")
+
+// visitDecls calls fn for the nodes of tu in pre-order, skipping the bodies of functions. Types declared inside a
+// function can't be used outside of it, and would be confused with file scope types of the same name.
+func visitDecls(tu *goclangast.TranslationUnitDecl, fn func(n goclangast.Node, depth int) error) {
+	goclangast.PreOrderVisit(tu, func(n goclangast.Node, depth int) error {
+		if _, ok := n.(*goclangast.FunctionDecl); ok {
+			return errSkipFunction
+		}
+		return fn(n, depth)
+	})
+}
+
+func (g *generator) index(tu *goclangast.TranslationUnitDecl) {
+	visitDecls(tu, func(n goclangast.Node, depth int) error {
+		switch n := n.(type) {
+		case *goclangast.RecordDecl:
+			if n.CompleteDefinition {
+				g.records[n.Key()] = n
+			}
+		case *goclangast.EnumDecl:
+			if len(n.Inner) > 0 {
+				g.enums[n.Key()] = n
+			}
+		case *goclangast.TypedefDecl:
+			if n.IsImplicit {
+				return nil
+			}
+			g.typedefs[n.Key()] = n
+
+			// Clang spells anonymous records and enums named by a typedef with the name of the typedef.
+			switch tag := n.TagDecl().(type) {
+			case *goclangast.RecordDecl:
+				if tag.Name == "" {
+					g.records[tag.TagUsed+" "+n.Name] = tag
+				}
+			case *goclangast.EnumDecl:
+				if tag.Name == "" {
+					g.enums["enum "+n.Name] = tag
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// anonTagKeys returns the keys of the anonymous record or enum named by a typedef, or nil if d names another type.
+func anonTagKeys(d *goclangast.TypedefDecl) []string {
+	switch tag := d.TagDecl().(type) {
+	case *goclangast.RecordDecl:
+		if tag.Name == "" {
+			return []string{tag.Key(), tag.TagUsed + " " + d.Name}
+		}
+	case *goclangast.EnumDecl:
+		if tag.Name == "" {
+			return []string{tag.Key(), "enum " + d.Name}
+		}
+	}
+	return nil
+}
+
+func (g *generator) wanted(n goclangast.Node) bool {
+	if len(g.files) == 0 {
+		return true
+	}
+
+	l := n.GetBaseNode().Loc
+	return l != nil && g.files[l.File]
+}
+
+func (g *generator) uniqueName(name string) string {
+	if !g.used[name] {
+		g.used[name] = true
+		return name
+	}
+
+	for i := 2; ; i++ {
+		n := name + strconv.Itoa(i)
+		if !g.used[n] {
+			g.used[n] = true
+			return n
+		}
+	}
+}
+
+// assignNames picks Go names for all generated types. Anonymous records and enums named by a typedef take the name of
+// the typedef, anonymous records used as field types are named after the parent record and the field.
+func (g *generator) assignNames(tu *goclangast.TranslationUnitDecl) {
+	visitDecls(tu, func(n goclangast.Node, depth int) error {
+		d, ok := n.(*goclangast.TypedefDecl)
+		if !ok || d.IsImplicit || !g.wanted(d) {
+			return nil
+		}
+
+		keys := anonTagKeys(d)
+		if len(keys) == 0 {
+			return nil
+		}
+
+		if _, found := g.names[keys[0]]; !found {
+			name := g.uniqueName(GoName(d.Name))
+			for _, key := range keys {
+				g.names[key] = name
+			}
+			g.names[d.Key()] = name
+		}
+		return nil
+	})
+
+	visitDecls(tu, func(n goclangast.Node, depth int) error {
+		switch n := n.(type) {
+		case *goclangast.RecordDecl:
+			if !n.CompleteDefinition || !g.wanted(n) {
+				return nil
+			}
+
+			key := n.Key()
+			if _, found := g.names[key]; !found {
+				if n.Name == "" {
+					// Anonymous records are named when their parent is visited.
+					return nil
+				}
+				g.names[key] = g.uniqueName(GoName(n.Name))
+			}
+
+			anon := 0
+			for _, c := range n.Inner {
+				f, ok := c.(*goclangast.FieldDecl)
+				if !ok {
+					continue
+				}
+
+				t, err := f.Type.DesugaredCType()
+				if err != nil {
+					continue
+				}
+				for t.Kind == goclangast.CTypeArray {
+					t = t.Elem
+				}
+				if !t.Anonymous {
+					continue
+				}
+
+				fkey := t.Key()
+				if _, found := g.names[fkey]; found {
+					continue
+				}
+
+				if f.Name != "" {
+					g.names[fkey] = g.uniqueName(g.names[key] + "_" + GoName(f.Name))
+				} else {
+					anon++
+					g.names[fkey] = g.uniqueName(fmt.Sprintf("%s_Anon%d", g.names[key], anon))
+				}
+			}
+
+		case *goclangast.EnumDecl:
+			if n.Name == "" || !g.wanted(n) || len(n.Inner) == 0 {
+				return nil
+			}
+
+			key := n.Key()
+			if _, found := g.names[key]; !found {
+				g.names[key] = g.uniqueName(GoName(n.Name))
+			}
+
+		case *goclangast.TypedefDecl:
+			if n.IsImplicit || !g.wanted(n) {
+				return nil
+			}
+
+			key := n.Key()
+			if _, found := g.names[key]; found {
+				return nil
+			}
+
+			// "typedef struct foo foo;" mirrors as a single type.
+			if ct, err := n.Type.CType(); err == nil && g.names[ct.Key()] == GoName(n.Name) {
+				g.names[key] = GoName(n.Name)
+				return nil
+			}
+
+			g.names[key] = g.uniqueName(GoName(n.Name))
+		}
+		return nil
+	})
+}
+
+func (g *generator) generate(tu *goclangast.TranslationUnitDecl) error {
+	var err error
+	visitDecls(tu, func(n goclangast.Node, depth int) error {
+		if err != nil {
+			return err
+		}
+
+		switch n := n.(type) {
+		case *goclangast.RecordDecl:
+			name, ok := g.names[n.Key()]
+			if !ok || !n.CompleteDefinition || g.records[n.Key()] != n {
+				return nil
+			}
+
+			if n.Layout == nil {
+				err = fmt.Errorf("%s %s has no layout, parse with Options.RecordLayouts", n.TagUsed, n.Name)
+				return err
+			}
+
+			if n.TagUsed == "union" {
+				err = g.union(name, n)
+			} else {
+				err = g.record(name, n)
+			}
+			if err != nil {
+				err = fmt.Errorf("%s %s: %w", n.TagUsed, n.Name, err)
+			}
+
+		case *goclangast.EnumDecl:
+			name, ok := g.names[n.Key()]
+			if len(n.Inner) == 0 || (!ok && (n.Name != "" || !g.wanted(n))) {
+				return nil
+			}
+			err = g.enum(name, n)
+
+		case *goclangast.TypedefDecl:
+			if n.IsImplicit || !g.wanted(n) {
+				return nil
+			}
+			err = g.typedef(n)
+
+		case *goclangast.VarDecl:
+			if depth != 1 || !g.wanted(n) {
+				return nil
+			}
+			g.constant(n)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+func (g *generator) record(name string, d *goclangast.RecordDecl) error {
+	l, err := g.structLayout(name, d)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(&g.buf, "type %s struct {\n", name)
+	g.buf.Write(l.fields.Bytes())
+	fmt.Fprintf(&g.buf, "}\n\n")
+	g.buf.Write(l.accessors.Bytes())
+
+	return nil
+}
+
+// goStruct is the Go mirror of a C struct.
+type goStruct struct {
+	fields    bytes.Buffer
+	accessors bytes.Buffer
+	// size and align are the size and alignment Go computes for the struct.
+	size  int
+	align int
+}
+
+// structLayout mirrors a struct so every field has the same offset in Go as in C. Fields which Go's alignment rules
+// would move, such as the members of packed structs, are mirrored as byte arrays with accessors.
+func (g *generator) structLayout(name string, d *goclangast.RecordDecl) (*goStruct, error) {
+	if l, ok := g.structs[d]; ok {
+		return l, nil
+	}
+
+	l, err := g.layoutStruct(name, d, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.size != d.Layout.Size {
+		// Go pads the struct to the alignment of its fields, which can exceed the alignment of a packed struct. Fields
+		// with an alignment the size isn't a multiple of are mirrored as byte arrays, so the padding goes away.
+		l, err = g.layoutStruct(name, d, func(align int) bool { return d.Layout.Size%align != 0 })
+		if err != nil {
+			return nil, err
+		}
+		if l.size != d.Layout.Size {
+			return nil, fmt.Errorf("size %d can't be reproduced in Go, got %d", d.Layout.Size, l.size)
+		}
+	}
+
+	g.structs[d] = l
+	return l, nil
+}
+
+// layoutStruct mirrors the fields of a struct, fields with an alignment for which unaligned returns true are mirrored
+// as byte arrays.
+func (g *generator) layoutStruct(name string, d *goclangast.RecordDecl, unaligned func(align int) bool) (*goStruct, error) {
+	var (
+		l        = &goStruct{align: 1}
+		cur      int
+		lastZero bool
+		fields   []*goclangast.FieldDecl
+	)
+	for _, c := range d.Inner {
+		if f, ok := c.(*goclangast.FieldDecl); ok && f.Layout != nil {
+			fields = append(fields, f)
+		}
+	}
+
+	pad := func(off int) {
+		if off > cur {
+			fmt.Fprintf(&l.fields, "_ [%d]byte\n", off-cur)
+			cur = off
+			lastZero = false
+		}
+	}
+
+	anon := 0
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+
+		if f.IsBitfield {
+			// Consecutive bitfields share storage, which is mirrored as a byte array with accessors.
+			j := i
+			for j+1 < len(fields) && fields[j+1].IsBitfield {
+				j++
+			}
+			start := fields[i].Layout.BitOffset / 8
+			if start < cur {
+				start = cur
+			}
+			end := start
+			for _, bf := range fields[i : j+1] {
+				if e := (bf.Layout.BitOffset + bf.Layout.BitWidth + 7) / 8; e > end {
+					end = e
+				}
+			}
+			pad(start)
+			if end > start {
+				storage := fmt.Sprintf("bitfields%d", start)
+				fmt.Fprintf(&l.fields, "%s [%d]byte\n", storage, end-start)
+				for _, bf := range fields[i : j+1] {
+					if bf.Name == "" || bf.Layout.BitWidth == 0 {
+						continue
+					}
+					g.bitfieldAccessors(&l.accessors, name, "s."+storage+"[:]", bf, bf.Layout.BitOffset-start*8)
+				}
+				cur = end
+				lastZero = false
+			}
+			i = j
+			continue
+		}
+
+		ct, err := f.Type.CType()
+		if err != nil {
+			return nil, err
+		}
+
+		goType, err := g.goType(ct)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+
+		size, err := g.sizeOf(ct)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+
+		align, err := g.alignOf(ct)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+
+		off := f.Layout.ByteOffset
+		if off < cur {
+			return nil, fmt.Errorf("field %s at offset %d overlaps the previous field", f.Name, off)
+		}
+
+		fieldName := GoName(f.Name)
+		if f.Name == "" {
+			anon++
+			fieldName = fmt.Sprintf("Anon%d", anon)
+		}
+
+		switch {
+		case size == 0 && off == d.Layout.Size:
+			// Go pads structs ending in a zero sized field, such as a flexible array member, so the field is only
+			// reachable through an accessor.
+			g.needUnsafe = true
+			fmt.Fprintf(&l.accessors, "func (s *%s) %s() unsafe.Pointer {\nreturn unsafe.Add(unsafe.Pointer(s), %d)\n}\n\n",
+				name, fieldName, off)
+
+		case off%align != 0 || (unaligned != nil && unaligned(align)):
+			pad(off)
+			storage := fmt.Sprintf("unaligned%d", off)
+			fmt.Fprintf(&l.fields, "%s [%d]byte\n", storage, size)
+			g.unalignedAccessors(&l.accessors, name, "s."+storage+"[:]", fieldName, goType, size)
+			cur = off + size
+			lastZero = size == 0
+
+		default:
+			pad(off)
+			fmt.Fprintf(&l.fields, "%s %s\n", fieldName, goType)
+			if align > l.align {
+				l.align = align
+			}
+			cur = off + size
+			lastZero = size == 0
+		}
+	}
+
+	pad(d.Layout.Size)
+
+	l.size = cur
+	if lastZero && cur > 0 {
+		l.size++
+	}
+	l.size = (l.size + l.align - 1) / l.align * l.align
+
+	return l, nil
+}
+
+// unalignedAccessors generates accessors for a field mirrored as a byte array, the value is copied since the field
+// may not be aligned for its type. storage is the byte slice holding the field, relative to the receiver s.
+func (g *generator) unalignedAccessors(w *bytes.Buffer, recv, storage, name, goType string, size int) {
+	g.needUnsafe = true
+
+	fmt.Fprintf(w, "func (s *%s) %s() %s {\nvar v %s\ncopy((*[%d]byte)(unsafe.Pointer(&v))[:], %s)\nreturn v\n}\n\n",
+		recv, name, goType, goType, size, storage)
+	fmt.Fprintf(w, "func (s *%s) Set%s(v %s) {\ncopy(%s, (*[%d]byte)(unsafe.Pointer(&v))[:])\n}\n\n",
+		recv, name, goType, storage, size)
+}
+
+// bitfieldAccessors generates accessors for a bitfield stored at bit shift of storage, the byte slice holding the
+// bitfield relative to the receiver s.
+func (g *generator) bitfieldAccessors(w *bytes.Buffer, recv, storage string, f *goclangast.FieldDecl, shift int) {
+	g.needBitfields = true
+
+	name := GoName(f.Name)
+	fmt.Fprintf(w, "func (s *%s) %s() uint64 {\nreturn bitfieldGet(%s, %d, %d)\n}\n\n",
+		recv, name, storage, shift, f.Layout.BitWidth)
+	fmt.Fprintf(w, "func (s *%s) Set%s(v uint64) {\nbitfieldSet(%s, %d, %d, v)\n}\n\n",
+		recv, name, storage, shift, f.Layout.BitWidth)
+}
+
+// union mirrors a union as a byte array of the same size with a getter and setter per member. The byte array is only
+// aligned to a byte, so the accessors copy the value rather than returning a pointer into the union.
+func (g *generator) union(name string, d *goclangast.RecordDecl) error {
+	fmt.Fprintf(&g.buf, "type %s [%d]byte\n\n", name, d.Layout.Size)
+
+	anon := 0
+	for _, c := range d.Inner {
+		f, ok := c.(*goclangast.FieldDecl)
+		if !ok {
+			continue
+		}
+
+		if f.IsBitfield {
+			// Unnamed bitfields only affect the layout, they aren't members.
+			if f.Name != "" && f.Layout != nil && f.Layout.BitWidth != 0 {
+				g.bitfieldAccessors(&g.buf, name, "s[:]", f, f.Layout.BitOffset)
+			}
+			continue
+		}
+
+		ct, err := f.Type.CType()
+		if err != nil {
+			return err
+		}
+
+		goType, err := g.goType(ct)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+
+		accessor := GoName(f.Name)
+		if f.Name == "" {
+			anon++
+			accessor = fmt.Sprintf("Anon%d", anon)
+		}
+
+		size, err := g.sizeOf(ct)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+
+		g.unalignedAccessors(&g.buf, name, fmt.Sprintf("s[:%d]", size), accessor, goType, size)
+	}
+
+	return nil
+}
+
+func (g *generator) enum(name string, d *goclangast.EnumDecl) error {
+	consts, values := d.Constants()
+
+	if name != "" {
+		underlying, _ := g.enumType(d, values)
+		fmt.Fprintf(&g.buf, "type %s %s\n\n", name, underlying)
+	}
+
+	fmt.Fprintf(&g.buf, "const (\n")
+	for i, c := range consts {
+		if name != "" {
+			fmt.Fprintf(&g.buf, "%s %s = %s\n", g.uniqueName(GoName(c.Name)), name, values[i])
+		} else {
+			fmt.Fprintf(&g.buf, "%s = %s\n", g.uniqueName(GoName(c.Name)), values[i])
+		}
+	}
+	fmt.Fprintf(&g.buf, ")\n\n")
+
+	return nil
+}
+
+// enumType returns the Go type and size of an enum, following clang's choice of the smallest int or unsigned int
+// compatible type which can represent all values.
+func (g *generator) enumType(d *goclangast.EnumDecl, values []*big.Int) (string, int) {
+	if d.FixedUnderlyingType.QualType != "" {
+		if ct, err := goclangast.ParseCType(d.FixedUnderlyingType.QualType); err == nil {
+			if b, err := g.builtin(ct); err == nil {
+				return b.goType, b.size
+			}
+		}
+	}
+
+	var negative, fitsInt32, fitsUint32 = false, true, true
+	for _, v := range values {
+		if v.Sign() < 0 {
+			negative = true
+		}
+		if !v.IsInt64() || v.Int64() < -1<<31 || v.Int64() > 1<<31-1 {
+			fitsInt32 = false
+		}
+		if v.Sign() < 0 || !v.IsUint64() || v.Uint64() > 1<<32-1 {
+			fitsUint32 = false
+		}
+	}
+
+	switch {
+	case negative && fitsInt32:
+		return "int32", 4
+	case negative:
+		return "int64", 8
+	case fitsUint32:
+		return "uint32", 4
+	}
+	return "uint64", 8
+}
+
+func (g *generator) typedef(d *goclangast.TypedefDecl) error {
+	name, ok := g.names[d.Key()]
+	if !ok {
+		return nil
+	}
+
+	// Typedefs of anonymous records and enums name the type itself.
+	if keys := anonTagKeys(d); len(keys) > 0 && g.names[keys[0]] == name {
+		return nil
+	}
+
+	ct, err := d.Type.CType()
+	if err != nil {
+		return err
+	}
+
+	if ct.Kind == goclangast.CTypeFunction {
+		// Function types can only be used through pointers, which are mirrored as uintptr.
+		return nil
+	}
+
+	goType, err := g.goType(ct)
+	if err != nil {
+		return fmt.Errorf("typedef %s: %w", d.Name, err)
+	}
+
+	if goType == name {
+		return nil
+	}
+
+	fmt.Fprintf(&g.buf, "type %s = %s\n\n", name, goType)
+	return nil
+}
+
+// constant mirrors const qualified integer variables with a constant initializer.
+func (g *generator) constant(d *goclangast.VarDecl) {
+	if d.StorageClass == "extern" || d.Init == "" || len(d.Inner) == 0 {
+		return
+	}
+
+	ct, err := d.Type.CType()
+	if err != nil || !ct.IsConst() {
+		return
+	}
+
+	value, ok := goclangast.EvalInt(d.Inner[len(d.Inner)-1])
+	if !ok {
+		return
+	}
+
+	goType, err := g.goType(ct.Unqualified())
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(&g.buf, "const %s %s = %s\n\n", g.uniqueName(GoName(d.Name)), goType, value)
+}
+
+type builtinType struct {
+	goType string
+	size   int
+}
+
+var builtinTypes = map[string]builtinType{
+	"char":               {"int8", 1},
+	"signed char":        {"int8", 1},
+	"unsigned char":      {"uint8", 1},
+	"_Bool":              {"bool", 1},
+	"bool":               {"bool", 1},
+	"short":              {"int16", 2},
+	"unsigned short":     {"uint16", 2},
+	"int":                {"int32", 4},
+	"unsigned int":       {"uint32", 4},
+	"long":               {"int64", 8},
+	"unsigned long":      {"uint64", 8},
+	"long long":          {"int64", 8},
+	"unsigned long long": {"uint64", 8},
+	"__int128":           {"[16]byte", 16},
+	"unsigned __int128":  {"[16]byte", 16},
+	"float":              {"float32", 4},
+	"double":             {"float64", 8},
+	"long double":        {"[16]byte", 16},
+	"_Float16":           {"uint16", 2},
+	"__fp16":             {"uint16", 2},
+	"wchar_t":            {"int32", 4},
+	"char16_t":           {"uint16", 2},
+	"char32_t":           {"uint32", 4},
+}
+
+func (g *generator) builtin(t *goclangast.CType) (builtinType, error) {
+	name := t.CanonicalName()
+	b, ok := builtinTypes[name]
+	if !ok {
+		return builtinType{}, fmt.Errorf("unsupported builtin type %s", t.Name)
+	}
+
+	// long has the size of a pointer on both ILP32 and LP64 targets.
+	if g.cfg.PointerSize == 4 {
+		switch name {
+		case "long":
+			b = builtinType{"int32", 4}
+		case "unsigned long":
+			b = builtinType{"uint32", 4}
+		}
+	}
+	return b, nil
+}
+
+func (g *generator) goType(t *goclangast.CType) (string, error) {
+	switch t.Kind {
+	case goclangast.CTypeBuiltin:
+		b, err := g.builtin(t)
+		return b.goType, err
+
+	case goclangast.CTypePointer, goclangast.CTypeReference:
+		return "uintptr", nil
+
+	case goclangast.CTypeArray:
+		elem, err := g.goType(t.Elem)
+		if err != nil {
+			return "", err
+		}
+		size := t.Size
+		if size < 0 {
+			size = 0
+		}
+		return fmt.Sprintf("[%d]%s", size, elem), nil
+
+	case goclangast.CTypeRecord, goclangast.CTypeEnum, goclangast.CTypeTypedef:
+		if name, ok := g.names[t.Key()]; ok {
+			return name, nil
+		}
+		return g.opaque(t)
+	}
+
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// opaque mirrors types which are not generated, because they are declared outside of Config.Files, by their underlying
+// type or as a byte array of their size.
+func (g *generator) opaque(t *goclangast.CType) (string, error) {
+	switch t.Kind {
+	case goclangast.CTypeTypedef:
+		d, ok := g.typedefs[t.Key()]
+		if !ok {
+			return "", fmt.Errorf("unknown typedef %s", t.Name)
+		}
+		ct, err := d.Type.CType()
+		if err != nil {
+			return "", err
+		}
+		return g.goType(ct)
+
+	case goclangast.CTypeEnum:
+		d, ok := g.enums[t.Key()]
+		if !ok {
+			return "uint32", nil
+		}
+		_, values := d.Constants()
+		goType, _ := g.enumType(d, values)
+		return goType, nil
+	}
+
+	size, err := g.sizeOf(t)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("[%d]byte", size), nil
+}
+
+func (g *generator) sizeOf(t *goclangast.CType) (int, error) {
+	switch t.Kind {
+	case goclangast.CTypeBuiltin:
+		b, err := g.builtin(t)
+		return b.size, err
+
+	case goclangast.CTypePointer, goclangast.CTypeReference:
+		return g.cfg.PointerSize, nil
+
+	case goclangast.CTypeArray:
+		if t.Size <= 0 {
+			return 0, nil
+		}
+		elem, err := g.sizeOf(t.Elem)
+		return elem * t.Size, err
+
+	case goclangast.CTypeRecord:
+		d, ok := g.records[t.Key()]
+		if !ok {
+			return 0, fmt.Errorf("incomplete type %s", t)
+		}
+		if d.Layout == nil {
+			return 0, fmt.Errorf("%s has no layout", t)
+		}
+		return d.Layout.Size, nil
+
+	case goclangast.CTypeEnum:
+		d, ok := g.enums[t.Key()]
+		if !ok {
+			return 4, nil
+		}
+		_, values := d.Constants()
+		_, size := g.enumType(d, values)
+		return size, nil
+
+	case goclangast.CTypeTypedef:
+		d, ok := g.typedefs[t.Key()]
+		if !ok {
+			return 0, fmt.Errorf("unknown typedef %s", t.Name)
+		}
+		ct, err := d.Type.CType()
+		if err != nil {
+			return 0, err
+		}
+		return g.sizeOf(ct)
+	}
+
+	return 0, fmt.Errorf("unsupported type %s", t)
+}
+
+// alignOf returns the alignment Go uses for the mirror of a type.
+func (g *generator) alignOf(t *goclangast.CType) (int, error) {
+	switch t.Kind {
+	case goclangast.CTypeBuiltin:
+		b, err := g.builtin(t)
+		return g.goAlign(b.goType), err
+
+	case goclangast.CTypePointer, goclangast.CTypeReference:
+		return g.cfg.PointerSize, nil
+
+	case goclangast.CTypeArray:
+		return g.alignOf(t.Elem)
+
+	case goclangast.CTypeRecord:
+		// Unions and records which aren't generated are mirrored as byte arrays.
+		name, ok := g.names[t.Key()]
+		d := g.records[t.Key()]
+		if !ok || d == nil || d.TagUsed == "union" || d.Layout == nil {
+			return 1, nil
+		}
+		l, err := g.structLayout(name, d)
+		if err != nil {
+			return 0, err
+		}
+		return l.align, nil
+
+	case goclangast.CTypeEnum:
+		d, ok := g.enums[t.Key()]
+		if !ok {
+			return 4, nil
+		}
+		_, values := d.Constants()
+		goType, _ := g.enumType(d, values)
+		return g.goAlign(goType), nil
+
+	case goclangast.CTypeTypedef:
+		d, ok := g.typedefs[t.Key()]
+		if !ok {
+			return 0, fmt.Errorf("unknown typedef %s", t.Name)
+		}
+		ct, err := d.Type.CType()
+		if err != nil {
+			return 0, err
+		}
+		return g.alignOf(ct)
+	}
+
+	return 0, fmt.Errorf("unsupported type %s", t)
+}
+
+// goAlign returns the alignment of the Go types used for builtin types. 64 bit integers and floats are only aligned to
+// the pointer size on 32 bit targets.
+func (g *generator) goAlign(goType string) int {
+	switch goType {
+	case "int16", "uint16":
+		return 2
+	case "int32", "uint32", "float32":
+		return 4
+	case "int64", "uint64", "float64":
+		if g.cfg.PointerSize < 8 {
+			return g.cfg.PointerSize
+		}
+		return 8
+	case "uintptr":
+		return g.cfg.PointerSize
+	}
+	return 1
+}
+
+// GoName converts a C identifier to an exported Go identifier, for example "foo_bar" and "FOO_BAR" to "FooBar".
+func GoName(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+
+		if strings.ToUpper(part) == part {
+			part = strings.ToLower(part)
+		}
+
+		r := []rune(part)
+		r[0] = unicode.ToUpper(r[0])
+		sb.WriteString(string(r))
+	}
+
+	s := sb.String()
+	if s == "" || !unicode.IsLetter([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+const bitfieldHelpers = `
+// bitfieldGet and bitfieldSet access width bits at bit offset off of b, counting from the least significant bit of
+// b[0] as the little-endian targets gogen supports lay out bitfields.
+func bitfieldGet(b []byte, off, width uint) uint64 {
+	var v uint64
+	for i := uint(0); i < width; i++ {
+		bit := off + i
+		if b[bit/8]&(1<<(bit%8)) != 0 {
+			v |= 1 << i
+		}
+	}
+	return v
+}
+
+func bitfieldSet(b []byte, off, width uint, v uint64) {
+	for i := uint(0); i < width; i++ {
+		bit := off + i
+		if v&(1<<i) != 0 {
+			b[bit/8] |= 1 << (bit % 8)
+		} else {
+			b[bit/8] &^= 1 << (bit % 8)
+		}
+	}
+}
+`
@@ -0,0 +1,101 @@
+package gogen
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/dylandreimerink/goclangast"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// stubClang writes a script standing in for clang, which prints the JSON dump and record layouts of the file name in
+// ../testdata/layout regardless of its arguments.
+func stubClang(t *testing.T, name string) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the clang stub is a shell script")
+	}
+
+	dir, err := filepath.Abs("../testdata/layout")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := fmt.Sprintf(`#!/bin/sh
+for arg; do
+	case "$arg" in
+	-ast-dump=json) exec cat %q ;;
+	-fdump-record-layouts) exec cat %q ;;
+	esac
+done
+exit 1
+`, filepath.Join(dir, name+".json"), filepath.Join(dir, name+".layout"))
+
+	path := filepath.Join(t.TempDir(), "clang")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestGenerateGolden generates Go for the layout test data and compares it with testdata/<name>.golden, run with
+// -update to regenerate them.
+//
+// records.c covers anonymous records, including one declared by a macro, and records with the same name in different
+// scopes. mirror.c covers padding, bitfields, unions, packed structs, anonymous members, enums and constants.
+func TestGenerateGolden(t *testing.T) {
+	for _, name := range []string{"records", "mirror"} {
+		t.Run(name, func(t *testing.T) {
+			tu, _, err := goclangast.NewASTContext(context.Background(), name+".c", goclangast.Options{
+				ClangPath:     stubClang(t, name),
+				RecordLayouts: true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Generate(tu, Config{Package: "mirror"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("generated code differs from %s:\n%s", golden, got)
+			}
+
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, golden, got, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conf := types.Config{Importer: importer.Default()}
+			if _, err := conf.Check("mirror", fset, []*ast.File{f}, nil); err != nil {
+				t.Errorf("generated code doesn't compile: %v", err)
+			}
+		})
+	}
+}
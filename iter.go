@@ -0,0 +1,79 @@
+//go:build go1.23
+
+package goclangast
+
+import "iter"
+
+// All yields n and all of its descendants in pre-order.
+func All(n Node) iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		all(n, yield)
+	}
+}
+
+// all yields n and its descendants, it returns false once yield does.
+func all(n Node, yield func(Node) bool) bool {
+	if !yield(n) {
+		return false
+	}
+
+	for _, child := range n.Children() {
+		if child != nil && !all(child, yield) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Descendants yields all descendants of n in pre-order, excluding n itself.
+func Descendants(n Node) iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		for _, child := range n.Children() {
+			if child != nil && !all(child, yield) {
+				return
+			}
+		}
+	}
+}
+
+// Ancestors yields the parent of n, its parent, and so on up to the root of the tree.
+func Ancestors(n Node) iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		for p := n.Parent(); p != nil; p = p.Parent() {
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
+
+// Siblings yields the other children of the parent of n in order, excluding n itself.
+func Siblings(n Node) iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		first := n
+		for first.PrevSibling() != nil {
+			first = first.PrevSibling()
+		}
+
+		for s := first; s != nil; s = s.NextSibling() {
+			if s != n && !yield(s) {
+				return
+			}
+		}
+	}
+}
+
+// OfType yields the nodes of type T in the tree rooted at n in pre-order, for example:
+//
+//	for d := range OfType[*FunctionDecl](tu) {
+//		...
+//	}
+func OfType[T Node](n Node) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		all(n, func(n Node) bool {
+			t, ok := n.(T)
+			return !ok || yield(t)
+		})
+	}
+}
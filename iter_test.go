@@ -0,0 +1,147 @@
+//go:build go1.23
+
+package goclangast
+
+import (
+	"reflect"
+	"testing"
+)
+
+// kindsOf returns the kinds of the nodes in order.
+func kindsOf[T Node](nodes []T) []string {
+	var kinds []string
+	for _, n := range nodes {
+		kinds = append(kinds, n.GetBaseNode().Kind)
+	}
+	return kinds
+}
+
+func TestAll(t *testing.T) {
+	add := stmtsFunc(t, "add")
+
+	var nodes []Node
+	for n := range All(add) {
+		nodes = append(nodes, n)
+	}
+	if want := flatten(add); !reflect.DeepEqual(nodes, want) {
+		t.Errorf("all yields %v, want %v", kindsOf(nodes), kindsOf(want))
+	}
+
+	// Breaking out of the loop stops the iteration, the runtime panics if yield is called again.
+	nodes = nil
+	for n := range All(add) {
+		nodes = append(nodes, n)
+		if _, ok := n.(*ReturnStmt); ok {
+			break
+		}
+	}
+	if got, want := kindsOf(nodes), []string{"FunctionDecl", "ParmVarDecl", "ParmVarDecl", "CompoundStmt",
+		"ReturnStmt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("all yields %v before the break, want %v", got, want)
+	}
+
+	// Nil children are skipped.
+	s := &CompoundStmt{BaseNode: BaseNode{Kind: "CompoundStmt"}}
+	s.Inner = []Node{nil, &NullStmt{BaseNode: BaseNode{Kind: "NullStmt"}}, nil}
+	nodes = nil
+	for n := range All(s) {
+		nodes = append(nodes, n)
+	}
+	if got, want := kindsOf(nodes), []string{"CompoundStmt", "NullStmt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("all yields %v, want %v", got, want)
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	add := stmtsFunc(t, "add")
+
+	var nodes []Node
+	for n := range Descendants(add) {
+		nodes = append(nodes, n)
+	}
+	if want := flatten(add)[1:]; !reflect.DeepEqual(nodes, want) {
+		t.Errorf("descendants are %v, want %v", kindsOf(nodes), kindsOf(want))
+	}
+
+	nodes = nil
+	for n := range Descendants(add) {
+		nodes = append(nodes, n)
+		if len(nodes) == 3 {
+			break
+		}
+	}
+	if got, want := kindsOf(nodes), []string{"ParmVarDecl", "ParmVarDecl", "CompoundStmt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("descendants are %v before the break, want %v", got, want)
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	ref := nodesOfKind(stmtsFunc(t, "add"), "DeclRefExpr")[0]
+
+	var nodes []Node
+	for n := range Ancestors(ref) {
+		nodes = append(nodes, n)
+	}
+	want := []string{"ImplicitCastExpr", "BinaryOperator", "ReturnStmt", "CompoundStmt", "FunctionDecl",
+		"TranslationUnitDecl"}
+	if got := kindsOf(nodes); !reflect.DeepEqual(got, want) {
+		t.Errorf("ancestors are %v, want %v", got, want)
+	}
+
+	nodes = nil
+	for n := range Ancestors(ref) {
+		nodes = append(nodes, n)
+		if _, ok := n.(*ReturnStmt); ok {
+			break
+		}
+	}
+	if got := kindsOf(nodes); !reflect.DeepEqual(got, want[:3]) {
+		t.Errorf("ancestors are %v before the break, want %v", got, want[:3])
+	}
+}
+
+func TestSiblings(t *testing.T) {
+	add := stmtsFunc(t, "add")
+	b := add.Inner[1]
+
+	var nodes []Node
+	for n := range Siblings(b) {
+		nodes = append(nodes, n)
+	}
+	if want := []Node{add.Inner[0], add.Inner[2]}; !reflect.DeepEqual(nodes, want) {
+		t.Errorf("siblings are %v, want %v", kindsOf(nodes), kindsOf(want))
+	}
+
+	nodes = nil
+	for n := range Siblings(b) {
+		nodes = append(nodes, n)
+		break
+	}
+	if want := []Node{add.Inner[0]}; !reflect.DeepEqual(nodes, want) {
+		t.Errorf("siblings are %v before the break, want %v", kindsOf(nodes), kindsOf(want))
+	}
+}
+
+func TestOfType(t *testing.T) {
+	apply := stmtsFunc(t, "apply")
+
+	var names []string
+	for ref := range OfType[*DeclRefExpr](apply) {
+		names = append(names, ref.ReferencedDecl.Name)
+		if len(names) == 4 {
+			break
+		}
+	}
+	if want := []string{"add", "p", "p", "total"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("references are %v, want %v", names, want)
+	}
+
+	// Nodes of other types, such as the for loop without a condition, don't stop the iteration.
+	var loops []*ForStmt
+	for loop := range OfType[*ForStmt](apply) {
+		loops = append(loops, loop)
+	}
+	if len(loops) != 1 || loops[0].Cond() != nil {
+		t.Errorf("for loops are %v, want for (;;)", kindsOf(loops))
+	}
+}
@@ -0,0 +1,186 @@
+package goclangast
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// RecordLayout is the memory layout of a record as computed by clang.
+type RecordLayout struct {
+	// Size is the size of the record in bytes, including tail padding.
+	Size int `json:"size"`
+	// Alignment is the alignment of the record in bytes.
+	Alignment int `json:"alignment"`
+}
+
+// FieldLayout is the position of a field within its record as computed by clang.
+type FieldLayout struct {
+	// ByteOffset is the offset of the byte containing the first bit of the field.
+	ByteOffset int `json:"byteOffset"`
+	// BitOffset is the offset of the field in bits from the start of the record.
+	BitOffset int `json:"bitOffset"`
+	// BitWidth is the width of bitfields, zero for other fields.
+	BitWidth int `json:"bitWidth"`
+}
+
+// BitfieldWidth returns the width of the field as written in the source, or zero if the field is not a bitfield.
+func (d *FieldDecl) BitfieldWidth() int {
+	if !d.IsBitfield || len(d.Inner) == 0 {
+		return 0
+	}
+
+	switch w := d.Inner[0].(type) {
+	case *ConstantExpr:
+		n, _ := strconv.Atoi(w.Value)
+		return n
+	case *IntegerLiteral:
+		n, _ := strconv.Atoi(w.Value)
+		return n
+	}
+
+	return 0
+}
+
+type rawRecordLayout struct {
+	typ          string
+	size         int
+	alignment    int
+	fieldOffsets []int
+}
+
+// parseRecordLayouts parses the output of clang's -fdump-record-layouts-simple. Sizes and offsets are in bits.
+func parseRecordLayouts(r io.Reader) ([]rawRecordLayout, error) {
+	var (
+		layouts []rawRecordLayout
+		cur     *rawRecordLayout
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if typ, ok := strings.CutPrefix(line, "Type: "); ok {
+			layouts = append(layouts, rawRecordLayout{typ: typ})
+			cur = &layouts[len(layouts)-1]
+			continue
+		}
+
+		if cur == nil {
+			continue
+		}
+
+		var err error
+		switch {
+		case strings.HasPrefix(line, "Size:"):
+			cur.size, err = strconv.Atoi(strings.TrimPrefix(line, "Size:"))
+		case strings.HasPrefix(line, "Alignment:"):
+			cur.alignment, err = strconv.Atoi(strings.TrimPrefix(line, "Alignment:"))
+		case strings.HasPrefix(line, "FieldOffsets:"):
+			list := strings.TrimPrefix(line, "FieldOffsets:")
+			list = strings.Trim(strings.TrimSpace(list), "[]>")
+			for _, f := range strings.Split(list, ",") {
+				f = strings.TrimSpace(f)
+				if f == "" {
+					continue
+				}
+
+				var off int
+				off, err = strconv.Atoi(f)
+				if err != nil {
+					break
+				}
+				cur.fieldOffsets = append(cur.fieldOffsets, off)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("layout of %s: %w", cur.typ, err)
+		}
+	}
+
+	return layouts, scanner.Err()
+}
+
+// applyRecordLayouts sets the layouts of the complete records in tu, which are matched on their location. Clang only
+// prints the location of anonymous records, named records are resolved to the location of their definition: clang
+// dumps layouts as it completes definitions, so the n-th layout of a name is that of the n-th definition of the name
+// in post-order.
+func applyRecordLayouts(tu *TranslationUnitDecl, layouts []rawRecordLayout) {
+	// Clang spells anonymous records named by a typedef with the name of the typedef.
+	typedefNames := make(map[*RecordDecl]string)
+	PreOrderVisit(tu, func(n Node, depth int) error {
+		if td, ok := n.(*TypedefDecl); ok {
+			if d := AsRecordDecl(td.TagDecl()); d != nil && d.Name == "" {
+				typedefNames[d] = d.TagUsed + " " + td.Name
+			}
+		}
+		return nil
+	})
+
+	var records []*RecordDecl
+	defs := make(map[string][]string)
+	PostOrderVisit(tu, func(n Node, depth int) error {
+		d := AsRecordDecl(n)
+		if d == nil || !d.CompleteDefinition {
+			return nil
+		}
+
+		records = append(records, d)
+		switch {
+		case d.Name != "":
+			name := d.TagUsed + " " + d.Name
+			defs[name] = append(defs[name], declLoc(&d.BaseNode))
+		case typedefNames[d] != "":
+			defs[typedefNames[d]] = append(defs[typedefNames[d]], declLoc(&d.BaseNode))
+		}
+		return nil
+	})
+
+	byLoc := make(map[string]rawRecordLayout)
+	seen := make(map[string]int)
+	for _, l := range layouts {
+		if loc, ok := anonLoc(l.typ); ok {
+			byLoc[loc] = l
+			continue
+		}
+
+		if i := seen[l.typ]; i < len(defs[l.typ]) {
+			byLoc[defs[l.typ][i]] = l
+		}
+		seen[l.typ]++
+	}
+
+	for _, d := range records {
+		l, ok := byLoc[declLoc(&d.BaseNode)]
+		if !ok {
+			continue
+		}
+
+		d.Layout = &RecordLayout{
+			Size:      l.size / 8,
+			Alignment: l.alignment / 8,
+		}
+
+		i := 0
+		for _, child := range d.Inner {
+			f, ok := child.(*FieldDecl)
+			if !ok {
+				continue
+			}
+			if i >= len(l.fieldOffsets) {
+				break
+			}
+
+			off := l.fieldOffsets[i]
+			f.Layout = &FieldLayout{
+				ByteOffset: off / 8,
+				BitOffset:  off,
+				BitWidth:   f.BitfieldWidth(),
+			}
+			i++
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package goclangast
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// testdata/layout/records.json is the output of clang -Xclang -ast-dump=json -fsyntax-only for records.c on x86_64, and
+// records.layout the output of clang -Xclang -fdump-record-layouts-simple -Xclang -fdump-record-layouts-complete
+// -fsyntax-only.
+
+func TestRecordLayouts(t *testing.T) {
+	tu := parseTestdata(t, "testdata/layout/records.json")
+
+	f, err := os.Open("testdata/layout/records.layout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	layouts, err := parseRecordLayouts(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layouts) != 6 {
+		t.Fatalf("got %d layouts, want 6", len(layouts))
+	}
+
+	applyRecordLayouts(tu, layouts)
+
+	type layout struct {
+		loc     string
+		size    int
+		align   int
+		offsets []int
+	}
+	// In pre-order: the global struct pair, struct outer and the anonymous struct inside it, the struct named by
+	// named_t, the struct declared by DECLARE_ANON and the struct pair local to fn.
+	want := []layout{
+		{"records.c:3:8", 16, 8, []int{0, 64}},
+		{"records.c:8:8", 8, 4, []int{0, 32}},
+		{"records.c:9:2", 4, 2, []int{0, 16}},
+		{"records.c:16:9", 8, 8, []int{0}},
+		{"records.c:20:1", 4, 4, []int{0}},
+		{"records.c:24:9", 1, 1, []int{0}},
+	}
+
+	var got []layout
+	for _, n := range nodesOfKind(tu, "RecordDecl") {
+		d := n.(*RecordDecl)
+		if d.Layout == nil {
+			t.Errorf("record at %s has no layout", declLoc(&d.BaseNode))
+			continue
+		}
+
+		l := layout{loc: declLoc(&d.BaseNode), size: d.Layout.Size, align: d.Layout.Alignment}
+		for _, c := range d.Inner {
+			if f, ok := c.(*FieldDecl); ok && f.Layout != nil {
+				l.offsets = append(l.offsets, f.Layout.BitOffset)
+			}
+		}
+		got = append(got, l)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got layouts %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,220 @@
+// Package match implements composable matchers over goclangast nodes, modelled on the AST matchers of clang. For
+// example, to find calls to foo and bind the first argument:
+//
+//	m := match.CallExpr(
+//		match.Callee(match.FunctionDecl(match.HasName("foo"))),
+//		match.HasArgument(0, match.Anything().Bind("arg")),
+//	)
+//	for _, r := range match.Match(tu, m) {
+//		fmt.Println(r.Bindings["arg"])
+//	}
+//
+// Node matchers such as FunctionDecl and CallExpr match a node type and all of their arguments, narrowing matchers
+// such as HasName check properties of the node and traversal matchers such as Has and HasDescendant match related
+// nodes. Like in clang, FunctionDecl, RecordDecl and CallExpr also match the C++ nodes derived from them, such as
+// CXXMethodDecl, CXXRecordDecl and CXXMemberCallExpr.
+package match
+
+import "github.com/dylandreimerink/goclangast"
+
+// Bindings maps the names given to Matcher.Bind to the nodes matched.
+type Bindings map[string]goclangast.Node
+
+// Result is a node matched by Match, with the bindings made while matching it.
+type Result struct {
+	Node     goclangast.Node
+	Bindings Bindings
+}
+
+// Matcher matches a node. The zero value matches nothing.
+type Matcher struct {
+	fn func(n goclangast.Node, b *binder) bool
+}
+
+// binder records bindings, the bindings of failed alternatives are discarded by resetting to an earlier mark.
+type binder struct {
+	names []string
+	nodes []goclangast.Node
+}
+
+func (b *binder) mark() int {
+	return len(b.names)
+}
+
+func (b *binder) reset(mark int) {
+	b.names = b.names[:mark]
+	b.nodes = b.nodes[:mark]
+}
+
+func (b *binder) bindings() Bindings {
+	bindings := make(Bindings, len(b.names))
+	for i, name := range b.names {
+		bindings[name] = b.nodes[i]
+	}
+	return bindings
+}
+
+func (m Matcher) match(n goclangast.Node, b *binder) bool {
+	if m.fn == nil || n == nil {
+		return false
+	}
+
+	mark := b.mark()
+	if !m.fn(n, b) {
+		b.reset(mark)
+		return false
+	}
+	return true
+}
+
+// Bind returns a matcher which binds the node matched by m to name.
+func (m Matcher) Bind(name string) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		if !m.match(n, b) {
+			return false
+		}
+
+		b.names = append(b.names, name)
+		b.nodes = append(b.nodes, n)
+		return true
+	}}
+}
+
+// Matches reports whether m matches n, and returns the bindings made if it does.
+func (m Matcher) Matches(n goclangast.Node) (Bindings, bool) {
+	var b binder
+	if !m.match(n, &b) {
+		return nil, false
+	}
+	return b.bindings(), true
+}
+
+// Match returns the nodes in the tree rooted at root matched by m, in pre-order.
+func Match(root goclangast.Node, m Matcher) []Result {
+	var results []Result
+	goclangast.Inspect(root, func(n goclangast.Node) bool {
+		if n == nil {
+			return false
+		}
+
+		if bindings, ok := m.Matches(n); ok {
+			results = append(results, Result{Node: n, Bindings: bindings})
+		}
+		return true
+	})
+	return results
+}
+
+// Anything matches any node.
+func Anything() Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		return true
+	}}
+}
+
+// AllOf matches nodes matched by all of ms.
+func AllOf(ms ...Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		for _, m := range ms {
+			if !m.match(n, b) {
+				return false
+			}
+		}
+		return true
+	}}
+}
+
+// AnyOf matches nodes matched by any of ms, the bindings of the first matcher which matches are kept.
+func AnyOf(ms ...Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		for _, m := range ms {
+			if m.match(n, b) {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// Unless matches nodes not matched by m.
+func Unless(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		mark := b.mark()
+		defer b.reset(mark)
+		return !m.match(n, b)
+	}}
+}
+
+// Has matches nodes with a child matched by m.
+func Has(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		for _, c := range n.Children() {
+			if m.match(c, b) {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// HasDescendant matches nodes with a descendant matched by m.
+func HasDescendant(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		for _, c := range n.Children() {
+			if c != nil && hasSelfOrDescendant(c, m, b) {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+func hasSelfOrDescendant(n goclangast.Node, m Matcher, b *binder) bool {
+	if m.match(n, b) {
+		return true
+	}
+
+	for _, c := range n.Children() {
+		if c != nil && hasSelfOrDescendant(c, m, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasParent matches nodes whose parent is matched by m.
+func HasParent(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		return m.match(n.Parent(), b)
+	}}
+}
+
+// HasAncestor matches nodes with an ancestor matched by m.
+func HasAncestor(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		for p := n.Parent(); p != nil; p = p.Parent() {
+			if m.match(p, b) {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// IgnoringParenImpCasts matches expressions matched by m after stripping the ParenExpr's and ImplicitCastExpr's
+// around them.
+func IgnoringParenImpCasts(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		for {
+			switch e := n.(type) {
+			case *goclangast.ParenExpr:
+				n = e.SubExpr()
+				continue
+			case *goclangast.ImplicitCastExpr:
+				n = e.SubExpr()
+				continue
+			}
+			return m.match(n, b)
+		}
+	}}
+}
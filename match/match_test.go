@@ -0,0 +1,220 @@
+package match
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/dylandreimerink/goclangast"
+)
+
+// loadTestTU parses testdata/stmts, returning the AST and the source to describe nodes with.
+func loadTestTU(t *testing.T) (*goclangast.TranslationUnitDecl, []byte) {
+	t.Helper()
+
+	b, err := os.ReadFile("../testdata/stmts/stmts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tu, err := goclangast.ParseTU(bytes.NewBuffer(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := os.ReadFile("../testdata/stmts/stmts.c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tu, src
+}
+
+// describe returns the kind of n followed by its source text.
+func describe(src []byte, n goclangast.Node) string {
+	bn := n.GetBaseNode()
+	if bn.Range == nil || bn.Range.Begin == nil || bn.Range.End == nil || bn.Range.End.TokLen == 0 {
+		return bn.Kind
+	}
+	return bn.Kind + " " + string(src[bn.Range.Begin.Offset:bn.Range.End.Offset+bn.Range.End.TokLen])
+}
+
+// describeResults describes the nodes and bindings of rs, bindings are listed after the node as name=node.
+func describeResults(src []byte, rs []Result) []string {
+	var out []string
+	for _, r := range rs {
+		s := describe(src, r.Node)
+
+		var names []string
+		for name := range r.Bindings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			s += "; " + name + "=" + describe(src, r.Bindings[name])
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestMatch(t *testing.T) {
+	tu, src := loadTestTU(t)
+
+	for _, tt := range []struct {
+		name string
+		m    Matcher
+		want []string
+	}{
+		{"zero", Matcher{}, nil},
+		{"anything", ReturnStmt(Anything()), []string{"ReturnStmt return a + b", "ReturnStmt return total"}},
+
+		{"AllOf", AllOf(VarDecl(), HasType("int")), []string{
+			"VarDecl static int counter", "VarDecl int total = add(p->x, p->y)",
+		}},
+		{"AllOf none", AllOf(VarDecl(), ParmVarDecl()), nil},
+		{"AllOf empty", ForStmt(AllOf()), []string{"ForStmt for (;;)\n\t\tbreak"}},
+		{"AnyOf", VarDecl(AnyOf(HasName("a"), HasName("counter"))), []string{
+			"VarDecl static int counter", "VarDecl int a[4]",
+		}},
+		{"AnyOf empty", ForStmt(AnyOf()), nil},
+		{"Unless", VarDecl(Unless(HasType("int"))), []string{"VarDecl int a[4]"}},
+		{"Unless nested", ParmVarDecl(Unless(AnyOf(HasName("a"), HasName("b"))), Unless(HasName("fn"))), []string{
+			"ParmVarDecl point_t *p",
+		}},
+
+		{"Has", CaseStmt(Has(GotoStmt())), []string{"CaseStmt case 2:\n\t\tgoto out"}},
+		{"Has only children", CompoundStmt(Has(GotoStmt())), nil},
+		{"HasDescendant", FunctionDecl(HasDescendant(GotoStmt())), []string{describe(src, tu.Inner[5])}},
+		{"HasDescendant excludes self", GotoStmt(HasDescendant(GotoStmt())), nil},
+		{"HasDescendant deep", CaseStmt(HasDescendant(GotoStmt())), []string{
+			"CaseStmt case 1:\n\tcase 2:\n\t\tgoto out", "CaseStmt case 2:\n\t\tgoto out",
+		}},
+		{"HasParent", BinaryOperator(HasParent(CaseStmt())), []string{"BinaryOperator total = 1"}},
+		{"HasAncestor", DeclRefExpr(To(HasName("total")), HasAncestor(SwitchStmt())), []string{
+			"DeclRefExpr total", "DeclRefExpr total",
+		}},
+		{"HasAncestor excludes self", SwitchStmt(HasAncestor(SwitchStmt())), nil},
+
+		{"Callee", CallExpr(Callee(FunctionDecl(HasName("add")))), []string{"CallExpr add(p->x, p->y)"}},
+		{"Callee expression", CallExpr(Callee(IgnoringParenImpCasts(DeclRefExpr(To(ParmVarDecl()))))), []string{
+			"CallExpr fn(a[1], 1[a])",
+		}},
+		{"Member", MemberExpr(Member(FieldDecl(HasName("y")))), []string{"MemberExpr p->y"}},
+		{"HasCondition nil slot", ForStmt(HasCondition(Anything())), nil},
+		{"HasBody", ForStmt(HasBody(Kind("BreakStmt"))), []string{"ForStmt for (;;)\n\t\tbreak"}},
+	} {
+		if got := describeResults(src, Match(tu, tt.m)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: matched %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBindings(t *testing.T) {
+	tu, src := loadTestTU(t)
+
+	for _, tt := range []struct {
+		name string
+		m    Matcher
+		want []string
+	}{
+		{"Bind", CallExpr(
+			Callee(FunctionDecl(HasName("add")).Bind("callee")),
+			HasArgument(0, IgnoringParenImpCasts(MemberExpr().Bind("arg"))),
+		).Bind("call"), []string{
+			"CallExpr add(p->x, p->y); arg=MemberExpr p->x; call=CallExpr add(p->x, p->y); " +
+				"callee=FunctionDecl int add(int a, int b)\n{\n\treturn a + b;\n}",
+		}},
+		// Both operand orders of the subscript bind the array as base.
+		{"subscript", ArraySubscriptExpr(
+			HasBase(IgnoringParenImpCasts(DeclRefExpr().Bind("base"))),
+			HasIndex(IntegerLiteral().Bind("index")),
+		), []string{
+			"ArraySubscriptExpr a[1]; base=DeclRefExpr a; index=IntegerLiteral 1",
+			"ArraySubscriptExpr 1[a]; base=DeclRefExpr a; index=IntegerLiteral 1",
+		}},
+		// The bindings of a failed alternative are discarded.
+		{"AnyOf", GotoStmt(AnyOf(
+			AllOf(HasAncestor(CaseStmt().Bind("case")), HasAncestor(ForStmt())),
+			HasAncestor(SwitchStmt().Bind("switch")),
+		)), []string{
+			"GotoStmt goto out; switch=" + describe(src, tu.Inner[5].Children()[2].Children()[3]),
+		}},
+		// HasAncestor binds the nearest ancestor matched.
+		{"HasAncestor", GotoStmt(HasAncestor(CaseStmt().Bind("case"))), []string{
+			"GotoStmt goto out; case=CaseStmt case 2:\n\t\tgoto out",
+		}},
+		// Unless never keeps bindings, the matcher it negates either failed or the Unless failed.
+		{"Unless", Kind("BreakStmt", Unless(HasParent(ForStmt().Bind("for")))), []string{"BreakStmt break"}},
+		{"Unless matched", ForStmt(Unless(Anything().Bind("any"))), nil},
+	} {
+		if got := describeResults(src, Match(tu, tt.m)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: matched %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tu, _ := loadTestTU(t)
+	apply := tu.Inner[5]
+
+	bindings, ok := FunctionDecl(HasName("apply"), HasBody(CompoundStmt().Bind("body"))).Bind("fn").Matches(apply)
+	if !ok {
+		t.Fatal("apply not matched")
+	}
+	if len(bindings) != 2 || bindings["fn"] != apply || bindings["body"] != apply.Children()[2] {
+		t.Errorf("bindings are %v, want fn and body of apply", bindings)
+	}
+
+	if bindings, ok := FunctionDecl(HasName("add")).Bind("fn").Matches(apply); ok || bindings != nil {
+		t.Errorf("matched apply as add with bindings %v", bindings)
+	}
+	if _, ok := Anything().Matches(nil); ok {
+		t.Error("matched nil")
+	}
+}
+
+// TestCXXNodes checks that the matchers of C declarations and calls match the C++ nodes embedding them.
+func TestCXXNodes(t *testing.T) {
+	b, err := os.ReadFile("../testdata/cxx/classes.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tu, err := goclangast.ParseTU(bytes.NewBuffer(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		m    Matcher
+		want []string
+	}{
+		{"methods", FunctionDecl(HasName("area")), []string{"CXXMethodDecl area", "CXXMethodDecl area"}},
+		{"method definition", FunctionDecl(MatchesName("^(area|unit)$"), IsDefinition()), nil},
+		{"record", RecordDecl(HasName("Square"), IsDefinition()), []string{"CXXRecordDecl Square"}},
+		{"calls", CallExpr(), []string{"CallExpr", "CXXOperatorCallExpr", "CXXMemberCallExpr"}},
+		{"method call", CallExpr(Callee(FunctionDecl(HasName("area"))), ArgumentCountIs(0)), []string{
+			"CXXMemberCallExpr",
+		}},
+		{"operator call", CallExpr(Callee(FunctionDecl(HasName("operator+"))), HasArgument(1, Anything())), []string{
+			"CXXOperatorCallExpr",
+		}},
+		{"templates", AnyOf(HasName("Box"), HasName("as"), HasName("T")), []string{
+			"FunctionTemplateDecl as", "CXXMethodDecl as",
+			"ClassTemplateDecl Box", "TemplateTypeParmDecl T", "CXXRecordDecl Box", "CXXRecordDecl Box",
+		}},
+	} {
+		var got []string
+		for _, r := range Match(tu, tt.m) {
+			s := r.Node.GetBaseNode().Kind
+			if d, ok := r.Node.(goclangast.NamedDecl); ok {
+				s += " " + d.DeclName()
+			}
+			got = append(got, s)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: matched %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
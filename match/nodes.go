@@ -0,0 +1,370 @@
+package match
+
+import (
+	"reflect"
+	"regexp"
+
+	"github.com/dylandreimerink/goclangast"
+)
+
+// node matches nodes of type T matched by all of ms.
+func node[T goclangast.Node](ms ...Matcher) Matcher {
+	all := AllOf(ms...)
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		if _, ok := n.(T); !ok {
+			return false
+		}
+		return all.match(n, b)
+	}}
+}
+
+// nodeOf matches nodes for which as returns non-nil matched by all of ms, for node types which other node types embed,
+// such as FunctionDecl in the C++ methods.
+func nodeOf[T any](as func(goclangast.Node) *T, ms ...Matcher) Matcher {
+	all := AllOf(ms...)
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		return as(n) != nil && all.match(n, b)
+	}}
+}
+
+// Kind matches nodes of the given kind matched by all of ms, for node types without a dedicated matcher.
+func Kind(kind string, ms ...Matcher) Matcher {
+	all := AllOf(ms...)
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		return n.GetBaseNode().Kind == kind && all.match(n, b)
+	}}
+}
+
+func TranslationUnitDecl(ms ...Matcher) Matcher { return node[*goclangast.TranslationUnitDecl](ms...) }
+func FunctionDecl(ms ...Matcher) Matcher        { return nodeOf(goclangast.AsFunctionDecl, ms...) }
+func VarDecl(ms ...Matcher) Matcher             { return node[*goclangast.VarDecl](ms...) }
+func ParmVarDecl(ms ...Matcher) Matcher         { return node[*goclangast.ParmVarDecl](ms...) }
+func FieldDecl(ms ...Matcher) Matcher           { return node[*goclangast.FieldDecl](ms...) }
+func RecordDecl(ms ...Matcher) Matcher          { return nodeOf(goclangast.AsRecordDecl, ms...) }
+func TypedefDecl(ms ...Matcher) Matcher         { return node[*goclangast.TypedefDecl](ms...) }
+func EnumDecl(ms ...Matcher) Matcher            { return node[*goclangast.EnumDecl](ms...) }
+func EnumConstantDecl(ms ...Matcher) Matcher    { return node[*goclangast.EnumConstantDecl](ms...) }
+func LabelDecl(ms ...Matcher) Matcher           { return node[*goclangast.LabelDecl](ms...) }
+
+func CallExpr(ms ...Matcher) Matcher            { return nodeOf(goclangast.AsCallExpr, ms...) }
+func DeclRefExpr(ms ...Matcher) Matcher         { return node[*goclangast.DeclRefExpr](ms...) }
+func MemberExpr(ms ...Matcher) Matcher          { return node[*goclangast.MemberExpr](ms...) }
+func ArraySubscriptExpr(ms ...Matcher) Matcher  { return node[*goclangast.ArraySubscriptExpr](ms...) }
+func ImplicitCastExpr(ms ...Matcher) Matcher    { return node[*goclangast.ImplicitCastExpr](ms...) }
+func CStyleCastExpr(ms ...Matcher) Matcher      { return node[*goclangast.CStyleCastExpr](ms...) }
+func ParenExpr(ms ...Matcher) Matcher           { return node[*goclangast.ParenExpr](ms...) }
+func BinaryOperator(ms ...Matcher) Matcher      { return node[*goclangast.BinaryOperator](ms...) }
+func UnaryOperator(ms ...Matcher) Matcher       { return node[*goclangast.UnaryOperator](ms...) }
+func ConditionalOperator(ms ...Matcher) Matcher { return node[*goclangast.ConditionalOperator](ms...) }
+func IntegerLiteral(ms ...Matcher) Matcher      { return node[*goclangast.IntegerLiteral](ms...) }
+func StringLiteral(ms ...Matcher) Matcher       { return node[*goclangast.StringLiteral](ms...) }
+
+func CompoundStmt(ms ...Matcher) Matcher { return node[*goclangast.CompoundStmt](ms...) }
+func ReturnStmt(ms ...Matcher) Matcher   { return node[*goclangast.ReturnStmt](ms...) }
+func IfStmt(ms ...Matcher) Matcher       { return node[*goclangast.IfStmt](ms...) }
+func ForStmt(ms ...Matcher) Matcher      { return node[*goclangast.ForStmt](ms...) }
+func WhileStmt(ms ...Matcher) Matcher    { return node[*goclangast.WhileStmt](ms...) }
+func DoStmt(ms ...Matcher) Matcher       { return node[*goclangast.DoStmt](ms...) }
+func SwitchStmt(ms ...Matcher) Matcher   { return node[*goclangast.SwitchStmt](ms...) }
+func CaseStmt(ms ...Matcher) Matcher     { return node[*goclangast.CaseStmt](ms...) }
+func GotoStmt(ms ...Matcher) Matcher     { return node[*goclangast.GotoStmt](ms...) }
+
+// HasName matches declarations with the given name.
+func HasName(name string) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		d, ok := n.(goclangast.NamedDecl)
+		return ok && d.DeclName() == name
+	}}
+}
+
+// MatchesName matches declarations whose name matches the regular expression, it panics if expr is invalid.
+func MatchesName(expr string) Matcher {
+	re := regexp.MustCompile(expr)
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		d, ok := n.(goclangast.NamedDecl)
+		return ok && re.MatchString(d.DeclName())
+	}}
+}
+
+// HasOperatorName matches unary, binary and compound assignment operators with the given opcode, such as "+" or "&=".
+func HasOperatorName(op string) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		switch n := n.(type) {
+		case *goclangast.BinaryOperator:
+			return n.Opcode == op
+		case *goclangast.UnaryOperator:
+			return n.Opcode == op
+		case *goclangast.CompoundAssignOperator:
+			return n.Opcode == op
+		}
+		return false
+	}}
+}
+
+// HasCastKind matches implicit and C-style casts of the given kind, such as "LValueToRValue".
+func HasCastKind(kind string) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		switch n := n.(type) {
+		case *goclangast.ImplicitCastExpr:
+			return n.CastKind == kind
+		case *goclangast.CStyleCastExpr:
+			return n.CastKind == kind
+		}
+		return false
+	}}
+}
+
+var typeType = reflect.TypeOf(goclangast.Type{})
+
+// HasType matches expressions and declarations whose type, or desugared type, is spelled as qualType.
+func HasType(qualType string) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		v := reflect.ValueOf(n)
+		if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return false
+		}
+
+		f := v.Elem().FieldByName("Type")
+		if !f.IsValid() || f.Type() != typeType {
+			return false
+		}
+
+		t := f.Interface().(goclangast.Type)
+		return t.QualType == qualType || t.DesugaredQualType == qualType
+	}}
+}
+
+// IsDefinition matches function declarations with a body and complete record definitions.
+func IsDefinition() Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		if fn := goclangast.AsFunctionDecl(n); fn != nil {
+			for _, c := range fn.Inner {
+				if _, ok := c.(*goclangast.CompoundStmt); ok {
+					return true
+				}
+			}
+		}
+		if rec := goclangast.AsRecordDecl(n); rec != nil {
+			return rec.CompleteDefinition
+		}
+		return false
+	}}
+}
+
+// Callee matches calls whose callee is matched by m. m is tried on the called FunctionDecl first, then on the callee
+// expression.
+func Callee(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		call := goclangast.AsCallExpr(n)
+		if call == nil {
+			return false
+		}
+
+		if d := call.CalleeDecl(); d != nil && m.match(d, b) {
+			return true
+		}
+		return m.match(call.Callee(), b)
+	}}
+}
+
+// HasArgument matches calls whose i'th argument is matched by m.
+func HasArgument(i int, m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		call := goclangast.AsCallExpr(n)
+		if call == nil || i < 0 || i >= len(call.Args()) {
+			return false
+		}
+		return m.match(call.Args()[i], b)
+	}}
+}
+
+// HasAnyArgument matches calls with an argument matched by m.
+func HasAnyArgument(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		call := goclangast.AsCallExpr(n)
+		if call == nil {
+			return false
+		}
+
+		for _, arg := range call.Args() {
+			if m.match(arg, b) {
+				return true
+			}
+		}
+		return false
+	}}
+}
+
+// ArgumentCountIs matches calls with count arguments.
+func ArgumentCountIs(count int) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		call := goclangast.AsCallExpr(n)
+		return call != nil && len(call.Args()) == count
+	}}
+}
+
+// To matches DeclRefExpr's referencing a declaration matched by m.
+func To(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		ref, ok := n.(*goclangast.DeclRefExpr)
+		return ok && m.match(ref.Decl(), b)
+	}}
+}
+
+// Member matches MemberExpr's accessing a member matched by m.
+func Member(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		e, ok := n.(*goclangast.MemberExpr)
+		return ok && m.match(e.MemberDecl(), b)
+	}}
+}
+
+// HasObjectExpression matches MemberExpr's whose base is matched by m.
+func HasObjectExpression(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		e, ok := n.(*goclangast.MemberExpr)
+		return ok && m.match(e.Base(), b)
+	}}
+}
+
+// HasLHS matches binary operators and array subscripts whose left-hand side is matched by m.
+func HasLHS(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		switch n := n.(type) {
+		case *goclangast.BinaryOperator:
+			return m.match(n.LHS(), b)
+		case *goclangast.CompoundAssignOperator:
+			return m.match(n.LHS(), b)
+		case *goclangast.ArraySubscriptExpr:
+			return m.match(n.LHS(), b)
+		}
+		return false
+	}}
+}
+
+// HasRHS matches binary operators and array subscripts whose right-hand side is matched by m.
+func HasRHS(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		switch n := n.(type) {
+		case *goclangast.BinaryOperator:
+			return m.match(n.RHS(), b)
+		case *goclangast.CompoundAssignOperator:
+			return m.match(n.RHS(), b)
+		case *goclangast.ArraySubscriptExpr:
+			return m.match(n.RHS(), b)
+		}
+		return false
+	}}
+}
+
+// HasEitherOperand matches binary operators with an operand matched by m.
+func HasEitherOperand(m Matcher) Matcher {
+	return AnyOf(HasLHS(m), HasRHS(m))
+}
+
+// HasUnaryOperand matches unary operators whose operand is matched by m.
+func HasUnaryOperand(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		op, ok := n.(*goclangast.UnaryOperator)
+		return ok && m.match(op.SubExpr(), b)
+	}}
+}
+
+// HasSourceExpression matches casts whose operand is matched by m.
+func HasSourceExpression(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		switch n := n.(type) {
+		case *goclangast.ImplicitCastExpr:
+			return m.match(n.SubExpr(), b)
+		case *goclangast.CStyleCastExpr:
+			return m.match(n.SubExpr(), b)
+		}
+		return false
+	}}
+}
+
+// HasBase matches array subscripts whose pointer or array operand is matched by m.
+func HasBase(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		e, ok := n.(*goclangast.ArraySubscriptExpr)
+		return ok && m.match(e.Base(), b)
+	}}
+}
+
+// HasIndex matches array subscripts whose index is matched by m.
+func HasIndex(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		e, ok := n.(*goclangast.ArraySubscriptExpr)
+		return ok && m.match(e.Index(), b)
+	}}
+}
+
+// HasCondition matches if, loop, switch statements and conditional operators whose condition is matched by m.
+func HasCondition(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		switch n := n.(type) {
+		case *goclangast.IfStmt:
+			return m.match(n.Cond(), b)
+		case *goclangast.ForStmt:
+			return m.match(n.Cond(), b)
+		case *goclangast.WhileStmt:
+			return m.match(n.Cond(), b)
+		case *goclangast.DoStmt:
+			return m.match(n.Cond(), b)
+		case *goclangast.SwitchStmt:
+			return m.match(n.Cond(), b)
+		case *goclangast.ConditionalOperator:
+			return m.match(n.Cond(), b)
+		}
+		return false
+	}}
+}
+
+// HasThen matches if statements whose then branch is matched by m.
+func HasThen(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		s, ok := n.(*goclangast.IfStmt)
+		return ok && m.match(s.Then(), b)
+	}}
+}
+
+// HasElse matches if statements whose else branch is matched by m.
+func HasElse(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		s, ok := n.(*goclangast.IfStmt)
+		return ok && m.match(s.Else(), b)
+	}}
+}
+
+// HasBody matches loops, switch statements and function definitions whose body is matched by m.
+func HasBody(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		switch n := n.(type) {
+		case *goclangast.ForStmt:
+			return m.match(n.Body(), b)
+		case *goclangast.WhileStmt:
+			return m.match(n.Body(), b)
+		case *goclangast.DoStmt:
+			return m.match(n.Body(), b)
+		case *goclangast.SwitchStmt:
+			return m.match(n.Body(), b)
+		}
+
+		if fn := goclangast.AsFunctionDecl(n); fn != nil {
+			for _, c := range fn.Inner {
+				if body, ok := c.(*goclangast.CompoundStmt); ok {
+					return m.match(body, b)
+				}
+			}
+		}
+		return false
+	}}
+}
+
+// HasReturnValue matches return statements whose value is matched by m.
+func HasReturnValue(m Matcher) Matcher {
+	return Matcher{func(n goclangast.Node, b *binder) bool {
+		s, ok := n.(*goclangast.ReturnStmt)
+		return ok && m.match(s.Value(), b)
+	}}
+}
@@ -0,0 +1,99 @@
+package goclangast
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type rawOMPClause struct {
+	kind     string
+	implicit bool
+}
+
+// parseOpenMPClauses parses the text dump of an AST and returns the clauses of every OpenMP directive, in the order the
+// directives appear in the dump.
+func parseOpenMPClauses(r io.Reader) ([][]rawOMPClause, error) {
+	var (
+		directives [][]rawOMPClause
+		// path holds, for every depth of the node on the current line, the index of the directive at that depth or -1
+		// if the node at that depth isn't a directive.
+		path []int
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		node := strings.TrimLeft(line, " |`-")
+		depth := (len(line) - len(node)) / 2
+
+		for len(path) < depth {
+			path = append(path, -1)
+		}
+		path = append(path[:depth], -1)
+
+		kind, _, _ := strings.Cut(node, " ")
+		switch {
+		case strings.HasPrefix(kind, "OMP") && strings.HasSuffix(kind, "Directive"):
+			path[depth] = len(directives)
+			directives = append(directives, []rawOMPClause{})
+
+		case isOMPClauseKind(kind) && depth > 0 && path[depth-1] >= 0:
+			i := path[depth-1]
+			directives[i] = append(directives[i], rawOMPClause{
+				kind:     kind,
+				implicit: strings.HasSuffix(node, " <implicit>"),
+			})
+		}
+	}
+
+	return directives, scanner.Err()
+}
+
+// applyOpenMPClauses sets the kind of the clauses of the directives in the AST, which are matched to the directives
+// of the text dump by order. Where the dumps disagree the kinds are left unknown and a warning is returned, so the
+// rest of the AST is still usable.
+func applyOpenMPClauses(tu *TranslationUnitDecl, directives [][]rawOMPClause) []Diagnostic {
+	var found []*OMPExecutableDirective
+	PreOrderVisit(tu, func(n Node, depth int) error {
+		if d := asOMPDirective(n); d != nil {
+			found = append(found, d)
+		}
+		return nil
+	})
+
+	if len(found) != len(directives) {
+		return []Diagnostic{{
+			Severity: SeverityWarning,
+			Message: fmt.Sprintf("openmp clauses: %d directives in the text dump, %d in the AST, clause kinds are unknown",
+				len(directives), len(found)),
+		}}
+	}
+
+	var diags []Diagnostic
+	for i, d := range found {
+		clauses := d.Clauses()
+		if len(clauses) != len(directives[i]) {
+			var loc *Loc
+			if d.Range != nil {
+				loc = d.Range.Begin
+			}
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Loc:      loc,
+				Message: fmt.Sprintf("openmp clauses: %s %s has %d clauses in the text dump, %d in the AST, clause "+
+					"kinds are unknown", d.Kind, d.ID, len(directives[i]), len(clauses)),
+			})
+			continue
+		}
+
+		for j, c := range clauses {
+			c.Kind = directives[i][j].kind
+			c.IsImplicit = directives[i][j].implicit
+		}
+	}
+
+	return diags
+}
@@ -0,0 +1,175 @@
+package goclangast
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testdata/openmp/parallel.json and parallel.txt are the JSON and text dumps clang -fopenmp makes of parallel.c, a
+// parallel for loop with private, reduction, collapse and schedule clauses followed by a task with an implicit
+// firstprivate clause.
+
+// ompTestdata returns the JSON dump of parallel.c, its two directives and the clauses of the text dump.
+func ompTestdata(t *testing.T) (*TranslationUnitDecl, *OMPParallelForDirective, *OMPTaskDirective, [][]rawOMPClause) {
+	t.Helper()
+
+	tu := parseTestdata(t, "testdata/openmp/parallel.json")
+	loops := nodesOfKind(tu, "OMPParallelForDirective")
+	tasks := nodesOfKind(tu, "OMPTaskDirective")
+	if len(loops) != 1 || len(tasks) != 1 {
+		t.Fatalf("dump has %d parallel for and %d task directives, want one of each", len(loops), len(tasks))
+	}
+
+	f, err := os.Open("testdata/openmp/parallel.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	directives, err := parseOpenMPClauses(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tu, loops[0].(*OMPParallelForDirective), tasks[0].(*OMPTaskDirective), directives
+}
+
+// varNames returns the names of the variables referenced by the nodes.
+func varNames(t *testing.T, vars []Node) []string {
+	t.Helper()
+
+	var names []string
+	for _, v := range vars {
+		ref, ok := v.(*DeclRefExpr)
+		if !ok {
+			t.Fatalf("clause child is a %T, want a *DeclRefExpr", v)
+		}
+		names = append(names, ref.ReferencedDecl.Name)
+	}
+	return names
+}
+
+func TestOpenMPClauses(t *testing.T) {
+	tu, loop, task, directives := ompTestdata(t)
+
+	want := [][]rawOMPClause{
+		{{kind: OMPClausePrivate}, {kind: OMPClauseReduction}, {kind: OMPClauseCollapse}, {kind: OMPClauseSchedule}},
+		{{kind: OMPClauseFirstprivate, implicit: true}},
+	}
+	if !reflect.DeepEqual(directives, want) {
+		t.Fatalf("text dump has clauses %+v, want %+v", directives, want)
+	}
+
+	// The kindless clause objects of the JSON dump are decoded as clauses preceding the associated statement.
+	if n := len(loop.Clauses()); n != 4 {
+		t.Fatalf("parallel for has %d clauses, want 4", n)
+	}
+	if _, ok := loop.AssociatedStmt().(*CapturedStmt); !ok {
+		t.Errorf("associated statement is a %T, want a *CapturedStmt", loop.AssociatedStmt())
+	}
+
+	// Without the text dump the kinds of the clauses are unknown.
+	if loop.ClauseKindsKnown() {
+		t.Error("clause kinds are known before applying the text dump")
+	}
+	if vars, ok := loop.Private(); ok || vars != nil {
+		t.Errorf("private variables are %v, %v before applying the text dump, want unknown", vars, ok)
+	}
+	if n, ok := loop.Collapse(); ok {
+		t.Errorf("collapse is %d before applying the text dump, want unknown", n)
+	}
+
+	if diags := applyOpenMPClauses(tu, directives); len(diags) != 0 {
+		t.Fatalf("applying the text dump gives diagnostics %+v", diags)
+	}
+
+	if vars, ok := loop.Private(); !ok || !reflect.DeepEqual(varNames(t, vars), []string{"tmp"}) {
+		t.Errorf("private variables are %v, %v, want tmp", vars, ok)
+	}
+	if vars, ok := loop.Reduction(); !ok || !reflect.DeepEqual(varNames(t, vars), []string{"sum"}) {
+		t.Errorf("reduction variables are %v, %v, want sum", vars, ok)
+	}
+	if vars, ok := loop.Shared(); !ok || len(vars) != 0 {
+		t.Errorf("shared variables are %v, %v, want none", vars, ok)
+	}
+	if n, ok := loop.Collapse(); !ok || n != 2 {
+		t.Errorf("collapse is %d, %v, want 2", n, ok)
+	}
+	c, ok := loop.Schedule()
+	if !ok || c == nil {
+		t.Fatalf("schedule is %v, %v, want a clause", c, ok)
+	}
+	if n, ok := EvalInt(childAt(c.Inner, 0)); !ok || n.Int64() != 4 {
+		t.Errorf("chunk size is %v, %v, want 4", n, ok)
+	}
+
+	if vars, ok := task.Firstprivate(); !ok || !reflect.DeepEqual(varNames(t, vars), []string{"sum"}) {
+		t.Errorf("firstprivate variables of the task are %v, %v, want sum", vars, ok)
+	}
+	if c := task.Clauses()[0]; !c.IsImplicit {
+		t.Errorf("clause of the task is %+v, want an implicit clause", c)
+	}
+}
+
+func TestOpenMPStructuredBlock(t *testing.T) {
+	_, loop, task, _ := ompTestdata(t)
+
+	// The structured block is the first child of the CapturedDecl in the CapturedStmt, which also holds the implicit
+	// parameters of the outlined function and is followed by the captured variables.
+	outer := loop.Loop()
+	if outer == nil {
+		t.Fatalf("loop of the parallel for is nil, structured block is a %T", loop.StructuredBlock())
+	}
+	if outer.Range.Begin.Line != 7 {
+		t.Errorf("loop starts on line %d, want 7", outer.Range.Begin.Line)
+	}
+	if outer.Cond() == nil || outer.Inc() == nil {
+		t.Errorf("loop has condition %v and increment %v, want both", outer.Cond(), outer.Inc())
+	}
+	inner, ok := outer.Body().(*ForStmt)
+	if !ok {
+		t.Fatalf("loop body is a %T, want the collapsed *ForStmt", outer.Body())
+	}
+	if _, ok := inner.Body().(*CompoundStmt); !ok {
+		t.Errorf("inner loop body is a %T, want a *CompoundStmt", inner.Body())
+	}
+
+	op, ok := task.StructuredBlock().(*UnaryOperator)
+	if !ok || op.Opcode != "++" {
+		t.Errorf("structured block of the task is %+v, want the increment of sum", task.StructuredBlock())
+	}
+}
+
+func TestOpenMPClausesMismatch(t *testing.T) {
+	tu, loop, task, _ := ompTestdata(t)
+	directives := [][]rawOMPClause{
+		{{kind: OMPClausePrivate}},
+		{{kind: OMPClauseFirstprivate, implicit: true}},
+	}
+
+	// A directive with a different number of clauses keeps unknown kinds, the others are still set.
+	diags := applyOpenMPClauses(tu, directives)
+	if len(diags) != 1 || diags[0].Severity != SeverityWarning || !strings.Contains(diags[0].Message, loop.ID) {
+		t.Errorf("diagnostics are %+v, want a warning about directive %s", diags, loop.ID)
+	}
+	if diags[0].Loc == nil || diags[0].Loc.Line != 6 {
+		t.Errorf("warning is at %+v, want line 6", diags[0].Loc)
+	}
+	if _, ok := loop.Private(); ok {
+		t.Error("clause kinds of the mismatched directive are known")
+	}
+	if vars, ok := task.Firstprivate(); !ok || len(vars) != 1 {
+		t.Errorf("firstprivate variables of the task are %v, %v, want one variable", vars, ok)
+	}
+
+	// A different number of directives leaves all kinds unknown.
+	tu, _, task, _ = ompTestdata(t)
+	diags = applyOpenMPClauses(tu, directives[:1])
+	if len(diags) != 1 || diags[0].Severity != SeverityWarning {
+		t.Errorf("diagnostics are %+v, want a warning", diags)
+	}
+	if task.ClauseKindsKnown() {
+		t.Error("clause kinds are known after a mismatched number of directives")
+	}
+}
@@ -0,0 +1,95 @@
+package goclangast
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Job describes a single file to be parsed by ParseMany.
+type Job struct {
+	Path    string
+	Options Options
+}
+
+// Job returns a Job for the command, using base for everything not specified by the command.
+func (c CompileCommand) Job(base Options) (Job, error) {
+	opts, err := c.Options(base)
+	if err != nil {
+		return Job{}, err
+	}
+
+	return Job{
+		Path:    c.Path(),
+		Options: opts,
+	}, nil
+}
+
+type Result struct {
+	Job         Job
+	TU          *TranslationUnitDecl
+	Diagnostics []Diagnostic
+	Err         error
+}
+
+// ParseMany runs clang and parses the output for all jobs using the given number of workers, zero or less means
+// one worker per CPU. Results are sent on the returned channel in completion order, the channel is closed once all jobs
+// are done. Jobs without a ParseContext share a single one, so strings are interned once across all translation units.
+// Once ctx is done no new jobs are started, jobs which have not been started are reported with ctx.Err() as their
+// error, so every job has exactly one result. The caller must drain the channel.
+func ParseMany(ctx context.Context, jobs []Job, workers int) <-chan Result {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	pctx := NewParseContext()
+	jobCh := make(chan Job)
+	results := make(chan Result, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for job := range jobCh {
+				opts := job.Options
+				if opts.ParseContext == nil {
+					opts.ParseContext = pctx
+				}
+
+				res := Result{Job: job}
+				res.TU, res.Diagnostics, res.Err = NewASTContext(ctx, job.Path, opts)
+				results <- res
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobCh)
+
+		for i, job := range jobs {
+			if ctx.Err() == nil {
+				select {
+				case jobCh <- job:
+					continue
+				case <-ctx.Done():
+				}
+			}
+
+			for _, job := range jobs[i:] {
+				results <- Result{Job: job, Err: ctx.Err()}
+			}
+			return
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
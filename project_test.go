@@ -0,0 +1,92 @@
+package goclangast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func testJobs(clang string, n int) []Job {
+	jobs := make([]Job, n)
+	for i := range jobs {
+		jobs[i] = Job{Path: fmt.Sprintf("job%d.c", i), Options: Options{ClangPath: clang}}
+	}
+	return jobs
+}
+
+// collectResults drains the results and checks that every job is reported exactly once.
+func collectResults(t *testing.T, jobs []Job, results <-chan Result, onResult func(Result)) map[string]Result {
+	t.Helper()
+
+	got := make(map[string]Result)
+	for res := range results {
+		if _, dup := got[res.Job.Path]; dup {
+			t.Errorf("%s is reported twice", res.Job.Path)
+		}
+		got[res.Job.Path] = res
+		if onResult != nil {
+			onResult(res)
+		}
+	}
+
+	for _, job := range jobs {
+		if _, ok := got[job.Path]; !ok {
+			t.Errorf("%s isn't reported", job.Path)
+		}
+	}
+	return got
+}
+
+func TestParseManyCanceledBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	jobs := testJobs("clang-does-not-exist", 5)
+	for path, res := range collectResults(t, jobs, ParseMany(ctx, jobs, 2), nil) {
+		if res.Err != context.Canceled || res.TU != nil {
+			t.Errorf("%s: got TU %v and error %v, want the context error", path, res.TU, res.Err)
+		}
+	}
+}
+
+func TestParseManyCanceled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the clang stub is a shell script")
+	}
+
+	dump, err := filepath.Abs("testdata/roundtrip/loops.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clang := filepath.Join(t.TempDir(), "clang")
+	if err := os.WriteFile(clang, []byte(fmt.Sprintf("#!/bin/sh\nexec cat %q\n", dump)), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel as soon as the first job is done, the jobs after it may or may not have been started by then.
+	jobs := testJobs(clang, 8)
+	got := collectResults(t, jobs, ParseMany(ctx, jobs, 1), func(Result) { cancel() })
+
+	var done, canceled int
+	for path, res := range got {
+		var runErr *RunError
+		switch {
+		case res.Err == nil && res.TU != nil:
+			done++
+		case res.Err == context.Canceled, errors.As(res.Err, &runErr) && runErr.Reason == RunCanceled:
+			canceled++
+		default:
+			t.Errorf("%s: got TU %v and error %v, want a TU or a cancellation", path, res.TU, res.Err)
+		}
+	}
+	if done == 0 || canceled == 0 {
+		t.Errorf("%d jobs are done and %d canceled, want both", done, canceled)
+	}
+}
@@ -0,0 +1,352 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return p.errorAt(p.pos, format, args...)
+}
+
+// errorAt returns an error at the given offset, for errors found after the offending token has been consumed.
+func (p *parser) errorAt(pos int, format string, args ...any) error {
+	return fmt.Errorf("offset %d: %s", pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// skipSpace skips whitespace and reports whether any was skipped.
+func (p *parser) skipSpace() bool {
+	start := p.pos
+	for !p.eof() && strings.IndexByte(" \t\r\n", p.peek()) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+func (p *parser) expect(c byte) error {
+	if p.peek() != c {
+		if p.eof() {
+			return p.errorf("expected '%c', got end of query", c)
+		}
+		return p.errorf("expected '%c', got '%c'", c, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func (p *parser) ident() string {
+	start := p.pos
+	for !p.eof() && isIdentByte(p.peek()) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// selectorList parses comma separated complex selectors, up to the end of the query or a closing parenthesis.
+func (p *parser) selectorList(relative bool) ([]*complexSel, error) {
+	var list []*complexSel
+	for {
+		sel, err := p.complex(relative)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, sel)
+
+		p.skipSpace()
+		if p.peek() != ',' {
+			return list, nil
+		}
+		p.pos++
+	}
+}
+
+func isCombinator(c byte) bool {
+	return c == '>' || c == '+' || c == '~'
+}
+
+func (p *parser) complex(relative bool) (*complexSel, error) {
+	sel := &complexSel{}
+
+	p.skipSpace()
+	if relative {
+		// Relative selectors, as used by :has, are anchored to the node being matched.
+		sel.compounds = append(sel.compounds, &compound{scope: true})
+		comb := byte(' ')
+		if isCombinator(p.peek()) {
+			comb = p.peek()
+			p.pos++
+			p.skipSpace()
+		}
+		sel.combs = append(sel.combs, comb)
+	}
+
+	for {
+		c, err := p.compound()
+		if err != nil {
+			return nil, err
+		}
+		sel.compounds = append(sel.compounds, c)
+
+		space := p.skipSpace()
+		switch {
+		case p.eof() || p.peek() == ',' || p.peek() == ')':
+			return sel, nil
+		case isCombinator(p.peek()):
+			sel.combs = append(sel.combs, p.peek())
+			p.pos++
+			p.skipSpace()
+		case space:
+			sel.combs = append(sel.combs, ' ')
+		default:
+			return nil, p.errorf("unexpected '%c'", p.peek())
+		}
+	}
+}
+
+func (p *parser) compound() (*compound, error) {
+	c := &compound{}
+
+	universal := false
+	switch {
+	case p.peek() == '*':
+		universal = true
+		p.pos++
+	case isIdentByte(p.peek()):
+		c.kind = p.ident()
+	}
+
+	for {
+		switch p.peek() {
+		case '[':
+			a, err := p.attr()
+			if err != nil {
+				return nil, err
+			}
+			c.attrs = append(c.attrs, a)
+
+		case ':':
+			ps, err := p.pseudo()
+			if err != nil {
+				return nil, err
+			}
+			c.pseudos = append(c.pseudos, ps)
+
+		default:
+			if !universal && c.kind == "" && len(c.attrs) == 0 && len(c.pseudos) == 0 {
+				if p.eof() {
+					return nil, p.errorf("expected selector, got end of query")
+				}
+				return nil, p.errorf("expected selector, got '%c'", p.peek())
+			}
+			return c, nil
+		}
+	}
+}
+
+var attrOps = []string{"!=", "^=", "$=", "*=", "~=", "="}
+
+func (p *parser) attr() (*attr, error) {
+	if err := p.expect('['); err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+
+	a := &attr{}
+	for {
+		name := p.ident()
+		if name == "" {
+			return nil, p.errorf("expected field name")
+		}
+		a.path = append(a.path, name)
+
+		if p.peek() != '.' {
+			break
+		}
+		p.pos++
+	}
+
+	p.skipSpace()
+	if p.peek() == ']' {
+		p.pos++
+		return a, nil
+	}
+
+	for _, op := range attrOps {
+		if strings.HasPrefix(p.src[p.pos:], op) {
+			a.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if a.op == "" {
+		return nil, p.errorf("expected operator or ']'")
+	}
+
+	p.skipSpace()
+	start := p.pos
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	a.value = value
+
+	if a.op == "~=" {
+		a.re, err = regexp.Compile(value)
+		if err != nil {
+			return nil, p.errorAt(start, "%v", err)
+		}
+	}
+
+	p.skipSpace()
+	if err := p.expect(']'); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// value parses a quoted string, or an unquoted value up to the closing bracket.
+func (p *parser) value() (string, error) {
+	switch p.peek() {
+	case '"':
+		start := p.pos
+		for p.pos++; !p.eof() && p.peek() != '"'; p.pos++ {
+			if p.peek() == '\\' {
+				p.pos++
+			}
+		}
+		if p.eof() {
+			return "", p.errorAt(start, "unterminated string")
+		}
+		p.pos++
+		s, err := strconv.Unquote(p.src[start:p.pos])
+		if err != nil {
+			return "", p.errorAt(start, "%v", err)
+		}
+		return s, nil
+
+	case '\'':
+		end := strings.IndexByte(p.src[p.pos+1:], '\'')
+		if end < 0 {
+			return "", p.errorf("unterminated string")
+		}
+		p.pos++
+		s := p.src[p.pos : p.pos+end]
+		p.pos += end + 1
+		return s, nil
+	}
+
+	start := p.pos
+	for !p.eof() && p.peek() != ']' {
+		p.pos++
+	}
+	return strings.TrimSpace(p.src[start:p.pos]), nil
+}
+
+func (p *parser) pseudo() (*pseudo, error) {
+	if err := p.expect(':'); err != nil {
+		return nil, err
+	}
+
+	start := p.pos
+	ps := &pseudo{name: p.ident()}
+	switch ps.name {
+	case "root", "empty", "first-child", "last-child":
+		return ps, nil
+
+	case "has", "not", "ref":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+
+		var err error
+		ps.sels, err = p.selectorList(ps.name == "has")
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpace()
+		return ps, p.expect(')')
+
+	case "nth-child":
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+
+		end := strings.IndexByte(p.src[p.pos:], ')')
+		if end < 0 {
+			return nil, p.errorf("expected ')'")
+		}
+
+		var err error
+		ps.a, ps.b, err = parseNth(p.src[p.pos : p.pos+end])
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		p.pos += end + 1
+		return ps, nil
+
+	case "":
+		return nil, p.errorf("expected pseudo-class name")
+	}
+
+	return nil, p.errorAt(start, "unknown pseudo-class ':%s'", ps.name)
+}
+
+// parseNth parses the argument of :nth-child, "odd", "even", "b", "an", or "an+b".
+func parseNth(s string) (a, b int, err error) {
+	s = strings.ReplaceAll(s, " ", "")
+	switch s {
+	case "odd":
+		return 2, 1, nil
+	case "even":
+		return 2, 0, nil
+	}
+
+	an, bs, found := strings.Cut(s, "n")
+	if !found {
+		b, err = strconv.Atoi(s)
+		return 0, b, err
+	}
+
+	switch an {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		if a, err = strconv.Atoi(an); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if bs != "" {
+		if b, err = strconv.Atoi(bs); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return a, b, nil
+}
@@ -0,0 +1,369 @@
+// Package query selects nodes from an AST with CSS-like selectors. A selector consists of compound selectors joined by
+// combinators, for example:
+//
+//	CallExpr:has(> ImplicitCastExpr > DeclRefExpr[ReferencedDecl.Name=bpf_map_lookup_elem])
+//
+// A compound selector is a node kind, or "*" for any kind, followed by any number of predicates:
+//
+//	[Path]          the field is present and not the zero value
+//	[Path=value]    the field equals value
+//	[Path!=value]   the field does not equal value
+//	[Path^=value]   the field starts with value
+//	[Path$=value]   the field ends with value
+//	[Path*=value]   the field contains value
+//	[Path~=regexp]  the field matches the regular expression
+//	:has(sel)       a node relative to this one matches sel, descendants by default, or children, adjacent or following
+//	                siblings if sel starts with '>', '+' or '~'
+//	:not(sel)       the node doesn't match sel
+//	:ref(sel)       the declaration referenced by the node matches sel, see Referenced
+//	:nth-child(an+b), :first-child, :last-child, :root, :empty
+//
+// Paths are dot separated Go field names or JSON keys of the node struct, such as Name, Opcode, CastKind or
+// Type.QualType. Values may be quoted with single or double quotes. The combinators are ' ' (descendant), '>' (child),
+// '+' (adjacent sibling) and '~' (following sibling), and selectors can be grouped with ','.
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/dylandreimerink/goclangast"
+)
+
+// Query is a compiled selector, it is safe for concurrent use.
+type Query struct {
+	src  string
+	sels []*complexSel
+}
+
+// Compile parses a selector.
+func Compile(s string) (*Query, error) {
+	p := parser{src: s}
+	sels, err := p.selectorList(false)
+	if err != nil {
+		return nil, fmt.Errorf("query '%s': %w", s, err)
+	}
+
+	p.skipSpace()
+	if !p.eof() {
+		return nil, fmt.Errorf("query '%s': %w", s, p.errorf("unexpected '%c'", p.peek()))
+	}
+
+	return &Query{src: s, sels: sels}, nil
+}
+
+// MustCompile is like Compile but panics if the selector can't be parsed.
+func MustCompile(s string) *Query {
+	q, err := Compile(s)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+func (q *Query) String() string {
+	return q.src
+}
+
+// Match reports whether n matches the query, combinators are matched against the parents and siblings of n.
+func (q *Query) Match(n goclangast.Node) bool {
+	return matchList(q.sels, n, nil)
+}
+
+// All returns the nodes in the tree rooted at root matching the query, in pre-order.
+func (q *Query) All(root goclangast.Node) []goclangast.Node {
+	var nodes []goclangast.Node
+	goclangast.Inspect(root, func(n goclangast.Node) bool {
+		if n != nil && q.Match(n) {
+			nodes = append(nodes, n)
+		}
+		return true
+	})
+	return nodes
+}
+
+// First returns the first node in the tree rooted at root matching the query, or nil if none does.
+func (q *Query) First(root goclangast.Node) goclangast.Node {
+	var found goclangast.Node
+	goclangast.Inspect(root, func(n goclangast.Node) bool {
+		if found == nil && n != nil && q.Match(n) {
+			found = n
+		}
+		return found == nil
+	})
+	return found
+}
+
+// Referenced returns the declaration a node refers to, as used by :ref. This is the referenced declaration of a
+// DeclRefExpr, the member of a MemberExpr, the callee of a CallExpr and the target of a GotoStmt.
+func Referenced(n goclangast.Node) goclangast.Node {
+	switch n := n.(type) {
+	case *goclangast.DeclRefExpr:
+		return n.Decl()
+	case *goclangast.MemberExpr:
+		return n.MemberDecl()
+	case *goclangast.GotoStmt:
+		if d := n.Target(); d != nil {
+			return d
+		}
+	}
+
+	// Calls include the C++ method and operator calls, which embed CallExpr.
+	if call := goclangast.AsCallExpr(n); call != nil {
+		if d := call.CalleeDecl(); d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+// complexSel is a sequence of compound selectors, combs[i] is the combinator between compounds[i] and compounds[i+1].
+type complexSel struct {
+	compounds []*compound
+	combs     []byte
+}
+
+type compound struct {
+	// scope matches only the node a relative selector is anchored to.
+	scope   bool
+	kind    string
+	attrs   []*attr
+	pseudos []*pseudo
+}
+
+// matchList reports whether n matches any of the selectors, scope is the anchor of relative selectors.
+func matchList(sels []*complexSel, n, scope goclangast.Node) bool {
+	for _, sel := range sels {
+		if sel.match(len(sel.compounds)-1, n, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether n matches compounds[i], and the nodes related to n match the compounds before it.
+func (s *complexSel) match(i int, n, scope goclangast.Node) bool {
+	if !s.compounds[i].match(n, scope) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+
+	switch s.combs[i-1] {
+	case ' ':
+		for p := n.Parent(); p != nil; p = p.Parent() {
+			if s.match(i-1, p, scope) {
+				return true
+			}
+		}
+	case '>':
+		if p := n.Parent(); p != nil {
+			return s.match(i-1, p, scope)
+		}
+	case '+':
+		if p := n.PrevSibling(); p != nil {
+			return s.match(i-1, p, scope)
+		}
+	case '~':
+		for p := n.PrevSibling(); p != nil; p = p.PrevSibling() {
+			if s.match(i-1, p, scope) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (c *compound) match(n, scope goclangast.Node) bool {
+	if c.scope {
+		return n == scope
+	}
+
+	if c.kind != "" && n.GetBaseNode().Kind != c.kind {
+		return false
+	}
+
+	for _, a := range c.attrs {
+		if !a.match(n) {
+			return false
+		}
+	}
+
+	for _, p := range c.pseudos {
+		if !p.match(n) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type attr struct {
+	path  []string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+func (a *attr) match(n goclangast.Node) bool {
+	v, ok := field(reflect.ValueOf(n), a.path)
+	if !ok {
+		return false
+	}
+
+	if a.op == "" {
+		return !v.IsZero()
+	}
+
+	var s string
+	if v.Kind() == reflect.String {
+		s = v.String()
+	} else {
+		s = fmt.Sprint(v.Interface())
+	}
+
+	switch a.op {
+	case "=":
+		return s == a.value
+	case "!=":
+		return s != a.value
+	case "^=":
+		return strings.HasPrefix(s, a.value)
+	case "$=":
+		return strings.HasSuffix(s, a.value)
+	case "*=":
+		return strings.Contains(s, a.value)
+	case "~=":
+		return a.re.MatchString(s)
+	}
+
+	return false
+}
+
+// field resolves a field path, following pointers and embedded structs.
+func field(v reflect.Value, path []string) (reflect.Value, bool) {
+	for _, name := range path {
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+
+		f, ok := structField(v.Type(), name)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		v = v.FieldByIndex(f.Index)
+	}
+
+	return v, true
+}
+
+// structField finds an exported field by Go name or JSON key.
+func structField(t reflect.Type, name string) (reflect.StructField, bool) {
+	if f, ok := t.FieldByName(name); ok && f.IsExported() {
+		return f, true
+	}
+
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+
+		key, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if key == name {
+			return f, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+type pseudo struct {
+	name string
+	sels []*complexSel
+	// a and b are the arguments of :nth-child(an+b).
+	a, b int
+}
+
+func (p *pseudo) match(n goclangast.Node) bool {
+	switch p.name {
+	case "root":
+		return n.Parent() == nil
+	case "empty":
+		return len(n.Children()) == 0
+	case "first-child":
+		return n.Parent() != nil && n.PrevSibling() == nil
+	case "last-child":
+		return n.Parent() != nil && n.NextSibling() == nil
+	case "nth-child":
+		return p.matchNth(n)
+	case "not":
+		return !matchList(p.sels, n, nil)
+	case "ref":
+		ref := Referenced(n)
+		return ref != nil && matchList(p.sels, ref, nil)
+	case "has":
+		return p.matchHas(n)
+	}
+
+	return false
+}
+
+func (p *pseudo) matchNth(n goclangast.Node) bool {
+	if n.Parent() == nil {
+		return false
+	}
+
+	i := 1
+	for s := n.PrevSibling(); s != nil; s = s.PrevSibling() {
+		i++
+	}
+
+	if p.a == 0 {
+		return i == p.b
+	}
+
+	// i = a*k + b must hold for some k >= 0.
+	k := (i - p.b) / p.a
+	return k >= 0 && p.a*k+p.b == i
+}
+
+func (p *pseudo) matchHas(n goclangast.Node) bool {
+	for _, sel := range p.sels {
+		found := false
+		visit := func(c goclangast.Node) bool {
+			if c != nil && !found && sel.match(len(sel.compounds)-1, c, n) {
+				found = true
+			}
+			return !found
+		}
+
+		// Candidates are the descendants of n, or the following siblings of n and their descendants.
+		if sel.combs[0] == '+' || sel.combs[0] == '~' {
+			for s := n.NextSibling(); s != nil && !found; s = s.NextSibling() {
+				goclangast.Inspect(s, visit)
+			}
+		} else {
+			for _, c := range n.Children() {
+				if c != nil && !found {
+					goclangast.Inspect(c, visit)
+				}
+			}
+		}
+
+		if found {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,181 @@
+package query
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/dylandreimerink/goclangast"
+)
+
+// loadTestTU parses testdata/stmts, returning the AST and the source to describe nodes with.
+func loadTestTU(t *testing.T) (*goclangast.TranslationUnitDecl, []byte) {
+	t.Helper()
+
+	b, err := os.ReadFile("../testdata/stmts/stmts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tu, err := goclangast.ParseTU(bytes.NewBuffer(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := os.ReadFile("../testdata/stmts/stmts.c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tu, src
+}
+
+// describe returns the kind of n followed by its source text.
+func describe(src []byte, n goclangast.Node) string {
+	bn := n.GetBaseNode()
+	if bn.Range == nil || bn.Range.Begin == nil || bn.Range.End == nil || bn.Range.End.TokLen == 0 {
+		return bn.Kind
+	}
+	return bn.Kind + " " + string(src[bn.Range.Begin.Offset:bn.Range.End.Offset+bn.Range.End.TokLen])
+}
+
+func TestQuery(t *testing.T) {
+	tu, src := loadTestTU(t)
+
+	for _, tt := range []struct {
+		sel  string
+		want []string
+	}{
+		{"CallExpr", []string{"CallExpr add(p->x, p->y)", "CallExpr fn(a[1], 1[a])"}},
+		{"ReturnStmt", []string{"ReturnStmt return a + b", "ReturnStmt return total"}},
+
+		// Combinators.
+		{"FunctionDecl[Name=add] ParmVarDecl", []string{"ParmVarDecl int a", "ParmVarDecl int b"}},
+		{"FunctionDecl[Name=add] > ImplicitCastExpr", nil},
+		{"CallExpr > ImplicitCastExpr > DeclRefExpr", []string{"DeclRefExpr add", "DeclRefExpr fn"}},
+		{"SwitchStmt BinaryOperator", []string{"BinaryOperator total = 1"}},
+		{"ParmVarDecl + ParmVarDecl", []string{"ParmVarDecl int b", "ParmVarDecl point_t *p"}},
+		{"FunctionDecl[Name=apply] > ParmVarDecl ~ CompoundStmt", []string{describe(src, tu.Inner[5].Children()[2])}},
+		{"CaseStmt + BreakStmt", []string{"BreakStmt break"}},
+		{"CompoundStmt>SwitchStmt", []string{describe(src, tu.Inner[5].Children()[2].Children()[3])}},
+
+		// Attribute predicates, by Go field name and JSON key.
+		{"FunctionDecl[Name=apply] > ParmVarDecl[Name]", []string{"ParmVarDecl int (*fn)(int, int)", "ParmVarDecl point_t *p"}},
+		{"VarDecl[StorageClass]", []string{"VarDecl static int counter"}},
+		{"FunctionDecl[Name!=add]", []string{describe(src, tu.Inner[5])}},
+		{"*[Name^=cou]", []string{"VarDecl static int counter"}},
+		{"*[name$=_t]", []string{"TypedefDecl", "TypedefDecl typedef struct point point_t"}},
+		{"VarDecl[Name*=ota]", []string{"VarDecl int total = add(p->x, p->y)"}},
+		{"FunctionDecl[Name~='^a(dd|pply)$']", []string{describe(src, tu.Inner[4]), describe(src, tu.Inner[5])}},
+		{"MemberExpr[IsArrow]", []string{"MemberExpr p->x", "MemberExpr p->y"}},
+		{"MemberExpr[IsArrow=false]", nil},
+		{"*[opcode=\"+=\"]", []string{"CompoundAssignOperator total += fn(a[1], 1[a])"}},
+		{"VarDecl[Type.QualType='int[4]']", []string{"VarDecl int a[4]"}},
+		{"DeclRefExpr[ReferencedDecl.Kind=ParmVarDecl][ReferencedDecl.Name=p]", []string{"DeclRefExpr p", "DeclRefExpr p"}},
+		{"ParmVarDecl[NoSuchField]", nil},
+
+		// Pseudo-classes.
+		{"CallExpr:has(> ImplicitCastExpr > DeclRefExpr[ReferencedDecl.Name=add])", []string{"CallExpr add(p->x, p->y)"}},
+		{"CaseStmt:has(GotoStmt)", []string{"CaseStmt case 1:\n\tcase 2:\n\t\tgoto out", "CaseStmt case 2:\n\t\tgoto out"}},
+		{"CaseStmt:has(+ BreakStmt)", []string{"CaseStmt case 0:\n\t\ttotal = 1"}},
+		{"CaseStmt:has(~ DefaultStmt):not(:has(CaseStmt))", []string{"CaseStmt case 0:\n\t\ttotal = 1"}},
+		{"CaseStmt:not(:has(CaseStmt), CaseStmt CaseStmt)", []string{"CaseStmt case 0:\n\t\ttotal = 1"}},
+		{"DeclRefExpr:ref(FunctionDecl)", []string{"DeclRefExpr add"}},
+		{"MemberExpr:ref(FieldDecl[Name=y])", []string{"MemberExpr p->y"}},
+		{"CallExpr:ref([Name=add])", []string{"CallExpr add(p->x, p->y)"}},
+		{":root", []string{"TranslationUnitDecl"}},
+		{"SwitchStmt > CompoundStmt > :first-child", []string{"CaseStmt case 0:\n\t\ttotal = 1"}},
+		{"SwitchStmt > CompoundStmt > :last-child", []string{"DefaultStmt default:\n\t\tcounter++"}},
+		{"SwitchStmt > CompoundStmt > :nth-child(2n)", []string{"BreakStmt break", "DefaultStmt default:\n\t\tcounter++"}},
+		{"SwitchStmt > CompoundStmt > :nth-child(3)", []string{"CaseStmt case 1:\n\tcase 2:\n\t\tgoto out"}},
+		{"SwitchStmt > CompoundStmt > :nth-child(-n+2)", []string{"CaseStmt case 0:\n\t\ttotal = 1", "BreakStmt break"}},
+		{"ForStmt > *", []string{"BreakStmt break"}},
+		{"ForStmt :empty, GotoStmt", []string{"GotoStmt goto out", "BreakStmt break"}},
+	} {
+		q, err := Compile(tt.sel)
+		if err != nil {
+			t.Errorf("%v", err)
+			continue
+		}
+
+		var got []string
+		for _, n := range q.All(tu) {
+			got = append(got, describe(src, n))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s matches %q, want %q", tt.sel, got, tt.want)
+		}
+
+		if first, all := q.First(tu), q.All(tu); len(all) == 0 && first != nil || len(all) > 0 && first != all[0] {
+			t.Errorf("%s: First isn't the first node of All", tt.sel)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, tt := range []struct {
+		sel  string
+		want string
+	}{
+		{"", "offset 0: expected selector, got end of query"},
+		{"CallExpr >", "offset 10: expected selector, got end of query"},
+		{"CallExpr, ", "offset 10: expected selector, got end of query"},
+		{"A >> B", "offset 3: expected selector, got '>'"},
+		{"A!B", "offset 1: unexpected '!'"},
+		{"CallExpr)", "offset 8: unexpected ')'"},
+		{"CallExpr[", "offset 9: expected field name"},
+		{"[.Name]", "offset 1: expected field name"},
+		{"CallExpr[Name", "offset 13: expected operator or ']'"},
+		{"[Name!]", "offset 5: expected operator or ']'"},
+		{"CallExpr[Name=x", "offset 15: expected ']', got end of query"},
+		{`CallExpr[Name="x]`, "offset 14: unterminated string"},
+		{"CallExpr[Name='x]", "offset 14: unterminated string"},
+		{`[Name="\q"]`, "offset 6: invalid syntax"},
+		{"[Name~=(]", "offset 7: error parsing regexp: missing closing ): `(`"},
+		{":", "offset 1: expected pseudo-class name"},
+		{"CallExpr:foo", "offset 9: unknown pseudo-class ':foo'"},
+		{":has(CallExpr", "offset 13: expected ')', got end of query"},
+		{":has(CallExpr]", "offset 13: unexpected ']'"},
+		{":not(>A)", "offset 5: expected selector, got '>'"},
+		{":nth-child(x)", `offset 11: strconv.Atoi: parsing "x": invalid syntax`},
+		{":nth-child(2n+1", "offset 11: expected ')'"},
+	} {
+		_, err := Compile(tt.sel)
+		if err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", tt.sel)
+			continue
+		}
+		if want := "query '" + tt.sel + "': " + tt.want; err.Error() != want {
+			t.Errorf("Compile(%q): %v, want %s", tt.sel, err, want)
+		}
+	}
+}
+
+func TestMatchCombinators(t *testing.T) {
+	tu, src := loadTestTU(t)
+
+	// Match checks the combinators against the parents and siblings of the node.
+	ref := MustCompile("DeclRefExpr[ReferencedDecl.Name=total]").All(tu)
+	if len(ref) != 4 {
+		t.Fatalf("found %d references to total, want 4", len(ref))
+	}
+
+	for _, tt := range []struct {
+		sel  string
+		want []bool
+	}{
+		{"FunctionDecl DeclRefExpr", []bool{true, true, true, true}},
+		{"CompoundAssignOperator > DeclRefExpr", []bool{true, false, false, false}},
+		{"SwitchStmt > ImplicitCastExpr > *", []bool{false, true, false, false}},
+		{"CaseStmt > BinaryOperator > :first-child", []bool{false, false, true, false}},
+		{"LabelStmt *", []bool{false, false, false, true}},
+		{"TranslationUnitDecl > DeclRefExpr", []bool{false, false, false, false}},
+	} {
+		q := MustCompile(tt.sel)
+		for i, n := range ref {
+			if got := q.Match(n); got != tt.want[i] {
+				t.Errorf("%s matches %s at offset %d: %v, want %v", tt.sel, describe(src, n),
+					n.GetBaseNode().Range.Begin.Offset, got, tt.want[i])
+			}
+		}
+	}
+}
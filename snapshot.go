@@ -0,0 +1,606 @@
+package goclangast
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+var nodeSliceType = reflect.TypeOf([]Node(nil))
+
+// Snapshots start with snapshotMagic, followed by the format version and a hash of the node types, so snapshots
+// written by other versions of this package are rejected instead of misread.
+const (
+	snapshotMagic   = "GCAS"
+	snapshotVersion = 1
+)
+
+// ErrSnapshotVersion is returned when reading a snapshot written by an incompatible version of this package.
+var ErrSnapshotVersion = errors.New("incompatible snapshot version")
+
+// WriteSnapshot writes a compact binary encoding of tu to w, which can be loaded with ReadSnapshot much faster than
+// the JSON it was parsed from. Strings are stored once in a string table.
+func WriteSnapshot(w io.Writer, tu *TranslationUnitDecl) error {
+	sw := snapWriter{strings: make(map[string]uint64)}
+	if err := sw.node(tu); err != nil {
+		return err
+	}
+
+	out := binary.AppendUvarint([]byte(snapshotMagic), snapshotVersion)
+	out = binary.AppendUvarint(out, snapshotSchema())
+	out = binary.AppendUvarint(out, uint64(len(sw.table)))
+	for _, s := range sw.table {
+		out = binary.AppendUvarint(out, uint64(len(s)))
+		out = append(out, s...)
+	}
+
+	if _, err := w.Write(out); err != nil {
+		return err
+	}
+	_, err := w.Write(sw.buf)
+	return err
+}
+
+func ReadSnapshot(r io.Reader) (*TranslationUnitDecl, error) {
+	return NewParseContext().ReadSnapshot(r)
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot, the strings of the string table are added to the intern pool.
+func (p *ParseContext) ReadSnapshot(r io.Reader) (*TranslationUnitDecl, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) < len(snapshotMagic) || string(b[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, fmt.Errorf("not a snapshot")
+	}
+
+	sr := snapReader{buf: b, pos: len(snapshotMagic)}
+	if sr.uvarint() != snapshotVersion || sr.uvarint() != snapshotSchema() {
+		if sr.err != nil {
+			return nil, sr.err
+		}
+		return nil, ErrSnapshotVersion
+	}
+
+	n := sr.uvarint()
+	if n > uint64(len(b)) {
+		return nil, fmt.Errorf("corrupt snapshot: %d strings", n)
+	}
+	sr.table = make([]string, n)
+	for i := range sr.table {
+		size := sr.uvarint()
+		if sr.err != nil || size > uint64(len(b)-sr.pos) {
+			return nil, fmt.Errorf("corrupt snapshot: string table")
+		}
+		sr.table[i] = p.InternBytes(b[sr.pos : sr.pos+int(size)])
+		sr.pos += int(size)
+	}
+
+	node, err := sr.node()
+	if err != nil {
+		return nil, err
+	}
+	if sr.err != nil {
+		return nil, sr.err
+	}
+
+	tu, ok := node.(*TranslationUnitDecl)
+	if !ok {
+		return nil, fmt.Errorf("expected TranslationUnitDecl, got %T", node)
+	}
+
+	// Locations were already filled in before the snapshot was written and the unknown kinds are part of it.
+	linkNodes(tu)
+	tu.buildIndex()
+
+	return tu, nil
+}
+
+type snapWriter struct {
+	buf     []byte
+	strings map[string]uint64
+	table   []string
+}
+
+func (w *snapWriter) uvarint(v uint64) {
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *snapWriter) string(s string) {
+	i, ok := w.strings[s]
+	if !ok {
+		i = uint64(len(w.table))
+		w.strings[s] = i
+		w.table = append(w.table, s)
+	}
+	w.uvarint(i)
+}
+
+// node writes the kind of the node, followed by its fields. Nil nodes are written as the empty kind.
+func (w *snapWriter) node(n Node) error {
+	if n == nil || reflect.ValueOf(n).IsNil() {
+		w.string("")
+		return nil
+	}
+
+	w.string(n.GetBaseNode().Kind)
+	v := reflect.ValueOf(n).Elem()
+	if err := snapCodecFor(v.Type()).enc(w, v); err != nil {
+		return err
+	}
+
+	if f, ok := n.(*ForStmt); ok {
+		// The slots are written as a mask of the slots present in Inner.
+		var mask uint64
+		if f.slotsConsistent() {
+			for i, s := range f.slots {
+				if s != nil {
+					mask |= 1 << i
+				}
+			}
+		}
+		w.uvarint(mask)
+	}
+
+	return nil
+}
+
+type snapReader struct {
+	buf   []byte
+	pos   int
+	table []string
+	err   error
+}
+
+func (r *snapReader) fail(format string, args ...any) {
+	if r.err == nil {
+		r.err = fmt.Errorf("corrupt snapshot: offset %d: %s", r.pos, fmt.Sprintf(format, args...))
+	}
+}
+
+func (r *snapReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		r.fail("bad varint")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *snapReader) string() string {
+	i := r.uvarint()
+	if i >= uint64(len(r.table)) {
+		r.fail("string %d out of range", i)
+		return ""
+	}
+	return r.table[i]
+}
+
+func (r *snapReader) node() (Node, error) {
+	kind := r.string()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if kind == "" {
+		return nil, nil
+	}
+
+	nodeFn, found := lookupKind(kind)
+	if !found {
+		nodeFn = func() Node { return &UnknownNode{} }
+	}
+
+	n := nodeFn()
+	v := reflect.ValueOf(n).Elem()
+	snapCodecFor(v.Type()).dec(r, v)
+
+	if f, ok := n.(*ForStmt); ok {
+		mask := r.uvarint()
+		i := 0
+		for slot := range f.slots {
+			if mask&(1<<slot) != 0 && i < len(f.Inner) {
+				f.slots[slot] = f.Inner[i]
+				i++
+			}
+		}
+	}
+
+	return n, r.err
+}
+
+// snapCodec encodes and decodes values of one type, codecs are built once per type by reflection.
+type snapCodec struct {
+	enc func(w *snapWriter, v reflect.Value) error
+	dec func(r *snapReader, v reflect.Value)
+}
+
+var snapCodecs sync.Map
+
+func snapCodecFor(t reflect.Type) *snapCodec {
+	if c, ok := snapCodecs.Load(t); ok {
+		return c.(*snapCodec)
+	}
+
+	c, _ := snapCodecs.LoadOrStore(t, newSnapCodec(t))
+	return c.(*snapCodec)
+}
+
+func newSnapCodec(t reflect.Type) *snapCodec {
+	if t == nodeType {
+		return &snapCodec{
+			enc: func(w *snapWriter, v reflect.Value) error {
+				n, _ := v.Interface().(Node)
+				return w.node(n)
+			},
+			dec: func(r *snapReader, v reflect.Value) {
+				n, err := r.node()
+				if err == nil && n != nil {
+					v.Set(reflect.ValueOf(n))
+				}
+			},
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &snapCodec{
+			enc: func(w *snapWriter, v reflect.Value) error {
+				w.string(v.String())
+				return nil
+			},
+			dec: func(r *snapReader, v reflect.Value) {
+				v.SetString(r.string())
+			},
+		}
+
+	case reflect.Bool:
+		return &snapCodec{
+			enc: func(w *snapWriter, v reflect.Value) error {
+				if v.Bool() {
+					w.uvarint(1)
+				} else {
+					w.uvarint(0)
+				}
+				return nil
+			},
+			dec: func(r *snapReader, v reflect.Value) {
+				v.SetBool(r.uvarint() != 0)
+			},
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &snapCodec{
+			enc: func(w *snapWriter, v reflect.Value) error {
+				w.buf = binary.AppendVarint(w.buf, v.Int())
+				return nil
+			},
+			dec: func(r *snapReader, v reflect.Value) {
+				if r.err != nil {
+					return
+				}
+				i, n := binary.Varint(r.buf[r.pos:])
+				if n <= 0 {
+					r.fail("bad varint")
+					return
+				}
+				r.pos += n
+				v.SetInt(i)
+			},
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &snapCodec{
+			enc: func(w *snapWriter, v reflect.Value) error {
+				w.uvarint(v.Uint())
+				return nil
+			},
+			dec: func(r *snapReader, v reflect.Value) {
+				v.SetUint(r.uvarint())
+			},
+		}
+
+	case reflect.Float32, reflect.Float64:
+		return &snapCodec{
+			enc: func(w *snapWriter, v reflect.Value) error {
+				w.uvarint(math.Float64bits(v.Float()))
+				return nil
+			},
+			dec: func(r *snapReader, v reflect.Value) {
+				v.SetFloat(math.Float64frombits(r.uvarint()))
+			},
+		}
+
+	case reflect.Pointer:
+		// The codec of the element is looked up on first use, since types such as Loc refer to themselves.
+		var elemCodec atomic.Pointer[snapCodec]
+		elem := func() *snapCodec {
+			c := elemCodec.Load()
+			if c == nil {
+				c = snapCodecFor(t.Elem())
+				elemCodec.Store(c)
+			}
+			return c
+		}
+
+		return &snapCodec{
+			enc: func(w *snapWriter, v reflect.Value) error {
+				if v.IsNil() {
+					w.uvarint(0)
+					return nil
+				}
+				w.uvarint(1)
+				return elem().enc(w, v.Elem())
+			},
+			dec: func(r *snapReader, v reflect.Value) {
+				if r.uvarint() == 0 {
+					return
+				}
+				v.Set(reflect.New(t.Elem()))
+				elem().dec(r, v.Elem())
+			},
+		}
+
+	case reflect.Slice:
+		if t == nodeSliceType {
+			// Children are decoded into a []Node directly, setting them through reflection is slow.
+			return &snapCodec{
+				enc: func(w *snapWriter, v reflect.Value) error {
+					nodes := v.Interface().([]Node)
+					if nodes == nil {
+						w.uvarint(0)
+						return nil
+					}
+					w.uvarint(uint64(len(nodes)) + 1)
+					for _, n := range nodes {
+						if err := w.node(n); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+				dec: func(r *snapReader, v reflect.Value) {
+					n := r.uvarint()
+					if n == 0 || r.err != nil {
+						return
+					}
+					if n-1 > uint64(len(r.buf)-r.pos) {
+						r.fail("%d children", n-1)
+						return
+					}
+					nodes := make([]Node, n-1)
+					for i := range nodes {
+						node, err := r.node()
+						if err != nil {
+							return
+						}
+						nodes[i] = node
+					}
+					*v.Addr().Interface().(*[]Node) = nodes
+				},
+			}
+		}
+
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &snapCodec{
+				enc: func(w *snapWriter, v reflect.Value) error {
+					if v.IsNil() {
+						w.uvarint(0)
+						return nil
+					}
+					w.uvarint(uint64(v.Len()) + 1)
+					w.buf = append(w.buf, v.Bytes()...)
+					return nil
+				},
+				dec: func(r *snapReader, v reflect.Value) {
+					n := r.uvarint()
+					if n == 0 || r.err != nil {
+						return
+					}
+					if n-1 > uint64(len(r.buf)-r.pos) {
+						r.fail("byte slice of %d bytes", n-1)
+						return
+					}
+					b := make([]byte, n-1)
+					copy(b, r.buf[r.pos:])
+					r.pos += len(b)
+					v.SetBytes(b)
+				},
+			}
+		}
+
+		// Slices are written with their length plus one, so nil slices can be told apart from empty ones.
+		return &snapCodec{
+			enc: func(w *snapWriter, v reflect.Value) error {
+				if v.IsNil() {
+					w.uvarint(0)
+					return nil
+				}
+				w.uvarint(uint64(v.Len()) + 1)
+				elem := snapCodecFor(t.Elem())
+				for i := 0; i < v.Len(); i++ {
+					if err := elem.enc(w, v.Index(i)); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			dec: func(r *snapReader, v reflect.Value) {
+				n := r.uvarint()
+				if n == 0 || r.err != nil {
+					return
+				}
+				if n-1 > uint64(len(r.buf)-r.pos) {
+					r.fail("slice of %d elements", n-1)
+					return
+				}
+				v.Set(reflect.MakeSlice(t, int(n-1), int(n-1)))
+				elem := snapCodecFor(t.Elem())
+				for i := 0; i < int(n-1) && r.err == nil; i++ {
+					elem.dec(r, v.Index(i))
+				}
+			},
+		}
+
+	case reflect.Map:
+		return &snapCodec{
+			enc: func(w *snapWriter, v reflect.Value) error {
+				if v.IsNil() {
+					w.uvarint(0)
+					return nil
+				}
+				w.uvarint(uint64(v.Len()) + 1)
+
+				// Keys are sorted so equal trees produce equal snapshots.
+				keys := v.MapKeys()
+				sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+
+				key, elem := snapCodecFor(t.Key()), snapCodecFor(t.Elem())
+				for _, k := range keys {
+					if err := key.enc(w, k); err != nil {
+						return err
+					}
+					if err := elem.enc(w, v.MapIndex(k)); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			dec: func(r *snapReader, v reflect.Value) {
+				n := r.uvarint()
+				if n == 0 || r.err != nil {
+					return
+				}
+				if n-1 > uint64(len(r.buf)-r.pos) {
+					r.fail("map of %d elements", n-1)
+					return
+				}
+				m := reflect.MakeMapWithSize(t, int(n-1))
+				key, elem := snapCodecFor(t.Key()), snapCodecFor(t.Elem())
+				for i := 0; i < int(n-1) && r.err == nil; i++ {
+					k := reflect.New(t.Key()).Elem()
+					e := reflect.New(t.Elem()).Elem()
+					key.dec(r, k)
+					elem.dec(r, e)
+					m.SetMapIndex(k, e)
+				}
+				v.Set(m)
+			},
+		}
+
+	case reflect.Struct:
+		type field struct {
+			index []int
+			codec *snapCodec
+		}
+		var fields []field
+		for _, f := range snapFields(t) {
+			fields = append(fields, field{index: f.Index, codec: snapCodecFor(f.Type)})
+		}
+
+		return &snapCodec{
+			enc: func(w *snapWriter, v reflect.Value) error {
+				for _, f := range fields {
+					if err := f.codec.enc(w, v.FieldByIndex(f.index)); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			dec: func(r *snapReader, v reflect.Value) {
+				for _, f := range fields {
+					if r.err != nil {
+						return
+					}
+					f.codec.dec(r, v.FieldByIndex(f.index))
+				}
+			},
+		}
+	}
+
+	err := fmt.Errorf("snapshot: unsupported type %s", t)
+	return &snapCodec{
+		enc: func(w *snapWriter, v reflect.Value) error {
+			return err
+		},
+		dec: func(r *snapReader, v reflect.Value) {
+			r.fail("%v", err)
+		},
+	}
+}
+
+// snapFields returns the exported fields of a struct written to snapshots, including the ones excluded from JSON such
+// as record layouts.
+func snapFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for _, f := range reflect.VisibleFields(t) {
+		if f.IsExported() && !f.Anonymous {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+var (
+	snapSchemaOnce sync.Once
+	snapSchemaHash uint64
+)
+
+// snapshotSchema returns a hash of the fields of all node types, which changes whenever the encoding of a node does.
+func snapshotSchema() uint64 {
+	snapSchemaOnce.Do(func() {
+		kinds := make([]string, 0, len(getKindMap()))
+		for kind := range getKindMap() {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+
+		h := fnv.New64a()
+		seen := make(map[reflect.Type]bool)
+		var hashType func(t reflect.Type)
+		hashType = func(t reflect.Type) {
+			fmt.Fprintf(h, "%s;", t)
+			if seen[t] {
+				return
+			}
+			seen[t] = true
+
+			switch t.Kind() {
+			case reflect.Pointer, reflect.Slice:
+				hashType(t.Elem())
+			case reflect.Map:
+				hashType(t.Key())
+				hashType(t.Elem())
+			case reflect.Struct:
+				for _, f := range snapFields(t) {
+					fmt.Fprintf(h, "%s:", f.Name)
+					hashType(f.Type)
+				}
+			}
+		}
+
+		for _, kind := range kinds {
+			fmt.Fprintf(h, "%s=", kind)
+			hashType(reflect.TypeOf(getKindMap()[kind]()).Elem())
+		}
+		hashType(reflect.TypeOf(UnknownNode{}))
+		hashType(reflect.TypeOf(OMPClause{}))
+
+		snapSchemaHash = h.Sum64()
+	})
+
+	return snapSchemaHash
+}
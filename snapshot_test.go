@@ -0,0 +1,147 @@
+package goclangast
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// snapshotTestdata are the dumps snapshots are tested on, the round trip corpus and the dumps covering C++, OpenMP,
+// Objective-C, type nodes and record layouts.
+func snapshotTestdata(t testing.TB) []string {
+	paths, err := filepath.Glob("testdata/roundtrip/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(paths, "testdata/cxx/classes.json", "testdata/types/types.json", "testdata/stmts/stmts.json",
+		"testdata/objc/messages.json", "testdata/openmp/parallel.json")
+}
+
+func writeTestSnapshot(t testing.TB, tu *TranslationUnitDecl) []byte {
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, tu); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	for _, path := range snapshotTestdata(t) {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			want := parseTestdata(t, path)
+
+			got, err := ReadSnapshot(bytes.NewReader(writeTestSnapshot(t, want)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wantJSON, err := MarshalNode(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotJSON, err := MarshalNode(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(gotJSON, wantJSON) {
+				t.Error("the snapshot encodes to different JSON than the dump")
+			}
+
+			// The tree is linked and indexed like a parsed one.
+			wantNodes, gotNodes := flatten(want), flatten(got)
+			for i, n := range gotNodes {
+				if i > 0 && n.Parent() == nil {
+					t.Fatalf("node %d (%s) has no parent", i, n.GetBaseNode().Kind)
+				}
+				if id := n.GetBaseNode().ID; id != "" && got.Lookup(id) != n {
+					t.Fatalf("looking up node %d (%s %s) gives %v", i, n.GetBaseNode().Kind, id, got.Lookup(id))
+				}
+				if (wantNodes[i].NextSibling() == nil) != (n.NextSibling() == nil) {
+					t.Fatalf("node %d (%s) has different siblings", i, n.GetBaseNode().Kind)
+				}
+			}
+		})
+	}
+}
+
+func TestSnapshotVersionMismatch(t *testing.T) {
+	b := writeTestSnapshot(t, parseTestdata(t, "testdata/roundtrip/loops.json"))
+
+	// The magic is followed by the version and the schema hash.
+	header := append([]byte(snapshotMagic), 1)
+	schema := snapshotSchema()
+	for _, tt := range []struct {
+		name string
+		b    []byte
+	}{
+		{"version", append([]byte(snapshotMagic), 2)},
+		{"schema", binary.AppendUvarint(header, schema+1)},
+	} {
+		in := append(tt.b, b[len(tt.b):]...)
+		if _, err := ReadSnapshot(bytes.NewReader(in)); !errors.Is(err, ErrSnapshotVersion) {
+			t.Errorf("%s mismatch: got %v, want %v", tt.name, err, ErrSnapshotVersion)
+		}
+	}
+
+	if _, err := ReadSnapshot(bytes.NewReader([]byte(`{"kind":"TranslationUnitDecl"}`))); err == nil {
+		t.Error("read JSON as snapshot")
+	}
+}
+
+// TestSnapshotTruncated checks that every truncation of a snapshot is reported as an error.
+func TestSnapshotTruncated(t *testing.T) {
+	b := writeTestSnapshot(t, parseTestdata(t, "testdata/stmts/stmts.json"))
+	ctx := NewParseContext()
+	for i := 0; i < len(b); i++ {
+		if _, err := ctx.ReadSnapshot(bytes.NewReader(b[:i])); err == nil {
+			t.Fatalf("snapshot truncated to %d of %d bytes read without error", i, len(b))
+		}
+	}
+}
+
+// benchmarkDump is the dump BenchmarkReadSnapshot and BenchmarkParseTU load, as a snapshot and as JSON. Both report
+// the throughput in bytes of JSON, and share a ParseContext across iterations, as creating one costs more than loading
+// these small dumps.
+const benchmarkDump = "testdata/cxx/classes.json"
+
+func BenchmarkReadSnapshot(b *testing.B) {
+	data, err := os.ReadFile(benchmarkDump)
+	if err != nil {
+		b.Fatal(err)
+	}
+	tu, err := ParseTU(bytes.NewBuffer(data))
+	if err != nil {
+		b.Fatal(err)
+	}
+	snap := writeTestSnapshot(b, tu)
+	ctx := NewParseContext()
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ctx.ReadSnapshot(bytes.NewReader(snap)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseTU(b *testing.B) {
+	data, err := os.ReadFile(benchmarkDump)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := NewParseContext()
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ctx.ParseTU(bytes.NewBuffer(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
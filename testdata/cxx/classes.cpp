@@ -0,0 +1,27 @@
+struct Base {
+	virtual int area() const = 0;
+};
+
+struct Square : public virtual Base {
+	int side;
+	int area() const override;
+	static Square unit();
+	Square operator+(const Square &o) const;
+	template <typename U> U as() const;
+};
+
+typedef Square square_t;
+
+template <typename T = int>
+struct Box {
+	T value;
+};
+
+int use(Square *s, const Square &t)
+{
+	Square u = Square::unit();
+	Square sum = *s + t;
+	int *p = new int[4];
+	::delete[] p;
+	return s->area() + sum.side;
+}
@@ -0,0 +1,42 @@
+struct padded {
+	char c;
+	long l;
+	short s;
+};
+
+struct flags {
+	unsigned int ready : 1;
+	unsigned int mode : 3;
+	unsigned int : 4;
+	unsigned char tail;
+};
+
+union value {
+	int i;
+	double d;
+	unsigned int low : 4;
+	char bytes[8];
+};
+
+struct __attribute__((packed)) packed {
+	char tag;
+	int value;
+	double d;
+};
+
+enum color { RED, GREEN = 5, BLUE };
+
+enum { FLAG_A = 1, FLAG_B = -2 };
+
+typedef struct {
+	struct {
+		int x, y;
+	} pos;
+	union {
+		int id;
+		float weight;
+	};
+	enum color color;
+} item_t;
+
+static const int max_items = 16;
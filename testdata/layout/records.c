@@ -0,0 +1,27 @@
+#define DECLARE_ANON struct { int a; } anon_var
+
+struct pair {
+	int a;
+	long b;
+};
+
+struct outer {
+	struct {
+		char c;
+		short s;
+	} inner;
+	int x;
+};
+
+typedef struct {
+	long l;
+} named_t;
+
+DECLARE_ANON;
+
+void fn(void)
+{
+	struct pair {
+		char c;
+	} local;
+}
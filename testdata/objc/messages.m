@@ -0,0 +1,42 @@
+@protocol Named
+@property(nonatomic, copy, readonly) id name;
+@optional
+@property(nonatomic, strong, setter=changeNickname:) id nickname;
+@end
+
+@interface Base
++ (id)alloc;
+- (id)init;
+@end
+
+@interface Person : Base <Named> {
+@private
+	int _secret;
+}
+@property(readonly, getter=years) int age;
++ (id)personWithAge:(int)age;
+- (id)initWithName:(id)name age:(int)age;
+@end
+
+@interface Person (Greeting)
+- (void)greet;
+@end
+
+@implementation Person
++ (id)personWithAge:(int)age {
+	id p = [super alloc];
+	return [p initWithName:0 age:age];
+}
+- (id)initWithName:(id)name age:(int)age {
+	self = [super init];
+	return self;
+}
+- (int)years {
+	return 0;
+}
+@end
+
+int main(void) {
+	Person *p = [Person personWithAge:3];
+	return [p years];
+}
@@ -0,0 +1,15 @@
+void f(int n, int *a)
+{
+	int sum = 0;
+	int tmp;
+
+#pragma omp parallel for private(tmp) reduction(+:sum) collapse(2) schedule(static, 4)
+	for (int i = 0; i < n; i++)
+		for (int j = 0; j < n; j++) {
+			tmp = a[i] * j;
+			sum += tmp;
+		}
+
+#pragma omp task
+	sum++;
+}
@@ -0,0 +1,4 @@
+#include "lines.h"
+
+#line 100 "generated.c"
+struct point origin;
@@ -0,0 +1,19 @@
+#define ZERO 0
+
+int sum(int n)
+{
+	int s = ZERO;
+
+	for (int i = 0; i < n; i++)
+		s += i;
+	for (;;)
+		break;
+	for (; n > 0;)
+		n--;
+
+	int (^twice)(int) = ^(int x) {
+		return x * 2;
+	};
+
+	return twice(s);
+}
@@ -0,0 +1,35 @@
+struct point {
+	int x;
+	int y;
+};
+
+typedef struct point point_t;
+
+static int counter;
+
+int add(int a, int b)
+{
+	return a + b;
+}
+
+int apply(int (*fn)(int, int), point_t *p)
+{
+	int a[4];
+	int total = add(p->x, p->y);
+
+	total += fn(a[1], 1[a]);
+	switch (total) {
+	case 0:
+		total = 1;
+		break;
+	case 1:
+	case 2:
+		goto out;
+	default:
+		counter++;
+	}
+	for (;;)
+		break;
+out:
+	return total;
+}
@@ -0,0 +1,23 @@
+#define __user __attribute__((btf_type_tag("user")))
+
+typedef int incomplete_t[];
+typedef _Atomic(int) atomic_t;
+typedef int v4si __attribute__((vector_size(16)));
+typedef __attribute__((neon_vector_type(4))) int int32x4_t;
+typedef float float2 __attribute__((ext_vector_type(2)));
+typedef _Complex double complex_t;
+typedef unsigned _BitInt(7) u7;
+typedef _BitInt(128) i128;
+typedef int knr_t();
+typedef int __user *user_ptr;
+typedef int *_Nonnull nonnull_ptr;
+typedef void fatal_t(void) __attribute__((noreturn));
+typedef void params_t(int a[static 4], int b[const 2], int n, int c[*]);
+
+int global;
+typedef typeof(global) global_t;
+
+void f(int n)
+{
+	typedef int vla_t[n + 1];
+}
@@ -0,0 +1,69 @@
+package goclangast
+
+import "errors"
+
+var (
+	// SkipChildren can be returned by Visitor.Enter to skip the children of the node. Leave is still called for it.
+	SkipChildren = errors.New("skip children")
+	// Stop can be returned by Visitor.Enter or Visitor.Leave to end the walk, Walk then returns nil.
+	Stop = errors.New("stop walk")
+)
+
+// Visitor is called by Walk for every node in the tree.
+type Visitor interface {
+	// Enter is called before the children of n are walked.
+	Enter(n Node) error
+	// Leave is called after the children of n have been walked.
+	Leave(n Node) error
+}
+
+// Walk traverses the tree rooted at n in depth-first order. Any error returned by the visitor other than SkipChildren
+// and Stop ends the walk and is returned, without calling Leave for the nodes still being walked.
+func Walk(v Visitor, n Node) error {
+	err := walk(v, n)
+	if err == Stop {
+		return nil
+	}
+	return err
+}
+
+func walk(v Visitor, n Node) error {
+	err := v.Enter(n)
+	switch err {
+	case nil:
+		for _, child := range n.Children() {
+			if child == nil {
+				continue
+			}
+
+			if err := walk(v, child); err != nil {
+				return err
+			}
+		}
+	case SkipChildren:
+	default:
+		return err
+	}
+
+	err = v.Leave(n)
+	if err == SkipChildren {
+		return nil
+	}
+	return err
+}
+
+// Inspect traverses the tree rooted at n in depth-first order, like ast.Inspect of go/ast. It calls f(n) for every
+// node, if f returns true the children of n are inspected, followed by a call to f(nil).
+func Inspect(n Node, f func(Node) bool) {
+	if !f(n) {
+		return
+	}
+
+	for _, child := range n.Children() {
+		if child != nil {
+			Inspect(child, f)
+		}
+	}
+
+	f(nil)
+}
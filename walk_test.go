@@ -0,0 +1,201 @@
+package goclangast
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// traceVisitor records the kinds of the nodes entered and left, enter and leave return the result for a node.
+type traceVisitor struct {
+	trace []string
+	enter func(n Node) error
+	leave func(n Node) error
+}
+
+func (v *traceVisitor) Enter(n Node) error {
+	v.trace = append(v.trace, n.GetBaseNode().Kind)
+	if v.enter != nil {
+		return v.enter(n)
+	}
+	return nil
+}
+
+func (v *traceVisitor) Leave(n Node) error {
+	v.trace = append(v.trace, "/"+n.GetBaseNode().Kind)
+	if v.leave != nil {
+		return v.leave(n)
+	}
+	return nil
+}
+
+func TestWalk(t *testing.T) {
+	add := stmtsFunc(t, "add")
+
+	v := &traceVisitor{}
+	if err := Walk(v, add); err != nil {
+		t.Fatal(err)
+	}
+	want := "FunctionDecl ParmVarDecl /ParmVarDecl ParmVarDecl /ParmVarDecl CompoundStmt ReturnStmt BinaryOperator " +
+		"ImplicitCastExpr DeclRefExpr /DeclRefExpr /ImplicitCastExpr ImplicitCastExpr DeclRefExpr /DeclRefExpr " +
+		"/ImplicitCastExpr /BinaryOperator /ReturnStmt /CompoundStmt /FunctionDecl"
+	if got := strings.Join(v.trace, " "); got != want {
+		t.Errorf("walk visits\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWalkSkipChildren(t *testing.T) {
+	add := stmtsFunc(t, "add")
+
+	// The children of a skipped node aren't walked, but it is still left. SkipChildren from Leave is ignored.
+	v := &traceVisitor{
+		enter: func(n Node) error {
+			if _, ok := n.(*ReturnStmt); ok {
+				return SkipChildren
+			}
+			return nil
+		},
+		leave: func(n Node) error {
+			return SkipChildren
+		},
+	}
+	if err := Walk(v, add); err != nil {
+		t.Fatal(err)
+	}
+	want := "FunctionDecl ParmVarDecl /ParmVarDecl ParmVarDecl /ParmVarDecl CompoundStmt ReturnStmt /ReturnStmt " +
+		"/CompoundStmt /FunctionDecl"
+	if got := strings.Join(v.trace, " "); got != want {
+		t.Errorf("walk visits\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWalkStop(t *testing.T) {
+	add := stmtsFunc(t, "add")
+
+	// Stop ends the walk without leaving the nodes being walked, and isn't returned.
+	v := &traceVisitor{
+		enter: func(n Node) error {
+			if _, ok := n.(*DeclRefExpr); ok {
+				return Stop
+			}
+			return nil
+		},
+	}
+	if err := Walk(v, add); err != nil {
+		t.Fatalf("walk returns %v, want nil", err)
+	}
+	want := "FunctionDecl ParmVarDecl /ParmVarDecl ParmVarDecl /ParmVarDecl CompoundStmt ReturnStmt BinaryOperator " +
+		"ImplicitCastExpr DeclRefExpr"
+	if got := strings.Join(v.trace, " "); got != want {
+		t.Errorf("walk visits\n%s\nwant\n%s", got, want)
+	}
+
+	// Stop from Leave ends the walk as well.
+	v = &traceVisitor{
+		leave: func(n Node) error {
+			return Stop
+		},
+	}
+	if err := Walk(v, add); err != nil {
+		t.Fatalf("walk returns %v, want nil", err)
+	}
+	if got, want := strings.Join(v.trace, " "), "FunctionDecl ParmVarDecl /ParmVarDecl"; got != want {
+		t.Errorf("walk visits %s, want %s", got, want)
+	}
+}
+
+func TestWalkError(t *testing.T) {
+	add := stmtsFunc(t, "add")
+	errTest := errors.New("test")
+
+	for _, tc := range []struct {
+		name  string
+		v     *traceVisitor
+		trace string
+	}{
+		{
+			name: "enter",
+			v: &traceVisitor{enter: func(n Node) error {
+				if _, ok := n.(*CompoundStmt); ok {
+					return errTest
+				}
+				return nil
+			}},
+			trace: "FunctionDecl ParmVarDecl /ParmVarDecl ParmVarDecl /ParmVarDecl CompoundStmt",
+		},
+		{
+			name: "leave",
+			v: &traceVisitor{leave: func(n Node) error {
+				if _, ok := n.(*ParmVarDecl); ok {
+					return errTest
+				}
+				return nil
+			}},
+			trace: "FunctionDecl ParmVarDecl /ParmVarDecl",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := Walk(tc.v, add); err != errTest {
+				t.Errorf("walk returns %v, want %v", err, errTest)
+			}
+			if got := strings.Join(tc.v.trace, " "); got != tc.trace {
+				t.Errorf("walk visits %s, want %s", got, tc.trace)
+			}
+		})
+	}
+}
+
+func TestWalkNilChildren(t *testing.T) {
+	// The placeholders of the absent slots of for (;;) aren't children, nil children are skipped.
+	loop := nodesOfKind(stmtsFunc(t, "apply"), "ForStmt")[0]
+	v := &traceVisitor{}
+	if err := Walk(v, loop); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(v.trace, " "), "ForStmt BreakStmt /BreakStmt /ForStmt"; got != want {
+		t.Errorf("walk visits %s, want %s", got, want)
+	}
+
+	s := &CompoundStmt{BaseNode: BaseNode{Kind: "CompoundStmt"}}
+	s.Inner = []Node{nil, &NullStmt{BaseNode: BaseNode{Kind: "NullStmt"}}, nil}
+	v = &traceVisitor{}
+	if err := Walk(v, s); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(v.trace, " "), "CompoundStmt NullStmt /NullStmt /CompoundStmt"; got != want {
+		t.Errorf("walk visits %s, want %s", got, want)
+	}
+
+	var kinds []string
+	Inspect(s, func(n Node) bool {
+		if n != nil {
+			kinds = append(kinds, n.GetBaseNode().Kind)
+		}
+		return true
+	})
+	if want := []string{"CompoundStmt", "NullStmt"}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("inspect visits %v, want %v", kinds, want)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	add := stmtsFunc(t, "add")
+
+	// f(nil) follows the children of every node whose children are inspected, pruned nodes don't get one.
+	var trace []string
+	Inspect(add, func(n Node) bool {
+		if n == nil {
+			trace = append(trace, ")")
+			return true
+		}
+		trace = append(trace, n.GetBaseNode().Kind)
+		_, isCast := n.(*ImplicitCastExpr)
+		return !isCast
+	})
+	want := "FunctionDecl ParmVarDecl ) ParmVarDecl ) CompoundStmt ReturnStmt BinaryOperator ImplicitCastExpr " +
+		"ImplicitCastExpr ) ) ) )"
+	if got := strings.Join(trace, " "); got != want {
+		t.Errorf("inspect visits\n%s\nwant\n%s", got, want)
+	}
+}